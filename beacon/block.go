@@ -1,7 +1,10 @@
 package beacon
 
 import (
+	"errors"
+
 	"github.com/Layr-Labs/eigenpod-proofs-generation/common"
+	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	ssz "github.com/ferranbt/fastssz"
 )
@@ -43,3 +46,48 @@ func GetBlockHeaderFieldRoots(blockHeader *phase0.BeaconBlockHeader) ([]phase0.R
 
 	return blockHeaderContainerRoots, nil
 }
+
+// GetSignedBlockHeader builds the signed header that corresponds to a full signed beacon block.
+func GetSignedBlockHeader(block *spec.VersionedSignedBeaconBlock) (*phase0.SignedBeaconBlockHeader, error) {
+	var signature phase0.BLSSignature
+	switch block.Version {
+	case spec.DataVersionCapella:
+		signature = block.Capella.Signature
+	case spec.DataVersionDeneb:
+		signature = block.Deneb.Signature
+	default:
+		return nil, errors.New("unsupported beacon block version")
+	}
+
+	slot, err := block.Slot()
+	if err != nil {
+		return nil, err
+	}
+	proposerIndex, err := block.ProposerIndex()
+	if err != nil {
+		return nil, err
+	}
+	parentRoot, err := block.ParentRoot()
+	if err != nil {
+		return nil, err
+	}
+	stateRoot, err := block.StateRoot()
+	if err != nil {
+		return nil, err
+	}
+	bodyRoot, err := block.BodyRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &phase0.SignedBeaconBlockHeader{
+		Message: &phase0.BeaconBlockHeader{
+			Slot:          slot,
+			ProposerIndex: proposerIndex,
+			ParentRoot:    parentRoot,
+			StateRoot:     stateRoot,
+			BodyRoot:      bodyRoot,
+		},
+		Signature: signature,
+	}, nil
+}
@@ -96,3 +96,26 @@ func MarshalSSZVersionedBeaconState(beaconState spec.VersionedBeaconState) ([]by
 
 	return data, nil
 }
+
+func UnmarshalSSZVersionedSignedBeaconBlock(data []byte) (*spec.VersionedSignedBeaconBlock, error) {
+	block := &spec.VersionedSignedBeaconBlock{}
+	denebBlock := &deneb.SignedBeaconBlock{}
+	// Try to unmarshal using Deneb
+	err := denebBlock.UnmarshalSSZ(data)
+	if err != nil {
+		// If Deneb fails, try Capella
+		capellaBlock := &capella.SignedBeaconBlock{}
+		err = capellaBlock.UnmarshalSSZ(data)
+		if err != nil {
+			return nil, err
+		} else {
+			block.Capella = capellaBlock
+			block.Version = spec.DataVersionCapella
+		}
+	} else {
+		block.Deneb = denebBlock
+		block.Version = spec.DataVersionDeneb
+	}
+
+	return block, nil
+}
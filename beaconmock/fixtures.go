@@ -0,0 +1,239 @@
+package beaconmock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/era"
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type fixtureState struct {
+	slot  phase0.Slot
+	root  phase0.Root
+	state *spec.VersionedBeaconState
+	ssz   []byte // encoded lazily, states can be large
+}
+
+// Fixtures are the beacon states and block headers served by a mock beacon node.
+type Fixtures struct {
+	// The chain (see package chains) whose config the node reports. Defaults to holesky.
+	ChainID uint64
+
+	states  []*fixtureState         // ascending by slot
+	headers []*v1.BeaconBlockHeader // ascending by slot
+}
+
+func NewFixtures() *Fixtures {
+	return &Fixtures{
+		ChainID: chains.Holesky.ChainID,
+		states:  []*fixtureState{},
+		headers: []*v1.BeaconBlockHeader{},
+	}
+}
+
+// LoadFixtures reads every beacon state (`*.ssz`, `*.ssz_snappy`) and block header (`*.json`, in the
+// format of `data/deneb_holesky_beacon_headers_*.json`) in `dir`.
+//
+// Each state also contributes its latest block header, so a directory of states alone is enough to
+// serve `/eth/v1/beacon/headers` lookups for those slots.
+func LoadFixtures(dir string) (*Fixtures, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture directory: %w", err)
+	}
+
+	fixtures := NewFixtures()
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		switch {
+		case strings.HasSuffix(entry.Name(), ".ssz"), strings.HasSuffix(entry.Name(), ".ssz_snappy"):
+			state, err := era.ReadStateFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read state fixture %s: %w", path, err)
+			}
+			if err := fixtures.AddState(state); err != nil {
+				return nil, fmt.Errorf("failed to add state fixture %s: %w", path, err)
+			}
+		case strings.HasSuffix(entry.Name(), ".json"):
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			var header phase0.BeaconBlockHeader
+			if err := json.Unmarshal(data, &header); err != nil {
+				return nil, fmt.Errorf("failed to parse header fixture %s: %w", path, err)
+			}
+			if err := fixtures.AddHeader(&header); err != nil {
+				return nil, fmt.Errorf("failed to add header fixture %s: %w", path, err)
+			}
+		}
+	}
+
+	return fixtures, nil
+}
+
+// AddState serves `state` by slot and state root, and adds its latest block header.
+func (f *Fixtures) AddState(state *spec.VersionedBeaconState) error {
+	slot, err := state.Slot()
+	if err != nil {
+		return err
+	}
+	data, err := stateData(state)
+	if err != nil {
+		return err
+	}
+	root, err := data.HashTreeRoot()
+	if err != nil {
+		return err
+	}
+
+	f.states = append(f.states, &fixtureState{slot: slot, root: root, state: state})
+	sort.Slice(f.states, func(i, j int) bool {
+		return f.states[i].slot < f.states[j].slot
+	})
+
+	header, err := latestBlockHeader(state, root)
+	if err != nil {
+		return err
+	}
+	if _, err := f.header(strconv.FormatUint(uint64(header.Slot), 10)); errors.Is(err, ErrNotFound) {
+		return f.AddHeader(header)
+	}
+	return nil
+}
+
+// AddHeader serves `header` by slot and block root. A header for a slot that already has one replaces it.
+func (f *Fixtures) AddHeader(header *phase0.BeaconBlockHeader) error {
+	blockHeader, err := toBlockHeader(header)
+	if err != nil {
+		return err
+	}
+
+	headers := []*v1.BeaconBlockHeader{}
+	for _, existing := range f.headers {
+		if existing.Header.Message.Slot != header.Slot {
+			headers = append(headers, existing)
+		}
+	}
+	headers = append(headers, blockHeader)
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].Header.Message.Slot < headers[j].Header.Message.Slot
+	})
+	f.headers = headers
+	return nil
+}
+
+func toBlockHeader(header *phase0.BeaconBlockHeader) (*v1.BeaconBlockHeader, error) {
+	root, err := header.HashTreeRoot()
+	if err != nil {
+		return nil, err
+	}
+	return &v1.BeaconBlockHeader{
+		Root:      root,
+		Canonical: true,
+		Header: &phase0.SignedBeaconBlockHeader{
+			Message: header,
+		},
+	}, nil
+}
+
+// The latest block header in a state has a zero state root until the next slot is processed.
+func latestBlockHeader(state *spec.VersionedBeaconState, stateRoot phase0.Root) (*phase0.BeaconBlockHeader, error) {
+	var header phase0.BeaconBlockHeader
+	switch state.Version {
+	case spec.DataVersionDeneb:
+		header = *state.Deneb.LatestBlockHeader
+	case spec.DataVersionCapella:
+		header = *state.Capella.LatestBlockHeader
+	default:
+		return nil, fmt.Errorf("unsupported beacon state version %s", state.Version)
+	}
+
+	if header.StateRoot == (phase0.Root{}) {
+		header.StateRoot = stateRoot
+	}
+	return &header, nil
+}
+
+// resolves a state id: head/finalized/justified (the latest state), genesis, a slot, or a 0x state root.
+func (f *Fixtures) state(stateId string) (*fixtureState, error) {
+	if len(f.states) == 0 {
+		return nil, fmt.Errorf("%w: no states loaded", ErrNotFound)
+	}
+
+	switch {
+	case stateId == "head", stateId == "finalized", stateId == "justified":
+		return f.states[len(f.states)-1], nil
+	case stateId == "genesis":
+		stateId = "0"
+	case strings.HasPrefix(stateId, "0x"):
+		root := common.HexToHash(stateId)
+		for _, state := range f.states {
+			if state.root == phase0.Root(root) {
+				return state, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: state %s", ErrNotFound, stateId)
+	}
+
+	slot, err := strconv.ParseUint(stateId, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state id %s", stateId)
+	}
+	for _, state := range f.states {
+		if state.slot == phase0.Slot(slot) {
+			return state, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: state %s", ErrNotFound, stateId)
+}
+
+// resolves a block id: head/finalized/justified (the latest header), a slot, or a 0x block root.
+func (f *Fixtures) header(blockId string) (*v1.BeaconBlockHeader, error) {
+	if len(f.headers) == 0 {
+		return nil, fmt.Errorf("%w: no headers loaded", ErrNotFound)
+	}
+
+	switch {
+	case blockId == "head", blockId == "finalized", blockId == "justified":
+		return f.headers[len(f.headers)-1], nil
+	case blockId == "genesis":
+		blockId = "0"
+	case strings.HasPrefix(blockId, "0x"):
+		root := common.HexToHash(blockId)
+		for _, header := range f.headers {
+			if header.Root == phase0.Root(root) {
+				return header, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: block %s", ErrNotFound, blockId)
+	}
+
+	slot, err := strconv.ParseUint(blockId, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block id %s", blockId)
+	}
+	for _, header := range f.headers {
+		if header.Header.Message.Slot == phase0.Slot(slot) {
+			return header, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: block %s", ErrNotFound, blockId)
+}
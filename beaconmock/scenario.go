@@ -0,0 +1,128 @@
+package beaconmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+type Action string
+
+const (
+	// respond with a 404, as a node that has pruned the state would
+	ActionMissing Action = "missing"
+	// respond with `Status` (500 by default)
+	ActionError Action = "error"
+	// wait for `Delay` before responding normally
+	ActionSlow Action = "slow"
+	// serve `Header` in place of the fixture header, as if the chain had reorged
+	ActionReorg Action = "reorg"
+)
+
+// Scenario scripts a misbehaviour of the mock beacon node, e.g
+//
+//	{"path": "/eth/v2/debug/beacon/states/", "action": "slow", "delay": "5s", "times": 1}
+type Scenario struct {
+	// Applies to requests whose path starts with this prefix.
+	Path   string `json:"path"`
+	Action Action `json:"action"`
+
+	Delay  Duration                  `json:"delay,omitempty"`
+	Status int                       `json:"status,omitempty"`
+	Header *phase0.BeaconBlockHeader `json:"header,omitempty"`
+
+	// Let this many matching requests through untouched before applying the scenario.
+	After int `json:"after,omitempty"`
+	// Apply to at most this many requests (0 means every matching request).
+	Times int `json:"times,omitempty"`
+}
+
+// Duration is a time.Duration written as a string in scenario files ("250ms", "5s").
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+func (s *Scenario) validate() error {
+	if !strings.HasPrefix(s.Path, "/") {
+		return fmt.Errorf("scenario path must start with '/': %s", s.Path)
+	}
+
+	switch s.Action {
+	case ActionMissing, ActionError:
+	case ActionSlow:
+		if s.Delay <= 0 {
+			return fmt.Errorf("slow scenario for %s needs a delay", s.Path)
+		}
+	case ActionReorg:
+		if s.Header == nil {
+			return fmt.Errorf("reorg scenario for %s needs a header", s.Path)
+		}
+		if !strings.HasPrefix(s.Path, headersPath) {
+			return fmt.Errorf("reorg scenarios only apply to %s", headersPath)
+		}
+	default:
+		return fmt.Errorf("unknown scenario action: %s", s.Action)
+	}
+	return nil
+}
+
+// LoadScenarios reads a JSON list of scenarios.
+func LoadScenarios(path string) ([]Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenarios []Scenario
+	if err := json.Unmarshal(data, &scenarios); err != nil {
+		return nil, fmt.Errorf("failed to parse scenarios: %w", err)
+	}
+	for i := range scenarios {
+		if err := scenarios[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return scenarios, nil
+}
+
+type scenarioState struct {
+	Scenario
+	seen    int
+	applied int
+}
+
+// counts the request against the scenario, and returns whether the scenario applies to it.
+func (s *scenarioState) matches(path string) bool {
+	if !strings.HasPrefix(path, s.Path) {
+		return false
+	}
+
+	s.seen++
+	if s.seen <= s.After {
+		return false
+	}
+	if s.Times > 0 && s.applied >= s.Times {
+		return false
+	}
+	s.applied++
+	return true
+}
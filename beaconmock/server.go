@@ -0,0 +1,472 @@
+package beaconmock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	eventsPath     = "/eth/v1/events"
+	headersPath    = "/eth/v1/beacon/headers/"
+	statesPath     = "/eth/v2/debug/beacon/states/"
+	validatorsPath = "/eth/v1/beacon/states/"
+)
+
+// reported by /eth/v1/node/version
+const NodeVersion = "eigenpod-proofs-generation/beaconmock"
+
+// Server is a minimal beacon API serving fixtures, with enough of the config endpoints
+// for go-eth2-client (and so `core.NewBeaconClient`) to connect to it.
+type Server struct {
+	Verbose bool
+
+	fixtures *Fixtures
+
+	mu        sync.Mutex
+	scenarios []*scenarioState
+
+	eventsMu    sync.Mutex
+	subscribers map[*eventSubscriber]bool
+}
+
+type event struct {
+	topic string
+	data  []byte
+}
+
+type eventSubscriber struct {
+	topics map[string]bool
+	events chan event
+	drop   chan struct{}
+}
+
+// New serves `fixtures`, with `scenarios` applied. It rejects scenarios LoadScenarios would.
+func New(fixtures *Fixtures, scenarios ...Scenario) (*Server, error) {
+	server := &Server{
+		fixtures:    fixtures,
+		subscribers: map[*eventSubscriber]bool{},
+	}
+	for _, scenario := range scenarios {
+		if err := scenario.validate(); err != nil {
+			return nil, err
+		}
+		server.scenarios = append(server.scenarios, &scenarioState{Scenario: scenario})
+	}
+	return server, nil
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type apiResponse struct {
+	Version             string `json:"version,omitempty"`
+	ExecutionOptimistic bool   `json:"execution_optimistic"`
+	Finalized           bool   `json:"finalized"`
+	Data                any    `json:"data"`
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Verbose {
+		log.Info().Msgf("%s %s", r.Method, r.URL.String())
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("unsupported method %s", r.Method))
+		return
+	}
+
+	var reorgHeader *phase0.BeaconBlockHeader
+	for _, scenario := range s.activeScenarios(r.URL.Path) {
+		if s.Verbose {
+			log.Warn().Msgf("scenario %s applied to %s", scenario.Action, r.URL.Path)
+		}
+
+		switch scenario.Action {
+		case ActionMissing:
+			writeError(w, http.StatusNotFound, "not found (scenario)")
+			return
+		case ActionError:
+			status := scenario.Status
+			if status == 0 {
+				status = http.StatusInternalServerError
+			}
+			writeError(w, status, "internal error (scenario)")
+			return
+		case ActionSlow:
+			select {
+			case <-time.After(time.Duration(scenario.Delay)):
+			case <-r.Context().Done():
+				return
+			}
+		case ActionReorg:
+			reorgHeader = scenario.Header
+		}
+	}
+
+	path := r.URL.Path
+	switch {
+	case path == "/eth/v1/beacon/genesis":
+		s.serveGenesis(w)
+	case path == "/eth/v1/config/spec":
+		s.serveSpec(w)
+	case path == "/eth/v1/config/deposit_contract":
+		writeJSON(w, apiResponse{Data: map[string]string{
+			"chain_id": strconv.FormatUint(s.fixtures.ChainID, 10),
+			"address":  s.chain().DepositContract.Hex(),
+		}})
+	case path == "/eth/v1/config/fork_schedule":
+		writeJSON(w, apiResponse{Data: forkSchedule(s.chain())})
+	case path == eventsPath:
+		s.serveEvents(w, r)
+	case path == "/eth/v1/node/version":
+		writeJSON(w, apiResponse{Data: map[string]string{"version": NodeVersion}})
+	case strings.HasPrefix(path, headersPath):
+		s.serveHeader(w, strings.TrimPrefix(path, headersPath), reorgHeader)
+	case strings.HasPrefix(path, statesPath):
+		s.serveState(w, r, strings.TrimPrefix(path, statesPath))
+	case strings.HasPrefix(path, validatorsPath) && strings.HasSuffix(path, "/validators"):
+		stateId := strings.TrimSuffix(strings.TrimPrefix(path, validatorsPath), "/validators")
+		s.serveValidators(w, stateId, r.URL.Query().Get("id"))
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unsupported endpoint %s", path))
+	}
+}
+
+// Publish sends an event to every client subscribed to `topic` on /eth/v1/events.
+func (s *Server) Publish(topic string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	for subscriber := range s.subscribers {
+		if !subscriber.topics[topic] {
+			continue
+		}
+		// events for a subscriber that has fallen this far behind are dropped
+		select {
+		case subscriber.events <- event{topic: topic, data: encoded}:
+		default:
+		}
+	}
+	return nil
+}
+
+// EventSubscribers is the number of clients connected to /eth/v1/events.
+func (s *Server) EventSubscribers() int {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	return len(s.subscribers)
+}
+
+// DropEventStreams disconnects every client from /eth/v1/events, as a restarting node would.
+func (s *Server) DropEventStreams() {
+	s.eventsMu.Lock()
+	defer s.eventsMu.Unlock()
+	for subscriber := range s.subscribers {
+		close(subscriber.drop)
+		delete(s.subscribers, subscriber)
+	}
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	subscriber := &eventSubscriber{
+		topics: map[string]bool{},
+		events: make(chan event, 64),
+		drop:   make(chan struct{}),
+	}
+	for _, topic := range r.URL.Query()["topics"] {
+		subscriber.topics[topic] = true
+	}
+	if len(subscriber.topics) == 0 {
+		writeError(w, http.StatusBadRequest, "no topics requested")
+		return
+	}
+
+	s.eventsMu.Lock()
+	s.subscribers[subscriber] = true
+	s.eventsMu.Unlock()
+	defer func() {
+		s.eventsMu.Lock()
+		delete(s.subscribers, subscriber)
+		s.eventsMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case event := <-subscriber.events:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.topic, event.data)
+			flusher.Flush()
+		case <-subscriber.drop:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Server) activeScenarios(path string) []*scenarioState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := []*scenarioState{}
+	for _, scenario := range s.scenarios {
+		if scenario.matches(path) {
+			active = append(active, scenario)
+		}
+	}
+	return active
+}
+
+func (s *Server) serveGenesis(w http.ResponseWriter) {
+	var genesisTime uint64
+	var validatorsRoot phase0.Root
+	if len(s.fixtures.states) > 0 {
+		state := s.fixtures.states[0].state
+		switch state.Version {
+		case spec.DataVersionDeneb:
+			genesisTime, validatorsRoot = state.Deneb.GenesisTime, state.Deneb.GenesisValidatorsRoot
+		case spec.DataVersionCapella:
+			genesisTime, validatorsRoot = state.Capella.GenesisTime, state.Capella.GenesisValidatorsRoot
+		}
+	}
+
+	writeJSON(w, apiResponse{Data: map[string]string{
+		"genesis_time":            strconv.FormatUint(genesisTime, 10),
+		"genesis_validators_root": fmt.Sprintf("%#x", validatorsRoot),
+		"genesis_fork_version":    s.chain().GenesisForkVersion.String(),
+	}})
+}
+
+func (s *Server) serveSpec(w http.ResponseWriter) {
+	writeJSON(w, apiResponse{Data: map[string]string{
+		"CONFIG_NAME":      "beaconmock",
+		"DEPOSIT_CHAIN_ID": strconv.FormatUint(s.fixtures.ChainID, 10),
+		"SECONDS_PER_SLOT": strconv.FormatUint(s.chain().SecondsPerSlot, 10),
+		"SLOTS_PER_EPOCH":  strconv.FormatUint(s.chain().SlotsPerEpoch, 10),
+	}})
+}
+
+func (s *Server) serveHeader(w http.ResponseWriter, blockId string, reorgHeader *phase0.BeaconBlockHeader) {
+	var header *v1.BeaconBlockHeader
+	var err error
+	if reorgHeader != nil {
+		header, err = toBlockHeader(reorgHeader)
+	} else {
+		header, err = s.fixtures.header(blockId)
+	}
+	if err != nil {
+		writeFixtureError(w, err)
+		return
+	}
+
+	writeJSON(w, apiResponse{Data: header})
+}
+
+func (s *Server) serveState(w http.ResponseWriter, r *http.Request, stateId string) {
+	state, err := s.fixtures.state(stateId)
+	if err != nil {
+		writeFixtureError(w, err)
+		return
+	}
+
+	// prefer SSZ, as real nodes do for states
+	if strings.HasPrefix(r.Header.Get("Accept"), "application/json") {
+		data, err := stateData(state.state)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, apiResponse{Version: state.state.Version.String(), Data: data})
+		return
+	}
+
+	ssz, err := s.encodedState(state)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Eth-Consensus-Version", state.state.Version.String())
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(ssz)
+}
+
+func (s *Server) encodedState(state *fixtureState) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if state.ssz == nil {
+		data, err := stateData(state.state)
+		if err != nil {
+			return nil, err
+		}
+		ssz, err := data.MarshalSSZ()
+		if err != nil {
+			return nil, err
+		}
+		state.ssz = ssz
+	}
+	return state.ssz, nil
+}
+
+type versionedStateData interface {
+	MarshalSSZ() ([]byte, error)
+	MarshalJSON() ([]byte, error)
+	HashTreeRoot() ([32]byte, error)
+}
+
+func stateData(state *spec.VersionedBeaconState) (versionedStateData, error) {
+	switch state.Version {
+	case spec.DataVersionDeneb:
+		return state.Deneb, nil
+	case spec.DataVersionCapella:
+		return state.Capella, nil
+	default:
+		return nil, fmt.Errorf("unsupported beacon state version %s", state.Version)
+	}
+}
+
+// ids is the comma-separated `id` query parameter: validator indices and/or 0x pubkeys. Empty means all validators.
+func (s *Server) serveValidators(w http.ResponseWriter, stateId string, ids string) {
+	state, err := s.fixtures.state(stateId)
+	if err != nil {
+		writeFixtureError(w, err)
+		return
+	}
+
+	validators, err := state.state.Validators()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	balances, err := state.state.ValidatorBalances()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	epoch := phase0.Epoch(uint64(state.slot) / beacon.SLOTS_PER_EPOCH)
+
+	toValidator := func(index int) *v1.Validator {
+		return &v1.Validator{
+			Index:     phase0.ValidatorIndex(index),
+			Balance:   balances[index],
+			Status:    v1.ValidatorToState(validators[index], &balances[index], epoch, phase0.Epoch(math.MaxUint64)),
+			Validator: validators[index],
+		}
+	}
+
+	results := []*v1.Validator{}
+	if ids == "" {
+		for i := range validators {
+			results = append(results, toValidator(i))
+		}
+		writeJSON(w, apiResponse{Data: results})
+		return
+	}
+
+	for _, id := range strings.Split(ids, ",") {
+		if strings.HasPrefix(id, "0x") {
+			pubkey := common.FromHex(id)
+			for i, validator := range validators {
+				if string(validator.PublicKey[:]) == string(pubkey) {
+					results = append(results, toValidator(i))
+				}
+			}
+			continue
+		}
+
+		index, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid validator id %s", id))
+			return
+		}
+		// real nodes omit unknown validators rather than failing the request
+		if index < uint64(len(validators)) {
+			results = append(results, toValidator(int(index)))
+		}
+	}
+	writeJSON(w, apiResponse{Data: results})
+}
+
+func writeJSON(w http.ResponseWriter, response apiResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+func writeFixtureError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	data, _ := json.Marshal(apiError{Code: status, Message: message})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+// the chain the fixtures are for. Chains that aren't registered get mainnet's timing, and zero versions and addresses.
+func (s *Server) chain() *chains.Chain {
+	if chain, ok := chains.ByID(s.fixtures.ChainID); ok {
+		return chain
+	}
+	return &chains.Chain{
+		ChainID:            s.fixtures.ChainID,
+		GenesisForkVersion: make([]byte, 4),
+		SecondsPerSlot:     beacon.SECONDS_PER_SLOT,
+		SlotsPerEpoch:      beacon.SLOTS_PER_EPOCH,
+	}
+}
+
+func forkSchedule(chain *chains.Chain) []*phase0.Fork {
+	schedule := []*phase0.Fork{}
+	previous := chain.GenesisForkVersion
+	for _, fork := range chain.Forks {
+		f := &phase0.Fork{Epoch: phase0.Epoch(fork.Epoch)}
+		copy(f.PreviousVersion[:], previous)
+		copy(f.CurrentVersion[:], fork.Version)
+		schedule = append(schedule, f)
+		previous = fork.Version
+	}
+	return schedule
+}
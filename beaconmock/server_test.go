@@ -0,0 +1,51 @@
+package beaconmock
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+func TestScenariosAreValidated(t *testing.T) {
+	tests := []struct {
+		scenario Scenario
+		err      string
+	}{
+		{Scenario{Path: "/eth/v2/debug/beacon/states/", Action: ActionMissing}, ""},
+		{Scenario{Path: headersPath, Action: ActionReorg, Header: &phase0.BeaconBlockHeader{}}, ""},
+		{Scenario{Path: "eth/v2/debug/beacon/states/", Action: ActionMissing}, "must start with '/'"},
+		{Scenario{Path: "/eth/v2/debug/beacon/states/", Action: ActionSlow}, "needs a delay"},
+		{Scenario{Path: headersPath, Action: ActionReorg}, "needs a header"},
+		{Scenario{Path: statesPath, Action: ActionReorg, Header: &phase0.BeaconBlockHeader{}}, "only apply to"},
+		{Scenario{Path: "/", Action: "explode"}, "unknown scenario action"},
+	}
+	for _, test := range tests {
+		_, err := New(NewFixtures(), test.scenario)
+		checkErr(t, "New", test.scenario, err, test.err)
+
+		path := filepath.Join(t.TempDir(), "scenarios.json")
+		data := `[{"path": "` + test.scenario.Path + `", "action": "` + string(test.scenario.Action) + `"`
+		if test.scenario.Header != nil {
+			data += `, "header": {"slot": "0", "proposer_index": "0", "parent_root": "0x` + strings.Repeat("00", 32) +
+				`", "state_root": "0x` + strings.Repeat("00", 32) + `", "body_root": "0x` + strings.Repeat("00", 32) + `"}`
+		}
+		if err := os.WriteFile(path, []byte(data+"}]"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err = LoadScenarios(path)
+		checkErr(t, "LoadScenarios", test.scenario, err, test.err)
+	}
+}
+
+func checkErr(t *testing.T, what string, scenario Scenario, err error, want string) {
+	t.Helper()
+	if want == "" && err != nil {
+		t.Errorf("%s(%+v): %v", what, scenario, err)
+	}
+	if want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+		t.Errorf("%s(%+v): expected an error containing %q, got %v", what, scenario, want, err)
+	}
+}
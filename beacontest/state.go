@@ -0,0 +1,255 @@
+// Package beacontest builds small, internally consistent Deneb beacon states and block headers for tests,
+// so they don't need a multi-hundred-MB snapshot of a real network.
+package beacontest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+const (
+	// holesky's genesis, so timestamps line up with the chain the CLI is usually pointed at.
+	DefaultGenesisTime = uint64(1695902400)
+
+	MaxEffectiveBalance = phase0.Gwei(32_000_000_000)
+
+	// deneb preset vector lengths
+	slotsPerHistoricalRoot    = 8192
+	epochsPerHistoricalVector = 65536
+	epochsPerSlashingsVector  = 8192
+	syncCommitteeSize         = 512
+
+	minValidatorWithdrawabilityDelay = 256
+)
+
+// Validator describes one validator of a synthetic state. The zero value is an active, unslashed
+// validator with 32 ETH and BLS (0x00) withdrawal credentials.
+type Validator struct {
+	// If set, the validator has 0x01 withdrawal credentials pointing at this eigenpod.
+	Pod gethcommon.Address
+
+	// Defaults to 32 ETH.
+	Balance phase0.Gwei
+	// Defaults to Balance, rounded down to a whole ETH and capped at 32 ETH.
+	EffectiveBalance phase0.Gwei
+
+	Slashed         bool
+	ActivationEpoch phase0.Epoch
+	// 0 means the validator has not exited.
+	ExitEpoch phase0.Epoch
+}
+
+// Fixture is a beacon state together with the block that produced it.
+type Fixture struct {
+	State *spec.VersionedBeaconState
+	Block *deneb.SignedBeaconBlock
+
+	// The header of `Block`. Its state root commits to `State`.
+	Header    *phase0.BeaconBlockHeader
+	StateRoot phase0.Root
+	BlockRoot phase0.Root
+}
+
+// BeaconBlockHeader returns the header as a beacon node would from /eth/v1/beacon/headers.
+func (f *Fixture) BeaconBlockHeader() *v1.BeaconBlockHeader {
+	return &v1.BeaconBlockHeader{
+		Root:      f.BlockRoot,
+		Canonical: true,
+		Header:    &phase0.SignedBeaconBlockHeader{Message: f.Header},
+	}
+}
+
+type StateBuilder struct {
+	slot        phase0.Slot
+	genesisTime uint64
+	validators  []Validator
+}
+
+func NewStateBuilder(slot phase0.Slot) *StateBuilder {
+	return &StateBuilder{
+		slot:        slot,
+		genesisTime: DefaultGenesisTime,
+		validators:  []Validator{},
+	}
+}
+
+func (b *StateBuilder) WithGenesisTime(genesisTime uint64) *StateBuilder {
+	b.genesisTime = genesisTime
+	return b
+}
+
+func (b *StateBuilder) AddValidator(validator Validator) *StateBuilder {
+	b.validators = append(b.validators, validator)
+	return b
+}
+
+// AddValidators appends `count` copies of `validator`.
+func (b *StateBuilder) AddValidators(count int, validator Validator) *StateBuilder {
+	for i := 0; i < count; i++ {
+		b.validators = append(b.validators, validator)
+	}
+	return b
+}
+
+// Build assembles the state as it would look right after processing a block at the builder's slot:
+// the block's header is the state's latest block header (with a zero state root), and the parent
+// block's root is in the state's block roots.
+func (b *StateBuilder) Build() (*Fixture, error) {
+	state := b.emptyState()
+	for i, validator := range b.validators {
+		v, balance := b.validator(uint64(i), validator)
+		state.Validators = append(state.Validators, v)
+		state.Balances = append(state.Balances, balance)
+	}
+
+	block := NewBlock(b.slot)
+	block.Message.ParentRoot = parentRoot(b.slot)
+	if len(b.validators) > 0 {
+		block.Message.ProposerIndex = phase0.ValidatorIndex(uint64(b.slot) % uint64(len(b.validators)))
+	}
+	block.Message.Body.ExecutionPayload.Timestamp = b.genesisTime + uint64(b.slot)*beacon.SECONDS_PER_SLOT
+
+	bodyRoot, err := block.Message.Body.HashTreeRoot()
+	if err != nil {
+		return nil, err
+	}
+	state.LatestBlockHeader = &phase0.BeaconBlockHeader{
+		Slot:          block.Message.Slot,
+		ProposerIndex: block.Message.ProposerIndex,
+		ParentRoot:    block.Message.ParentRoot,
+		BodyRoot:      bodyRoot,
+	}
+	if b.slot > 0 {
+		state.BlockRoots[(b.slot-1)%slotsPerHistoricalRoot] = block.Message.ParentRoot
+	}
+	state.LatestExecutionPayloadHeader.Timestamp = block.Message.Body.ExecutionPayload.Timestamp
+
+	stateRoot, err := state.HashTreeRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	block.Message.StateRoot = stateRoot
+	header := *state.LatestBlockHeader
+	header.StateRoot = stateRoot
+	blockRoot, err := header.HashTreeRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Fixture{
+		State:     &spec.VersionedBeaconState{Version: spec.DataVersionDeneb, Deneb: state},
+		Block:     block,
+		Header:    &header,
+		StateRoot: stateRoot,
+		BlockRoot: blockRoot,
+	}, nil
+}
+
+// smallest deneb state that survives an SSZ round trip.
+func (b *StateBuilder) emptyState() *deneb.BeaconState {
+	syncCommittee := func() *altair.SyncCommittee {
+		return &altair.SyncCommittee{Pubkeys: make([]phase0.BLSPubKey, syncCommitteeSize)}
+	}
+
+	return &deneb.BeaconState{
+		GenesisTime: b.genesisTime,
+		Slot:        b.slot,
+		Fork: &phase0.Fork{
+			PreviousVersion: phase0.Version{0x04, 0x01, 0x70, 0x00},
+			CurrentVersion:  phase0.Version{0x05, 0x01, 0x70, 0x00},
+		},
+		LatestBlockHeader:            &phase0.BeaconBlockHeader{},
+		BlockRoots:                   make([]phase0.Root, slotsPerHistoricalRoot),
+		StateRoots:                   make([]phase0.Root, slotsPerHistoricalRoot),
+		ETH1Data:                     &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+		Validators:                   []*phase0.Validator{},
+		Balances:                     []phase0.Gwei{},
+		RANDAOMixes:                  make([]phase0.Root, epochsPerHistoricalVector),
+		Slashings:                    make([]phase0.Gwei, epochsPerSlashingsVector),
+		JustificationBits:            bitfield.NewBitvector4(),
+		PreviousJustifiedCheckpoint:  &phase0.Checkpoint{},
+		CurrentJustifiedCheckpoint:   &phase0.Checkpoint{},
+		FinalizedCheckpoint:          &phase0.Checkpoint{},
+		CurrentSyncCommittee:         syncCommittee(),
+		NextSyncCommittee:            syncCommittee(),
+		LatestExecutionPayloadHeader: &deneb.ExecutionPayloadHeader{BaseFeePerGas: uint256.NewInt(0)},
+	}
+}
+
+func (b *StateBuilder) validator(index uint64, validator Validator) (*phase0.Validator, phase0.Gwei) {
+	balance := validator.Balance
+	if balance == 0 {
+		balance = MaxEffectiveBalance
+	}
+	effectiveBalance := validator.EffectiveBalance
+	if effectiveBalance == 0 {
+		effectiveBalance = min(balance-balance%1_000_000_000, MaxEffectiveBalance)
+	}
+
+	exitEpoch := phase0.Epoch(beacon.FAR_FUTURE_EPOCH)
+	withdrawableEpoch := phase0.Epoch(beacon.FAR_FUTURE_EPOCH)
+	if validator.ExitEpoch != 0 {
+		exitEpoch = validator.ExitEpoch
+		withdrawableEpoch = validator.ExitEpoch + minValidatorWithdrawabilityDelay
+	}
+
+	return &phase0.Validator{
+		PublicKey:                  Pubkey(index),
+		WithdrawalCredentials:      WithdrawalCredentials(validator.Pod),
+		EffectiveBalance:           effectiveBalance,
+		Slashed:                    validator.Slashed,
+		ActivationEligibilityEpoch: validator.ActivationEpoch,
+		ActivationEpoch:            validator.ActivationEpoch,
+		ExitEpoch:                  exitEpoch,
+		WithdrawableEpoch:          withdrawableEpoch,
+	}, balance
+}
+
+// Pubkey is the (deterministic, not a valid BLS point) public key given to the validator at `index`.
+func Pubkey(index uint64) phase0.BLSPubKey {
+	var pubkey phase0.BLSPubKey
+	binary.LittleEndian.PutUint64(pubkey[:], index+1)
+	return pubkey
+}
+
+// WithdrawalCredentials returns 0x01 credentials for `pod`, or BLS credentials if `pod` is the zero address.
+func WithdrawalCredentials(pod gethcommon.Address) []byte {
+	credentials := make([]byte, 32)
+	if pod == (gethcommon.Address{}) {
+		return credentials
+	}
+	credentials[0] = 0x01
+	copy(credentials[12:], pod[:])
+	return credentials
+}
+
+// NewBlock returns an empty block at `slot`.
+func NewBlock(slot phase0.Slot) *deneb.SignedBeaconBlock {
+	return &deneb.SignedBeaconBlock{
+		Message: &deneb.BeaconBlock{
+			Slot: slot,
+			Body: &deneb.BeaconBlockBody{
+				ETH1Data:         &phase0.ETH1Data{BlockHash: make([]byte, 32)},
+				SyncAggregate:    &altair.SyncAggregate{SyncCommitteeBits: bitfield.NewBitvector512()},
+				ExecutionPayload: &deneb.ExecutionPayload{BaseFeePerGas: uint256.NewInt(0)},
+			},
+		},
+	}
+}
+
+func parentRoot(slot phase0.Slot) phase0.Root {
+	var data [8]byte
+	binary.LittleEndian.PutUint64(data[:], uint64(slot))
+	return sha256.Sum256(data[:])
+}
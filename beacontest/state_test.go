@@ -0,0 +1,67 @@
+package beacontest_test
+
+import (
+	"testing"
+
+	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacontest"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/common"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var pod = gethcommon.HexToAddress("0x0000000000000000000000000000000000000abc")
+
+func TestBuiltStateMatchesHeader(t *testing.T) {
+	fixture, err := beacontest.NewStateBuilder(1000).
+		AddValidators(3, beacontest.Validator{Pod: pod}).
+		AddValidator(beacontest.Validator{Balance: 31_500_000_000, Slashed: true, ExitEpoch: 40}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stateRoot, err := fixture.State.Deneb.HashTreeRoot()
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Root(stateRoot), fixture.Header.StateRoot)
+
+	blockRoot, err := fixture.Block.Message.HashTreeRoot()
+	assert.Nil(t, err)
+	assert.Equal(t, fixture.BlockRoot, phase0.Root(blockRoot))
+
+	validators := fixture.State.Deneb.Validators
+	assert.Equal(t, 4, len(validators))
+	assert.Equal(t, byte(0x01), validators[0].WithdrawalCredentials[0])
+	assert.Equal(t, pod[:], validators[0].WithdrawalCredentials[12:])
+	assert.Equal(t, phase0.Gwei(31_000_000_000), validators[3].EffectiveBalance)
+	assert.Equal(t, phase0.Epoch(296), validators[3].WithdrawableEpoch)
+}
+
+func TestBuiltStateIsProvable(t *testing.T) {
+	fixture, err := beacontest.NewStateBuilder(1000).
+		AddValidators(5, beacontest.Validator{Pod: pod}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	epp, err := eigenpodproofs.NewEigenPodProofs(17000, 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proofs, err := epp.ProveValidatorContainers(fixture.Header, fixture.State, []uint64{3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, common.ValidateProof(fixture.BlockRoot, proofs.StateRootProof.Proof, fixture.StateRoot, beacon.STATE_ROOT_INDEX))
+
+	validatorRoot, err := fixture.State.Deneb.Validators[3].HashTreeRoot()
+	assert.Nil(t, err)
+	// validator list (tree + length mix-in) within the state container
+	index := beacon.VALIDATORS_INDEX<<(beacon.VALIDATOR_TREE_HEIGHT+1) | 3
+	assert.True(t, common.ValidateProof(fixture.StateRoot, proofs.ValidatorFieldsProofs[0], validatorRoot, index))
+}
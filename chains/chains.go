@@ -0,0 +1,167 @@
+// Package chains is the registry of the chains proofs can be generated for: their beacon chain genesis, slot timing,
+// fork schedule, and EigenLayer contracts. Mainnet, Holesky, Hoodi and Sepolia are built in; devnets can be added
+// with Register or LoadFile.
+package chains
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type Fork struct {
+	Name    string        `json:"name"`
+	Epoch   uint64        `json:"epoch"`
+	Version hexutil.Bytes `json:"version"`
+}
+
+type Chain struct {
+	Name string `json:"name"`
+	// The execution chain id.
+	ChainID            uint64        `json:"chainId"`
+	GenesisTime        uint64        `json:"genesisTime"`
+	GenesisForkVersion hexutil.Bytes `json:"genesisForkVersion"`
+	SecondsPerSlot     uint64        `json:"secondsPerSlot"`
+	SlotsPerEpoch      uint64        `json:"slotsPerEpoch"`
+	// The effective balance (gwei) at which validators are ejected. 0 means mainnet's 16 ETH.
+	EjectionBalance uint64 `json:"ejectionBalance,omitempty"`
+	// Forks after genesis, ascending by epoch.
+	Forks           []Fork         `json:"forks"`
+	DepositContract common.Address `json:"depositContract"`
+	EigenPodManager common.Address `json:"eigenPodManager"`
+}
+
+// SlotTimestamp is the time `slot` starts at.
+func (c *Chain) SlotTimestamp(slot uint64) uint64 {
+	return c.GenesisTime + slot*c.SecondsPerSlot
+}
+
+// SlotAt is the slot in progress at `timestamp`, which must not be before genesis.
+func (c *Chain) SlotAt(timestamp uint64) uint64 {
+	return (timestamp - c.GenesisTime) / c.SecondsPerSlot
+}
+
+// ForkAt is the fork active at `epoch`, or (before any fork) "genesis".
+func (c *Chain) ForkAt(epoch uint64) Fork {
+	fork := Fork{Name: "genesis", Version: c.GenesisForkVersion}
+	for _, f := range c.Forks {
+		if f.Epoch > epoch {
+			break
+		}
+		fork = f
+	}
+	return fork
+}
+
+func (c *Chain) validate() error {
+	switch {
+	case c.ChainID == 0:
+		return errors.New("chainId is required")
+	case c.SecondsPerSlot == 0:
+		return fmt.Errorf("chain %d: secondsPerSlot is required", c.ChainID)
+	case c.SlotsPerEpoch == 0:
+		return fmt.Errorf("chain %d: slotsPerEpoch is required", c.ChainID)
+	case len(c.GenesisForkVersion) != 4:
+		return fmt.Errorf("chain %d: genesisForkVersion must be 4 bytes", c.ChainID)
+	case c.EigenPodManager == (common.Address{}):
+		return fmt.Errorf("chain %d: eigenPodManager is required", c.ChainID)
+	}
+	for i, fork := range c.Forks {
+		if len(fork.Version) != 4 {
+			return fmt.Errorf("chain %d: fork %s version must be 4 bytes", c.ChainID, fork.Name)
+		}
+		if i > 0 && fork.Epoch < c.Forks[i-1].Epoch {
+			return fmt.Errorf("chain %d: forks must be in epoch order", c.ChainID)
+		}
+	}
+	return nil
+}
+
+var registry = struct {
+	sync.RWMutex
+	chains map[uint64]*Chain
+}{chains: map[uint64]*Chain{}}
+
+func init() {
+	for _, chain := range []*Chain{Mainnet, Holesky, Hoodi, Sepolia} {
+		registry.chains[chain.ChainID] = chain
+	}
+}
+
+// ByID looks up a chain by its execution chain id.
+func ByID(chainId uint64) (*Chain, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	chain, ok := registry.chains[chainId]
+	return chain, ok
+}
+
+// ByGenesisTime looks up a chain by its beacon chain genesis time, e.g that of a beacon state.
+func ByGenesisTime(genesisTime uint64) (*Chain, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, chain := range registry.chains {
+		if chain.GenesisTime == genesisTime {
+			return chain, true
+		}
+	}
+	return nil, false
+}
+
+// IDs are the ids of every registered chain, ascending.
+func IDs() []uint64 {
+	registry.RLock()
+	defer registry.RUnlock()
+	ids := make([]uint64, 0, len(registry.chains))
+	for id := range registry.chains {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Register adds a chain, replacing any registered with the same id.
+func Register(chain Chain) error {
+	if err := chain.validate(); err != nil {
+		return err
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.chains[chain.ChainID] = &chain
+	return nil
+}
+
+// LoadFile registers the chains in a JSON file, an array of Chains.
+func LoadFile(path string) ([]Chain, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chains file: %w", err)
+	}
+	var chains []Chain
+	if err := json.Unmarshal(data, &chains); err != nil {
+		return nil, fmt.Errorf("failed to parse chains file %s: %w", path, err)
+	}
+	for _, chain := range chains {
+		if err := Register(chain); err != nil {
+			return nil, fmt.Errorf("invalid chain in %s: %w", path, err)
+		}
+	}
+	return chains, nil
+}
+
+// ErrUnsupported wraps the error for a chain that isn't registered.
+var ErrUnsupported = errors.New("unsupported chain")
+
+// Get is ByID, with an error listing the registered chains if `chainId` isn't one.
+func Get(chainId uint64) (*Chain, error) {
+	if chain, ok := ByID(chainId); ok {
+		return chain, nil
+	}
+	return nil, fmt.Errorf("%w %d (supported: %v; register others with a chains file)", ErrUnsupported, chainId, IDs())
+}
@@ -0,0 +1,81 @@
+package chains
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPresets(t *testing.T) {
+	for _, chain := range []*Chain{Mainnet, Holesky, Hoodi, Sepolia} {
+		if err := chain.validate(); err != nil {
+			t.Errorf("%s: %v", chain.Name, err)
+		}
+		if registered, ok := ByID(chain.ChainID); !ok || registered != chain {
+			t.Errorf("%s isn't registered under %d", chain.Name, chain.ChainID)
+		}
+		if found, ok := ByGenesisTime(chain.GenesisTime); !ok || found != chain {
+			t.Errorf("%s isn't found by its genesis time", chain.Name)
+		}
+	}
+
+	if Mainnet.EigenPodManager != common.HexToAddress("0x91E677b07F7AF907ec9a428aafA9fc14a0d3A338") {
+		t.Error("unexpected mainnet EigenPodManager")
+	}
+	if got := Holesky.SlotTimestamp(2227472); got != 1695902400+2227472*12 {
+		t.Errorf("unexpected slot timestamp %d", got)
+	}
+	if got := Holesky.SlotAt(Holesky.SlotTimestamp(100) + 11); got != 100 {
+		t.Errorf("unexpected slot %d", got)
+	}
+	if fork := Mainnet.ForkAt(269568); fork.Name != "deneb" {
+		t.Errorf("expected deneb, got %s", fork.Name)
+	}
+	if fork := Mainnet.ForkAt(0); fork.Name != "genesis" {
+		t.Errorf("expected genesis, got %s", fork.Name)
+	}
+	if _, err := Get(12345); err == nil {
+		t.Error("expected an error for an unregistered chain")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chains.json")
+	devnet := `[{
+		"name": "devnet",
+		"chainId": 3151908,
+		"genesisTime": 1700000000,
+		"genesisForkVersion": "0x10000038",
+		"secondsPerSlot": 6,
+		"slotsPerEpoch": 8,
+		"forks": [{"name": "deneb", "epoch": 0, "version": "0x40000038"}],
+		"eigenPodManager": "0x000000000000000000000000000000000000b0d5"
+	}]`
+	if err := os.WriteFile(path, []byte(devnet), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	chain, err := Get(3151908)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.SlotTimestamp(10) != 1700000060 {
+		t.Errorf("unexpected slot timestamp %d", chain.SlotTimestamp(10))
+	}
+	if chain.ForkAt(5).Name != "deneb" {
+		t.Errorf("unexpected fork %s", chain.ForkAt(5).Name)
+	}
+
+	invalid := `[{"name": "devnet", "chainId": 3151909, "genesisForkVersion": "0x10000038"}]`
+	if err := os.WriteFile(path, []byte(invalid), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("expected an error for a chain without slot timing")
+	}
+}
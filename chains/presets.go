@@ -0,0 +1,82 @@
+package chains
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Fork versions and epochs are from each network's consensus config (github.com/eth-clients), and contract
+// addresses from github.com/Layr-Labs/eigenlayer-contracts.
+
+var Mainnet = &Chain{
+	Name:               "mainnet",
+	ChainID:            1,
+	GenesisTime:        1606824023,
+	GenesisForkVersion: hexutil.MustDecode("0x00000000"),
+	SecondsPerSlot:     12,
+	SlotsPerEpoch:      32,
+	Forks: []Fork{
+		{Name: "altair", Epoch: 74240, Version: hexutil.MustDecode("0x01000000")},
+		{Name: "bellatrix", Epoch: 144896, Version: hexutil.MustDecode("0x02000000")},
+		{Name: "capella", Epoch: 194048, Version: hexutil.MustDecode("0x03000000")},
+		{Name: "deneb", Epoch: 269568, Version: hexutil.MustDecode("0x04000000")},
+		{Name: "electra", Epoch: 364032, Version: hexutil.MustDecode("0x05000000")},
+	},
+	DepositContract: common.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa"),
+	EigenPodManager: common.HexToAddress("0x91E677b07F7AF907ec9a428aafA9fc14a0d3A338"),
+}
+
+var Holesky = &Chain{
+	Name:               "holesky",
+	ChainID:            17000,
+	GenesisTime:        1695902400,
+	GenesisForkVersion: hexutil.MustDecode("0x01017000"),
+	SecondsPerSlot:     12,
+	SlotsPerEpoch:      32,
+	EjectionBalance:    28_000_000_000,
+	Forks: []Fork{
+		{Name: "altair", Epoch: 0, Version: hexutil.MustDecode("0x02017000")},
+		{Name: "bellatrix", Epoch: 0, Version: hexutil.MustDecode("0x03017000")},
+		{Name: "capella", Epoch: 256, Version: hexutil.MustDecode("0x04017000")},
+		{Name: "deneb", Epoch: 29696, Version: hexutil.MustDecode("0x05017000")},
+		{Name: "electra", Epoch: 115968, Version: hexutil.MustDecode("0x06017000")},
+	},
+	DepositContract: common.HexToAddress("0x4242424242424242424242424242424242424242"),
+	EigenPodManager: common.HexToAddress("0x30770d7E3e71112d7A6b7259542D1f680a70e315"),
+}
+
+var Hoodi = &Chain{
+	Name:               "hoodi",
+	ChainID:            560048,
+	GenesisTime:        1742213400,
+	GenesisForkVersion: hexutil.MustDecode("0x10000910"),
+	SecondsPerSlot:     12,
+	SlotsPerEpoch:      32,
+	Forks: []Fork{
+		{Name: "altair", Epoch: 0, Version: hexutil.MustDecode("0x20000910")},
+		{Name: "bellatrix", Epoch: 0, Version: hexutil.MustDecode("0x30000910")},
+		{Name: "capella", Epoch: 0, Version: hexutil.MustDecode("0x40000910")},
+		{Name: "deneb", Epoch: 0, Version: hexutil.MustDecode("0x50000910")},
+		{Name: "electra", Epoch: 2048, Version: hexutil.MustDecode("0x60000910")},
+	},
+	DepositContract: common.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa"),
+	EigenPodManager: common.HexToAddress("0xcd1442415Fc5C29Aa848A49d2e232720BE07976c"),
+}
+
+var Sepolia = &Chain{
+	Name:               "sepolia",
+	ChainID:            11155111,
+	GenesisTime:        1655733600,
+	GenesisForkVersion: hexutil.MustDecode("0x90000069"),
+	SecondsPerSlot:     12,
+	SlotsPerEpoch:      32,
+	Forks: []Fork{
+		{Name: "altair", Epoch: 50, Version: hexutil.MustDecode("0x90000070")},
+		{Name: "bellatrix", Epoch: 100, Version: hexutil.MustDecode("0x90000071")},
+		{Name: "capella", Epoch: 56832, Version: hexutil.MustDecode("0x90000072")},
+		{Name: "deneb", Epoch: 132608, Version: hexutil.MustDecode("0x90000073")},
+		{Name: "electra", Epoch: 222464, Version: hexutil.MustDecode("0x90000074")},
+	},
+	DepositContract: common.HexToAddress("0x7f02C3E3c98b133055B8B348B2Ac625669Ed295D"),
+	EigenPodManager: common.HexToAddress("0x56BfEb94879F4543E756d26103976c567256034a"),
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fatih/color"
+)
+
+type TApplyCommandArgs struct {
+	PlanFile   string
+	Node       string
+	BeaconNode string
+	Sender     string
+	// For deferred checkpoint proofs. 0 sizes batches by gas.
+	BatchSize    uint64
+	DisableColor bool
+	NoPrompt     bool
+	Verbose      bool
+}
+
+// ApplyCommand executes a plan written by `--plan`, refusing to if the pod has changed since.
+func ApplyCommand(args TApplyCommandArgs) error {
+	ctx := commandContext()
+
+	if args.DisableColor {
+		color.NoColor = true
+	}
+	if args.PlanFile == "" {
+		return fmt.Errorf("usage: `apply [FLAGS] <plan.json>`")
+	}
+
+	plan, err := core.LoadPlan(args.PlanFile)
+	PanicOnError("failed to load plan", err)
+
+	eth, beaconClient, chainId, err := core.GetClients(ctx, args.Node, args.BeaconNode, args.Verbose)
+	PanicOnError("failed to reach ethereum clients", err)
+
+	if !args.NoPrompt {
+		printPlan(plan)
+	}
+	result := newTransactionsResult("", nil, true /* sent */, false /* gasEstimated */)
+	setResult(result)
+	_, err = core.ApplyPlan(ctx, plan, args.Sender, eth, chainId, beaconClient, nil /* proofs */, args.BatchSize, args.NoPrompt, args.Verbose, func(description string, txn *types.Transaction) {
+		color.Green("%s: %s", description, txn.Hash().Hex())
+		result.add(planStepType(plan, description), []*types.Transaction{txn}, true /* sent */, false /* gasEstimated */)
+	})
+	PanicOnError("failed to apply plan", err)
+
+	for _, note := range plan.Notes {
+		color.Yellow(note)
+	}
+	color.Green("plan applied! re-run with `status` to see the updated Eigenpod state.")
+	return nil
+}
+
+func printPlan(plan *core.Plan) {
+	color.Green("plan for `%s` on pod %s (made at block %d, from %s):", plan.Command, plan.EigenpodAddress, plan.BlockNumber, plan.Sender)
+	for i, step := range plan.Steps {
+		switch {
+		case step.Deferred:
+			fmt.Fprintf(color.Output, "  %d. [%s] %s (deferred)\n", i+1, step.Type, step.Description)
+		case step.GasEstimate != nil:
+			fmt.Fprintf(color.Output, "  %d. [%s] %s, to %s (~%d gas)\n", i+1, step.Type, step.Description, step.To, *step.GasEstimate)
+		default:
+			fmt.Fprintf(color.Output, "  %d. [%s] %s, to %s\n", i+1, step.Type, step.Description, step.To)
+		}
+	}
+	for _, note := range plan.Notes {
+		color.Yellow("  note: %s", note)
+	}
+}
+
+// the type of the step a transaction sent by ApplyPlan is for, from its description.
+func planStepType(plan *core.Plan, description string) string {
+	for _, step := range plan.Steps {
+		if strings.HasPrefix(description, step.Description) {
+			return step.Type
+		}
+	}
+	return ""
+}
+
+func savePlan(plan *core.Plan, path string) error {
+	printPlan(plan)
+	PanicOnError("failed to write plan", plan.Save(path))
+
+	result := &TransactionsResult{Transactions: []TransactionResult{}, PlanFile: path}
+	for _, step := range plan.Steps {
+		result.Transactions = append(result.Transactions, TransactionResult{
+			Type:        step.Type,
+			To:          step.To,
+			CallData:    step.CallData,
+			GasEstimate: step.GasEstimate,
+		})
+	}
+	setResult(result)
+	return nil
+}
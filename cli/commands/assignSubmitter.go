@@ -1,13 +1,13 @@
 package commands
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/fatih/color"
 )
 
@@ -18,10 +18,12 @@ type TAssignSubmitterArgs struct {
 	EigenpodAddress string
 	NoPrompt        bool
 	Verbose         bool
+	// Export the transaction as a Safe batch for this Safe (the pod owner), instead of sending it.
+	Safe string
 }
 
 func AssignSubmitterCommand(args TAssignSubmitterArgs) error {
-	ctx := context.Background()
+	ctx := commandContext()
 
 	if len(args.TargetAddress) == 0 {
 		return fmt.Errorf("usage: `assign-submitter <0xsubmitter>`")
@@ -29,7 +31,7 @@ func AssignSubmitterCommand(args TAssignSubmitterArgs) error {
 		return fmt.Errorf("invalid address for 0xsubmitter: %s", args.TargetAddress)
 	}
 
-	eth, err := ethclient.Dial(args.Node)
+	eth, err := core.DialExecutionClient(ctx, args.Node)
 	if err != nil {
 		return fmt.Errorf("failed to reach eth --node: %w", err)
 	}
@@ -39,7 +41,12 @@ func AssignSubmitterCommand(args TAssignSubmitterArgs) error {
 		return fmt.Errorf("failed to reach eth node for chain id: %w", err)
 	}
 
-	ownerAccount, err := core.PrepareAccount(&args.Sender, chainId, false /* noSend */)
+	if args.Safe != "" {
+		args.Sender = safeSender(args.Safe)
+	} else if args.Sender == "" {
+		return fmt.Errorf("one of --sender or --safe is required")
+	}
+	ownerAccount, err := core.PrepareAccount(ctx, &args.Sender, chainId, args.Safe != "" /* noSend */)
 	if err != nil {
 		return fmt.Errorf("failed to parse --sender: %w", err)
 	}
@@ -58,15 +65,23 @@ func AssignSubmitterCommand(args TAssignSubmitterArgs) error {
 		return fmt.Errorf("error: new proof submitter is existing proof submitter (%s)", currentSubmitter)
 	}
 
-	if !args.NoPrompt {
-		fmt.Printf("Your pod's current proof submitter is %s.\n", currentSubmitter)
-		core.PanicIfNoConsent(fmt.Sprintf("This will update your EigenPod to allow %s to submit proofs on its behalf. As the EigenPod's owner, you can always change this later.", newSubmitter))
+	if !args.NoPrompt && args.Safe == "" {
+		fmt.Fprintf(color.Output, "Your pod's current proof submitter is %s.\n", currentSubmitter)
+		PanicIfNoConsent(fmt.Sprintf("This will update your EigenPod to allow %s to submit proofs on its behalf. As the EigenPod's owner, you can always change this later.", newSubmitter))
 	}
 
-	txn, err := pod.SetProofSubmitter(ownerAccount.TransactionOptions, newSubmitter)
+	txn, err := core.SendTransaction(ctx, eth, ownerAccount, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return pod.SetProofSubmitter(opts, newSubmitter)
+	}, args.Verbose)
 	if err != nil {
 		return fmt.Errorf("error updating submitter role: %w", err)
 	}
+	setResult(newTransactionsResult("set_proof_submitter", []*types.Transaction{txn}, args.Safe == "" /* sent */, false /* gasEstimated */))
+
+	if args.Safe != "" {
+		printSafeBatch(chainId, args.Safe, "Assign proof submitter", fmt.Sprintf("EigenPod(%s).setProofSubmitter(%s), replacing %s.", args.EigenpodAddress, newSubmitter, currentSubmitter), []*types.Transaction{txn})
+		return nil
+	}
 
 	color.Green("submitted txn: %s", txn.Hash())
 	color.Green("updated!")
@@ -2,11 +2,13 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
 
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/utils"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/fatih/color"
@@ -24,40 +26,64 @@ type TCheckpointCommandArgs struct {
 	BatchSize           uint64
 	ForceCheckpoint     bool
 	Verbose             bool
+	Out                 string
+	// Continue a submission that was interrupted, using its journal.
+	Resume bool
+	// Defaults to `core.DefaultCheckpointJournalPath`.
+	JournalFile string
+	// Write a plan for `apply` to this file, instead of sending anything.
+	Plan string
+	// Export the transactions as a Safe batch for this Safe, instead of sending them.
+	Safe string
 }
 
 func CheckpointCommand(args TCheckpointCommandArgs) error {
-	ctx := context.Background()
+	ctx := commandContext()
 
 	if args.DisableColor {
 		color.NoColor = true
 	}
+	if args.Safe != "" {
+		args.Sender = safeSender(args.Safe)
+		args.SimulateTransaction = true
+	}
 
 	isGasEstimate := args.SimulateTransaction && args.Sender != ""
 	isVerbose := !args.SimulateTransaction || args.Verbose
 
 	eth, beaconClient, chainId, err := core.GetClients(ctx, args.Node, args.BeaconNode, isVerbose)
-	core.PanicOnError("failed to reach ethereum clients", err)
+	PanicOnError("failed to reach ethereum clients", err)
 
 	currentCheckpoint, err := core.GetCurrentCheckpoint(args.EigenpodAddress, eth)
-	core.PanicOnError("failed to load checkpoint", err)
+	PanicOnError("failed to load checkpoint", err)
 
 	eigenpod, err := onchain.NewEigenPod(common.HexToAddress(args.EigenpodAddress), eth)
-	core.PanicOnError("failed to connect to eigenpod", err)
+	PanicOnError("failed to connect to eigenpod", err)
+
+	if args.Plan != "" {
+		return planCheckpoint(ctx, args, eth, beaconClient, chainId)
+	}
+
+	result := newTransactionsResult("checkpoint_start", nil, !args.SimulateTransaction, isGasEstimate)
+	setResult(result)
 
 	if currentCheckpoint == 0 {
 		if len(args.Sender) > 0 || args.SimulateTransaction {
 			if !args.NoPrompt && !args.SimulateTransaction {
-				core.PanicIfNoConsent(core.StartCheckpointProofConsent())
+				PanicIfNoConsent(core.StartCheckpointProofConsent())
 			}
 
 			txn, err := core.StartCheckpoint(ctx, args.EigenpodAddress, args.Sender, chainId, eth, args.ForceCheckpoint, args.SimulateTransaction)
-			core.PanicOnError("failed to start checkpoint", err)
+			PanicOnError("failed to start checkpoint", err)
+			result.add("checkpoint_start", []*types.Transaction{txn}, !args.SimulateTransaction, isGasEstimate)
 
 			if !args.SimulateTransaction {
-				color.Green("starting checkpoint: %s.. (waiting for txn to be mined)", txn.Hash().Hex())
-				bind.WaitMined(ctx, eth, txn)
+				// StartCheckpoint returns once the transaction is mined.
 				color.Green("started checkpoint! txn: %s", txn.Hash().Hex())
+			} else if args.Safe != "" {
+				// the proofs can't be generated until the checkpoint has started.
+				printSafeBatch(chainId, args.Safe, "Start checkpoint", fmt.Sprintf("EigenPod(%s).startCheckpoint(). Once executed, re-run `checkpoint --safe` to export the checkpoint proofs.", args.EigenpodAddress), []*types.Transaction{txn})
+				return nil
 			} else {
 				gas := txn.Gas()
 				printAsJSON([]Transaction{
@@ -78,11 +104,11 @@ func CheckpointCommand(args TCheckpointCommandArgs) error {
 			}
 
 			newCheckpoint, err := eigenpod.CurrentCheckpointTimestamp(nil)
-			core.PanicOnError("failed to fetch current checkpoint", err)
+			PanicOnError("failed to fetch current checkpoint", err)
 
 			currentCheckpoint = newCheckpoint
 		} else {
-			core.PanicOnError("no checkpoint active and no private key provided to start one", errors.New("no checkpoint"))
+			PanicOnError("no checkpoint active and no private key provided to start one", errors.New("no checkpoint"))
 		}
 	}
 
@@ -90,11 +116,60 @@ func CheckpointCommand(args TCheckpointCommandArgs) error {
 		color.Green("pod has active checkpoint! checkpoint timestamp: %d", currentCheckpoint)
 	}
 
+	// progress is journaled whenever proofs are actually sent, so an interrupted run can be resumed.
+	var journal *core.CheckpointJournal
+	if !args.SimulateTransaction && args.Out == "" {
+		journalFile := args.JournalFile
+		if journalFile == "" {
+			journalFile = core.DefaultCheckpointJournalPath(args.EigenpodAddress, currentCheckpoint)
+		}
+
+		head, err := eth.BlockNumber(ctx)
+		PanicOnError("failed to fetch latest block", err)
+
+		journal, err = core.OpenCheckpointJournal(journalFile, args.EigenpodAddress, currentCheckpoint, head)
+		PanicOnError("failed to open checkpoint journal", err)
+
+		if args.Resume {
+			PanicOnError("failed to resume from checkpoint journal", core.ResumeCheckpointJournal(ctx, eth, journal, isVerbose))
+
+			// the pending batches may have been all that was left.
+			currentCheckpoint, err = core.GetCurrentCheckpoint(args.EigenpodAddress, eth)
+			PanicOnError("failed to load checkpoint", err)
+			if currentCheckpoint == 0 {
+				color.Green("checkpoint complete! re-run with `status` to see the updated Eigenpod state.")
+				return nil
+			}
+		} else if len(journal.Batches) > 0 {
+			Panic(fmt.Sprintf("proofs for this checkpoint were already submitted (see %s). Re-run with `--resume` to continue.", journalFile))
+		}
+	} else if args.Resume {
+		Panic("--resume requires --sender")
+	}
+
 	proof, err := core.GenerateCheckpointProof(ctx, args.EigenpodAddress, eth, chainId, beaconClient, isVerbose)
-	core.PanicOnError("failed to generate checkpoint proof", err)
+	PanicOnError("failed to generate checkpoint proof", err)
+
+	if args.Out != "" {
+		// submitted later with `submit`
+		jsonProof, err := json.Marshal(core.SerializableCheckpointProof{
+			VerifyCheckpointProofsCallParams: proof,
+			EigenpodAddress:                  args.EigenpodAddress,
+			CheckpointTimestamp:              currentCheckpoint,
+		})
+		PanicOnError("failed to serialize checkpoint proof", err)
+		PanicOnError("failed to write checkpoint proof", core.WriteOutputToFileOrStdout(jsonProof, &args.Out))
+		result.ProofFile = args.Out
+		return nil
+	}
 
-	txns, err := core.SubmitCheckpointProof(ctx, args.Sender, args.EigenpodAddress, chainId, proof, eth, args.BatchSize, args.NoPrompt, args.SimulateTransaction, args.Verbose)
-	if args.SimulateTransaction {
+	txns, err := core.SubmitCheckpointProof(ctx, args.Sender, args.EigenpodAddress, chainId, proof, eth, args.BatchSize, args.NoPrompt, args.SimulateTransaction, args.Verbose, journal)
+	result.add("checkpoint_proof", txns, !args.SimulateTransaction, isGasEstimate)
+	if args.Safe != "" {
+		PanicOnError("failed to build checkpoint proof transactions", err)
+		printSafeBatch(chainId, args.Safe, "Checkpoint proofs", fmt.Sprintf("EigenPod(%s).verifyCheckpointProofs() for checkpoint %d, in %d batch(es).", args.EigenpodAddress, currentCheckpoint, len(txns)), txns)
+		return nil
+	} else if args.SimulateTransaction {
 		printableTxns := utils.Map(txns, func(txn *types.Transaction, _ uint64) Transaction {
 			return Transaction{
 				To:       txn.To().Hex(),
@@ -108,7 +183,18 @@ func CheckpointCommand(args TCheckpointCommandArgs) error {
 			color.Green("transaction(%d): %s", i, txn.Hash().Hex())
 		}
 	}
-	core.PanicOnError("an error occurred while submitting your checkpoint proofs", err)
+	PanicOnError("an error occurred while submitting your checkpoint proofs", err)
 
 	return nil
 }
+
+// writes what `checkpoint` would send to `args.Plan`.
+func planCheckpoint(ctx context.Context, args TCheckpointCommandArgs, eth core.ExecutionClient, beaconClient core.BeaconClient, chainId *big.Int) error {
+	if args.Sender == "" {
+		Panic("--plan requires --sender, to estimate gas")
+	}
+	plan, err := core.PlanCheckpoint(ctx, args.EigenpodAddress, args.Sender, eth, chainId, beaconClient, nil /* proofs */, args.BatchSize, args.ForceCheckpoint, args.Verbose)
+	PanicOnError("failed to plan checkpoint", err)
+
+	return savePlan(plan, args.Plan)
+}
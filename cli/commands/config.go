@@ -0,0 +1,246 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
+)
+
+// every flag can also be set with an environment variable: EIGENPOD_ followed by its name in upper snake case
+// (e.g EIGENPOD_EXEC_NODE for --execNode, EIGENPOD_NO_PROMPT for --no-prompt).
+const ENV_PREFIX = "EIGENPOD_"
+
+func EnvVar(flagName string) string {
+	var b strings.Builder
+	for i, r := range flagName {
+		if r == '-' {
+			b.WriteRune('_')
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return ENV_PREFIX + b.String()
+}
+
+// DefaultConfigPath is where the config file is read from, if `--configFile` isn't set.
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "eigenpod", "config.yaml")
+}
+
+// the config file: settings (keyed by flag name) shared by every profile, and named profiles (e.g per network and
+// pod) that can extend each other.
+type configFile struct {
+	// Used when `--profile` isn't set.
+	Profile  string                    `yaml:"profile"`
+	Defaults map[string]any            `yaml:"defaults"`
+	Profiles map[string]map[string]any `yaml:"profiles"`
+}
+
+// Profile is the settings a config file has for the selected profile, with those it extends and the file's
+// defaults folded in.
+type Profile struct {
+	Path string
+	Name string
+	// Keyed by flag name.
+	Values map[string]string
+	// Where each value came from: `profile <name>` or `defaults`.
+	Sources map[string]string
+}
+
+// LoadProfile reads the profile `name` (or the file's default one) from the config file at `path`. A missing
+// file is only an error if `required` is set.
+func LoadProfile(path, name string, required bool) (*Profile, error) {
+	profile := &Profile{Path: path, Name: name, Values: map[string]string{}, Sources: map[string]string{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		if name != "" {
+			return nil, fmt.Errorf("profile %s: no config file at %s", name, path)
+		}
+		return profile, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config := configFile{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if profile.Name == "" {
+		profile.Name = config.Profile
+	}
+
+	add := func(settings map[string]any, source string) error {
+		for key, value := range settings {
+			if key == "extends" {
+				continue
+			}
+			if _, ok := profile.Values[key]; ok {
+				continue // set by a profile that extends this one
+			}
+			switch value.(type) {
+			case map[string]any, []any:
+				return fmt.Errorf("%s: %s must be a single value", source, key)
+			}
+			profile.Values[key] = fmt.Sprint(value)
+			profile.Sources[key] = source
+		}
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for next := profile.Name; next != ""; {
+		if seen[next] {
+			return nil, fmt.Errorf("profile %s extends itself", next)
+		}
+		seen[next] = true
+
+		settings, ok := config.Profiles[next]
+		if !ok {
+			return nil, fmt.Errorf("no profile %s in %s", next, path)
+		}
+		if err := add(settings, "profile "+next); err != nil {
+			return nil, err
+		}
+		extends, _ := settings["extends"].(string)
+		next = extends
+	}
+	if err := add(config.Defaults, "defaults"); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// ResolvedSetting is a setting's value and where it came from: `flag`, `env`, or the profile.
+type ResolvedSetting struct {
+	Name   string `json:"name"`
+	Env    string `json:"env"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// set by main, once the config file is loaded
+var resolvedProfile *Profile
+var resolvedSettings []ResolvedSetting
+
+func SetResolvedConfig(profile *Profile, settings []ResolvedSetting) {
+	resolvedProfile = profile
+	resolvedSettings = settings
+}
+
+// ResolveSettings works out where each of `names` is set, in order of precedence: on the command line (`fromFlag`
+// returns its value there, if any), in the environment, or in the profile. Settings set nowhere are left out.
+func ResolveSettings(profile *Profile, names []string, fromFlag func(name string) (string, bool)) []ResolvedSetting {
+	settings := []ResolvedSetting{}
+	for _, name := range names {
+		setting := ResolvedSetting{Name: name, Env: EnvVar(name)}
+		if value, ok := fromFlag(name); ok {
+			setting.Value, setting.Source = value, "flag"
+		} else if value, ok := os.LookupEnv(setting.Env); ok {
+			setting.Value, setting.Source = value, "env"
+		} else if value, ok := profile.Values[name]; ok {
+			setting.Value, setting.Source = value, profile.Sources[name]
+		} else {
+			continue
+		}
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Name < settings[j].Name })
+	return settings
+}
+
+var hexPrivateKey = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{64}$`)
+
+// Redact hides the secrets a setting's value may hold: raw private keys, and the credentials and API keys node
+// and signer URLs often carry in their userinfo, path or query.
+func Redact(value string) string {
+	if hexPrivateKey.MatchString(value) {
+		return "<redacted>"
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" || u.Scheme == "era" {
+		return value
+	}
+	redacted := u.Scheme + "://"
+	if u.User != nil {
+		redacted += "<redacted>@"
+	}
+	redacted += u.Host
+	if u.Path != "" && u.Path != "/" {
+		redacted += "/<redacted>"
+	}
+	if u.RawQuery != "" {
+		redacted += "?<redacted>"
+	}
+	if u.Fragment != "" {
+		// remote signers pick an account with #<address>, which isn't secret
+		redacted += "#" + u.Fragment
+	}
+	return redacted
+}
+
+type TConfigCommandArgs struct {
+	UseJSON      bool
+	DisableColor bool
+}
+
+// ConfigCommand prints the settings in effect, and where each came from, with secrets redacted.
+func ConfigCommand(args TConfigCommandArgs) error {
+	if args.DisableColor {
+		color.NoColor = true
+	}
+
+	settings := make([]ResolvedSetting, len(resolvedSettings))
+	for i, setting := range resolvedSettings {
+		setting.Value = Redact(setting.Value)
+		settings[i] = setting
+	}
+
+	profile := resolvedProfile
+	if profile == nil {
+		profile = &Profile{}
+	}
+	setResult(&ConfigResult{ConfigFile: profile.Path, Profile: profile.Name, Settings: settings})
+	if args.UseJSON {
+		encoder := json.NewEncoder(color.Output)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		PanicOnError("failed to serialize config", encoder.Encode(map[string]any{
+			"configFile": profile.Path,
+			"profile":    profile.Name,
+			"settings":   settings,
+		}))
+		return nil
+	}
+
+	fmt.Fprintf(color.Output, "config file: %s\n", profile.Path)
+	if profile.Name != "" {
+		fmt.Fprintf(color.Output, "profile: %s\n", profile.Name)
+	}
+	if len(settings) == 0 {
+		color.Yellow("nothing is configured.")
+		return nil
+	}
+	for _, setting := range settings {
+		fmt.Fprintf(color.Output, "  %s = %s ", setting.Name, setting.Value)
+		color.New(color.Faint).Printf("(%s, or %s)\n", setting.Source, setting.Env)
+	}
+	return nil
+}
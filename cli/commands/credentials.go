@@ -1,7 +1,7 @@
 package commands
 
 import (
-	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"math/big"
@@ -26,46 +26,69 @@ type TCredentialCommandArgs struct {
 	BatchSize           uint64
 	NoPrompt            bool
 	Verbose             bool
+	Out                 string
+	// Export the transactions as a Safe batch for this Safe (the pod owner or proof submitter), instead of sending them.
+	Safe string
 }
 
 func CredentialsCommand(args TCredentialCommandArgs) error {
-	ctx := context.Background()
+	ctx := commandContext()
 	if args.DisableColor {
 		color.NoColor = true
 	}
+	if args.Safe != "" {
+		args.Sender = safeSender(args.Safe)
+		args.SimulateTransaction = true
+	}
 
 	isGasEstimate := args.SimulateTransaction && args.Sender != ""
 	isVerbose := (!args.UseJSON && !args.SimulateTransaction) || args.Verbose
 
 	eth, beaconClient, chainId, err := core.GetClients(ctx, args.Node, args.BeaconNode, isVerbose)
-	core.PanicOnError("failed to reach ethereum clients", err)
+	PanicOnError("failed to reach ethereum clients", err)
 
 	var specificValidatorIndex *big.Int = nil
 	if args.SpecificValidator != math.MaxUint64 && args.SpecificValidator != 0 {
 		specificValidatorIndex = new(big.Int).SetUint64(args.SpecificValidator)
 		if isVerbose {
-			fmt.Printf("Using specific validator: %d", args.SpecificValidator)
+			fmt.Fprintf(color.Output, "Using specific validator: %d", args.SpecificValidator)
 		}
 	}
 
 	validatorProofs, oracleBeaconTimestamp, err := core.GenerateValidatorProof(ctx, args.EigenpodAddress, eth, chainId, beaconClient, specificValidatorIndex, isVerbose)
 
 	if err != nil || validatorProofs == nil {
-		core.PanicOnError("Failed to generate validator proof", err)
-		core.Panic("no inactive validators")
+		PanicOnError("Failed to generate validator proof", err)
+		Panic("no inactive validators")
+	}
+
+	if args.Out != "" {
+		// submitted later with `submit`
+		jsonProof, err := json.Marshal(core.SerializableCredentialProof{
+			ValidatorProofs:       validatorProofs,
+			OracleBeaconTimestamp: oracleBeaconTimestamp,
+			EigenpodAddress:       args.EigenpodAddress,
+		})
+		PanicOnError("failed to serialize credential proof", err)
+		PanicOnError("failed to write credential proof", core.WriteOutputToFileOrStdout(jsonProof, &args.Out))
+		setResult(&TransactionsResult{Transactions: []TransactionResult{}, ProofFile: args.Out})
+		return nil
 	}
 
 	if len(args.Sender) != 0 || args.SimulateTransaction {
 		txns, indices, err := core.SubmitValidatorProof(ctx, args.Sender, args.EigenpodAddress, chainId, eth, args.BatchSize, validatorProofs, oracleBeaconTimestamp, args.NoPrompt, args.SimulateTransaction, isVerbose)
-		core.PanicOnError(fmt.Sprintf("failed to %s validator proof", func() string {
+		PanicOnError(fmt.Sprintf("failed to %s validator proof", func() string {
 			if args.SimulateTransaction {
 				return "simulate"
 			} else {
 				return "submit"
 			}
 		}()), err)
+		setResult(newCredentialsResult(txns, indices, !args.SimulateTransaction, isGasEstimate))
 
-		if args.SimulateTransaction {
+		if args.Safe != "" {
+			printSafeBatch(chainId, args.Safe, "Withdrawal credential proofs", fmt.Sprintf("EigenPod(%s).verifyWithdrawalCredentials() for %d validator(s), in %d batch(es).", args.EigenpodAddress, len(utils.Flatten(indices)), len(txns)), txns)
+		} else if args.SimulateTransaction {
 			out := utils.Map(txns, func(txn *types.Transaction, _ uint64) CredentialProofTransaction {
 				gas := txn.Gas()
 				return CredentialProofTransaction{
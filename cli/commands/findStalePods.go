@@ -1,7 +1,6 @@
 package commands
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
@@ -16,12 +15,13 @@ type TFindStalePodsCommandArgs struct {
 }
 
 func FindStalePodsCommand(args TFindStalePodsCommandArgs) error {
-	ctx := context.Background()
+	ctx := commandContext()
 	eth, beacon, chainId, err := core.GetClients(ctx, args.EthNode, args.BeaconNode /* verbose */, args.Verbose)
-	core.PanicOnError("failed to dial clients", err)
+	PanicOnError("failed to dial clients", err)
 
 	results, err := core.FindStaleEigenpods(ctx, eth, args.EthNode, beacon, chainId, args.Verbose, args.Tolerance)
-	core.PanicOnError("failed to find stale eigenpods", err)
+	PanicOnError("failed to find stale eigenpods", err)
+	setResult(newStalePodsResult(results))
 
 	if !args.Verbose {
 		printAsJSON(results)
@@ -32,7 +32,7 @@ func FindStalePodsCommand(args TFindStalePodsCommandArgs) error {
 		for pod, res := range results {
 			color.Red("pod %s\n", pod)
 			for _, validator := range res {
-				fmt.Printf("\t[#%d] (%s) - %d\n", validator.Index, func() string {
+				fmt.Fprintf(color.Output, "\t[#%d] (%s) - %d\n", validator.Index, func() string {
 					if validator.Validator.Slashed {
 						return "slashed"
 					} else {
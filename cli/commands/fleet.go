@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/fatih/color"
+)
+
+type TFleetCommandArgs struct {
+	ConfigFile string
+	Node       string
+	BeaconNode string
+	// The sender for pods whose config doesn't set one.
+	Sender       string
+	UseJSON      bool
+	DisableColor bool
+	NoPrompt     bool
+	Verbose      bool
+}
+
+func FleetStatusCommand(args TFleetCommandArgs) error {
+	fleet := loadFleet(args)
+	return printFleetReport(fleet.Status(commandContext()), args.UseJSON)
+}
+
+func FleetCheckpointCommand(args TFleetCommandArgs) error {
+	fleet := loadFleet(args)
+	confirmFleet(args, fleet, "complete the active checkpoints (and start checkpoints, where the pod's policy says to) of")
+	return printFleetReport(fleet.Checkpoint(commandContext()), args.UseJSON)
+}
+
+func FleetCredentialsCommand(args TFleetCommandArgs) error {
+	fleet := loadFleet(args)
+	confirmFleet(args, fleet, "verify the withdrawal credentials of any inactive validators of")
+	return printFleetReport(fleet.Credentials(commandContext()), args.UseJSON)
+}
+
+func loadFleet(args TFleetCommandArgs) *core.Fleet {
+	ctx := commandContext()
+	if args.DisableColor {
+		color.NoColor = true
+	}
+
+	config, err := core.LoadFleetConfig(args.ConfigFile)
+	PanicOnError("failed to load fleet config", err)
+
+	eth, beaconClient, chainId, err := core.GetClients(ctx, args.Node, args.BeaconNode, args.Verbose)
+	PanicOnError("failed to reach ethereum clients", err)
+
+	fleet, err := core.NewFleet(config, eth, beaconClient, chainId)
+	PanicOnError("failed to load fleet", err)
+	fleet.Sender = args.Sender
+	fleet.Verbose = args.Verbose
+	return fleet
+}
+
+func confirmFleet(args TFleetCommandArgs, fleet *core.Fleet, action string) {
+	if args.NoPrompt {
+		return
+	}
+	sending := 0
+	for _, pod := range fleet.Config.Pods {
+		if fleet.Config.SenderFor(pod, fleet.Sender) != "" && !fleet.Config.PolicyFor(pod).Disabled {
+			sending++
+		}
+	}
+	if sending > 0 {
+		PanicIfNoConsent(core.FleetConsent(action, sending, len(fleet.Config.Pods)))
+	}
+}
+
+func printFleetReport(report *core.FleetReport, useJSON bool) error {
+	setResult(newFleetResult(report))
+	if useJSON {
+		printAsJSON(report)
+	} else {
+		for _, pod := range report.Pods {
+			name := pod.Address
+			if pod.Name != "" {
+				name = fmt.Sprintf("%s (%s)", pod.Name, pod.Address)
+			}
+			switch {
+			case pod.Error != "":
+				color.Red("✗ %s: %s", name, pod.Error)
+			case pod.Skipped != "":
+				color.Yellow("- %s: skipped, %s", name, pod.Skipped)
+			case pod.Status != nil:
+				checkpoint := "no active checkpoint"
+				if pod.Status.ActiveCheckpoint != nil {
+					checkpoint = fmt.Sprintf("checkpoint active, %d proof(s) remaining", pod.Status.ActiveCheckpoint.ProofsRemaining)
+				}
+				color.Green("✓ %s [slot %d]: %d validator(s), %s, %f shares (%f after a checkpoint)", name, pod.Slot, len(pod.Status.Validators), checkpoint, pod.Status.CurrentTotalSharesETH, pod.Status.TotalSharesAfterCheckpointETH)
+			default:
+				verb := "built"
+				if pod.Sent {
+					verb = "sent"
+				}
+				color.Green("✓ %s [slot %d]: %d proof(s), %d txn(s) %s", name, pod.Slot, pod.Proofs, len(pod.Transactions), verb)
+			}
+		}
+	}
+
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("fleet %s failed for %d of %d pod(s)", report.Command, failed, len(report.Pods))
+	}
+	return nil
+}
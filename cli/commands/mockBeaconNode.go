@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beaconmock"
+	"github.com/fatih/color"
+)
+
+type TMockBeaconNodeArgs struct {
+	FixturesDir   string
+	ScenariosFile string
+	Port          uint64
+	ChainID       uint64
+	Verbose       bool
+}
+
+func MockBeaconNodeCommand(args TMockBeaconNodeArgs) error {
+	fixtures, err := beaconmock.LoadFixtures(args.FixturesDir)
+	PanicOnError("failed to load fixtures", err)
+	fixtures.ChainID = args.ChainID
+
+	scenarios := []beaconmock.Scenario{}
+	if args.ScenariosFile != "" {
+		scenarios, err = beaconmock.LoadScenarios(args.ScenariosFile)
+		PanicOnError("failed to load scenarios", err)
+	}
+
+	server, err := beaconmock.New(fixtures, scenarios...)
+	PanicOnError("invalid scenarios", err)
+	server.Verbose = args.Verbose
+
+	address := fmt.Sprintf("127.0.0.1:%d", args.Port)
+	color.Green("serving mock beacon node on http://%s (%d scenarios)", address, len(scenarios))
+	return http.ListenAndServe(address, server)
+}
@@ -0,0 +1,342 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fatih/color"
+)
+
+// `--output` formats.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// OUTPUT_SCHEMA_VERSION is bumped whenever the `--output json` envelope or a command's result changes in a way
+// that isn't backwards compatible. Adding fields isn't.
+const OUTPUT_SCHEMA_VERSION = 1
+
+// Output is everything a command prints to stdout with `--output json`: exactly one of these, whether it
+// succeeds or fails. Logs, prompts and the text output go to stderr.
+type Output struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Command       string       `json:"command"`
+	OK            bool         `json:"ok"`
+	Result        any          `json:"result"`
+	Error         *OutputError `json:"error,omitempty"`
+}
+
+type OutputError struct {
+	Message string `json:"message"`
+	Cause   string `json:"cause,omitempty"`
+}
+
+var outputFormat = OutputText
+
+// where the Output goes. Everything else is printed to color.Output.
+var outputWriter io.Writer = os.Stdout
+
+// the command running, and its result so far
+var currentCommand string
+var currentResult any
+
+// SetOutputFormat is set by the global `--output` flag. With `json`, the text commands and core print (to
+// color.Output) goes to stderr instead, leaving stdout for the Output.
+func SetOutputFormat(format string) error {
+	switch format {
+	case OutputText:
+	case OutputJSON:
+		color.Output = os.Stderr
+	default:
+		return fmt.Errorf("unknown --output %s (expected %s or %s)", format, OutputText, OutputJSON)
+	}
+	outputFormat = format
+	return nil
+}
+
+func isJSONOutput() bool {
+	return outputFormat == OutputJSON
+}
+
+// BeginCommand is called before each command runs, with its full name (e.g `fleet status`).
+func BeginCommand(name string) {
+	currentCommand = name
+	currentResult = nil
+	startCommandTrace(name)
+}
+
+// NameCommand names the command about to run, before its flags are checked, so errors from before it begins (e.g
+// a missing required flag) are reported against it.
+func NameCommand(name string) {
+	currentCommand = name
+}
+
+// EndCommand prints the command's Output (with `--output json`), and returns its error.
+func EndCommand(err error) error {
+	finishCommand(err)
+	if !isJSONOutput() {
+		return err
+	}
+	if err != nil {
+		exitWithOutput(&OutputError{Message: err.Error()})
+	}
+	writeOutput(nil)
+	return nil
+}
+
+// sets what the command prints as its result with `--output json`.
+func setResult(result any) {
+	currentResult = result
+}
+
+func writeOutput(outputError *OutputError) {
+	encoder := json.NewEncoder(outputWriter)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(Output{
+		SchemaVersion: OUTPUT_SCHEMA_VERSION,
+		Command:       currentCommand,
+		OK:            outputError == nil,
+		Result:        currentResult,
+		Error:         outputError,
+	})
+}
+
+func exitWithOutput(outputError *OutputError) {
+	writeOutput(outputError)
+	os.Exit(1)
+}
+
+// Result schemas. Fields are only ever added to these; see OUTPUT_SCHEMA_VERSION.
+
+// TransactionResult is a transaction a command sent, or (if `sent` is false) built.
+type TransactionResult struct {
+	Type     string `json:"type"`
+	To       string `json:"to"`
+	CallData string `json:"calldata"`
+	// Set once sent.
+	Hash string `json:"hash,omitempty"`
+	// The estimated gas, if simulated from a sender.
+	GasEstimate      *uint64  `json:"gasEstimate,omitempty"`
+	ValidatorIndices []uint64 `json:"validatorIndices,omitempty"`
+}
+
+// TransactionsResult is the result of commands that send (or build) transactions: checkpoint, credentials,
+// submit, apply, correct-stale-pod and assign-submitter.
+type TransactionsResult struct {
+	Sent         bool                `json:"sent"`
+	Transactions []TransactionResult `json:"transactions"`
+	// Set when the command wrote a plan (`--plan`) or proof (`--out`) instead.
+	PlanFile  string `json:"planFile,omitempty"`
+	ProofFile string `json:"proofFile,omitempty"`
+	// Set with `--safe`.
+	SafeBatch *core.SafeBatch `json:"safeBatch,omitempty"`
+}
+
+func newTransactionsResult(txType string, txns []*types.Transaction, sent, gasEstimated bool) *TransactionsResult {
+	result := &TransactionsResult{Sent: sent, Transactions: []TransactionResult{}}
+	result.add(txType, txns, sent, gasEstimated)
+	return result
+}
+
+func (r *TransactionsResult) add(txType string, txns []*types.Transaction, sent, gasEstimated bool) {
+	for _, txn := range txns {
+		if txn == nil {
+			continue
+		}
+		tx := TransactionResult{
+			Type:     txType,
+			To:       txn.To().Hex(),
+			CallData: "0x" + common.Bytes2Hex(txn.Data()),
+		}
+		if sent {
+			tx.Hash = txn.Hash().Hex()
+		}
+		if gasEstimated {
+			gas := txn.Gas()
+			tx.GasEstimate = &gas
+		}
+		r.Transactions = append(r.Transactions, tx)
+	}
+}
+
+func newCredentialsResult(txns []*types.Transaction, indices [][]*big.Int, sent, gasEstimated bool) *TransactionsResult {
+	result := newTransactionsResult("credential_proof", nil, sent, gasEstimated)
+	for i, txn := range txns {
+		result.add("credential_proof", []*types.Transaction{txn}, sent, gasEstimated)
+		if i < len(indices) && len(result.Transactions) > 0 {
+			tx := &result.Transactions[len(result.Transactions)-1]
+			for _, index := range indices[i] {
+				tx.ValidatorIndices = append(tx.ValidatorIndices, index.Uint64())
+			}
+		}
+	}
+	return result
+}
+
+type ValidatorResult struct {
+	Index                               uint64 `json:"index"`
+	PublicKey                           string `json:"publicKey"`
+	Status                              string `json:"status"`
+	Slashed                             bool   `json:"slashed"`
+	IsAwaitingActivationQueue           bool   `json:"isAwaitingActivationQueue"`
+	IsAwaitingWithdrawalCredentialProof bool   `json:"isAwaitingWithdrawalCredentialProof"`
+	EffectiveBalanceGwei                uint64 `json:"effectiveBalanceGwei"`
+	CurrentBalanceGwei                  uint64 `json:"currentBalanceGwei"`
+}
+
+type CheckpointResult struct {
+	StartedAt       uint64 `json:"startedAt"`
+	ProofsRemaining uint64 `json:"proofsRemaining"`
+}
+
+// StatusResult is the result of `status`, and of each pod in `fleet status`.
+type StatusResult struct {
+	PodOwner       string            `json:"podOwner"`
+	ProofSubmitter string            `json:"proofSubmitter"`
+	Validators     []ValidatorResult `json:"validators"`
+	// Unset if there's no checkpoint active.
+	ActiveCheckpoint             *CheckpointResult `json:"activeCheckpoint"`
+	NumberValidatorsToCheckpoint int               `json:"numberValidatorsToCheckpoint"`
+	// Decimal strings, so no precision is lost.
+	CurrentTotalSharesETH          string `json:"currentTotalSharesETH"`
+	TotalSharesAfterCheckpointGwei string `json:"totalSharesAfterCheckpointGwei"`
+	TotalSharesAfterCheckpointETH  string `json:"totalSharesAfterCheckpointETH"`
+	MustForceCheckpoint            bool   `json:"mustForceCheckpoint"`
+}
+
+var validatorStatusNames = map[int]string{
+	core.ValidatorStatusInactive:  "inactive",
+	core.ValidatorStatusActive:    "active",
+	core.ValidatorStatusWithdrawn: "withdrawn",
+}
+
+func formatFloat(f *big.Float) string {
+	if f == nil {
+		return "0"
+	}
+	return f.Text('f', -1)
+}
+
+func newStatusResult(status *core.EigenpodStatus) *StatusResult {
+	result := &StatusResult{
+		PodOwner:                       status.PodOwner.Hex(),
+		ProofSubmitter:                 status.ProofSubmitter.Hex(),
+		Validators:                     []ValidatorResult{},
+		NumberValidatorsToCheckpoint:   status.NumberValidatorsToCheckpoint,
+		CurrentTotalSharesETH:          formatFloat(status.CurrentTotalSharesETH),
+		TotalSharesAfterCheckpointGwei: formatFloat(status.TotalSharesAfterCheckpointGwei),
+		TotalSharesAfterCheckpointETH:  formatFloat(status.TotalSharesAfterCheckpointETH),
+		MustForceCheckpoint:            status.MustForceCheckpoint,
+	}
+	if status.ActiveCheckpoint != nil {
+		result.ActiveCheckpoint = &CheckpointResult{
+			StartedAt:       status.ActiveCheckpoint.StartedAt,
+			ProofsRemaining: status.ActiveCheckpoint.ProofsRemaining,
+		}
+	}
+	for _, validator := range status.Validators {
+		result.Validators = append(result.Validators, ValidatorResult{
+			Index:                               validator.Index,
+			PublicKey:                           validator.PublicKey,
+			Status:                              validatorStatusNames[validator.Status],
+			Slashed:                             validator.Slashed,
+			IsAwaitingActivationQueue:           validator.IsAwaitingActivationQueue,
+			IsAwaitingWithdrawalCredentialProof: validator.IsAwaitingWithdrawalCredentialProof,
+			EffectiveBalanceGwei:                validator.EffectiveBalance,
+			CurrentBalanceGwei:                  validator.CurrentBalance,
+		})
+	}
+	sort.Slice(result.Validators, func(i, j int) bool { return result.Validators[i].Index < result.Validators[j].Index })
+	return result
+}
+
+// StalePodsResult is the result of `find-stale-pods`.
+type StalePodsResult struct {
+	Pods []StalePodResult `json:"pods"`
+}
+
+type StalePodResult struct {
+	Address    string               `json:"address"`
+	Validators []StaleValidatorInfo `json:"validators"`
+}
+
+type StaleValidatorInfo struct {
+	Index                uint64 `json:"index"`
+	Slashed              bool   `json:"slashed"`
+	EffectiveBalanceGwei uint64 `json:"effectiveBalanceGwei"`
+}
+
+func newStalePodsResult(results map[string][]core.ValidatorWithIndex) *StalePodsResult {
+	result := &StalePodsResult{Pods: []StalePodResult{}}
+	for pod, validators := range results {
+		podResult := StalePodResult{Address: pod, Validators: []StaleValidatorInfo{}}
+		for _, validator := range validators {
+			podResult.Validators = append(podResult.Validators, StaleValidatorInfo{
+				Index:                validator.Index,
+				Slashed:              validator.Validator.Slashed,
+				EffectiveBalanceGwei: uint64(validator.Validator.EffectiveBalance),
+			})
+		}
+		result.Pods = append(result.Pods, podResult)
+	}
+	sort.Slice(result.Pods, func(i, j int) bool { return result.Pods[i].Address < result.Pods[j].Address })
+	return result
+}
+
+// FleetResult is the result of the `fleet` commands.
+type FleetResult struct {
+	Pods []FleetPodResult `json:"pods"`
+}
+
+type FleetPodResult struct {
+	Name    string        `json:"name,omitempty"`
+	Address string        `json:"address"`
+	Slot    uint64        `json:"slot,omitempty"`
+	Status  *StatusResult `json:"status,omitempty"`
+	Proofs  int           `json:"proofs"`
+	// Hashes of the transactions sent (or, if not sent, built).
+	Transactions []string `json:"transactions"`
+	Sent         bool     `json:"sent"`
+	Skipped      string   `json:"skipped,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+func newFleetResult(report *core.FleetReport) *FleetResult {
+	result := &FleetResult{Pods: []FleetPodResult{}}
+	for _, pod := range report.Pods {
+		podResult := FleetPodResult{
+			Name:         pod.Name,
+			Address:      pod.Address,
+			Slot:         pod.Slot,
+			Proofs:       pod.Proofs,
+			Transactions: pod.Transactions,
+			Sent:         pod.Sent,
+			Skipped:      pod.Skipped,
+			Error:        pod.Error,
+		}
+		if podResult.Transactions == nil {
+			podResult.Transactions = []string{}
+		}
+		if pod.Status != nil {
+			podResult.Status = newStatusResult(pod.Status)
+		}
+		result.Pods = append(result.Pods, podResult)
+	}
+	return result
+}
+
+// ConfigResult is the result of `config`.
+type ConfigResult struct {
+	ConfigFile string            `json:"configFile"`
+	Profile    string            `json:"profile"`
+	Settings   []ResolvedSetting `json:"settings"`
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// compares `got` with testdata/<name>.golden (or, with -update, rewrites it).
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(want), string(got))
+}
+
+// runs a command with `--output json`, and returns the Output it printed.
+func runWithJSONOutput(t *testing.T, run func()) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	format, writer := outputFormat, outputWriter
+	outputFormat, outputWriter = OutputJSON, &out
+	defer func() { outputFormat, outputWriter = format, writer }()
+
+	run()
+	return out.Bytes()
+}
+
+func TestOutputStatus(t *testing.T) {
+	status := &core.EigenpodStatus{
+		Validators: map[string]core.Validator{
+			"2": {Index: 2, PublicKey: "0x02", Status: core.ValidatorStatusInactive, IsAwaitingWithdrawalCredentialProof: true, EffectiveBalance: 32_000_000_000, CurrentBalance: 32_000_000_000},
+			"1": {Index: 1, PublicKey: "0x01", Status: core.ValidatorStatusActive, Slashed: true, EffectiveBalance: 31_000_000_000, CurrentBalance: 30_500_000_000},
+		},
+		ActiveCheckpoint:               &core.Checkpoint{ProofsRemaining: 1, StartedAt: 1_700_000_000},
+		NumberValidatorsToCheckpoint:   1,
+		CurrentTotalSharesETH:          big.NewFloat(31),
+		TotalSharesAfterCheckpointGwei: big.NewFloat(30_500_000_000),
+		TotalSharesAfterCheckpointETH:  big.NewFloat(30.5),
+		PodOwner:                       common.HexToAddress("0x00000000000000000000000000000000000000e1"),
+		ProofSubmitter:                 common.HexToAddress("0x00000000000000000000000000000000000005b1"),
+	}
+	out := runWithJSONOutput(t, func() {
+		BeginCommand("status")
+		setResult(newStatusResult(status))
+		assert.Nil(t, EndCommand(nil))
+	})
+	assertGolden(t, "status", out)
+}
+
+func TestOutputCheckpointSimulated(t *testing.T) {
+	pod := common.HexToAddress("0x000000000000000000000000000000000000b0d5")
+	txn := func(nonce uint64, data []byte, gas uint64) *types.Transaction {
+		return types.NewTx(&types.DynamicFeeTx{Nonce: nonce, To: &pod, Data: data, Gas: gas})
+	}
+	out := runWithJSONOutput(t, func() {
+		// as `checkpoint` without a sender: the transactions are built, but neither sent nor estimated
+		BeginCommand("checkpoint")
+		result := newTransactionsResult("checkpoint_start", nil, false /* sent */, false /* gasEstimated */)
+		setResult(result)
+		result.add("checkpoint_start", []*types.Transaction{txn(0, []byte{0x88, 0x67, 0x6c, 0xad, 0x00}, 0)}, false, false)
+		result.add("checkpoint_proof", []*types.Transaction{txn(1, []byte{0xf0, 0x74, 0xba, 0x62}, 0), nil}, false, false)
+		assert.Nil(t, EndCommand(nil))
+	})
+	assertGolden(t, "checkpoint_simulated", out)
+}
+
+// The Output of a command that fails exits the process, so is tested in a copy of it.
+func TestOutputExit(t *testing.T) {
+	if os.Getenv("EIGENPOD_TEST_OUTPUT_EXIT") != "" {
+		if err := SetOutputFormat(OutputJSON); err != nil {
+			t.Fatal(err)
+		}
+		BeginCommand("status")
+		PanicOnError("failed to load ethereum clients", errors.New("failed to fetch chain id: connection refused"))
+		t.Fatal("PanicOnError returned")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(os.Args[0], "-test.run=^TestOutputExit$")
+	cmd.Env = append(os.Environ(), "EIGENPOD_TEST_OUTPUT_EXIT=1")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("expected exit status 1, got %v (stderr: %s)", err, stderr.String())
+	}
+	// stdout holds only the Output, and the text goes to stderr
+	assertGolden(t, "exit", stdout.Bytes())
+	assert.True(t, strings.Contains(stderr.String(), "error: failed to load ethereum clients"), stderr.String())
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// asks on the terminal, for anything core needs from the user.
+var terminalInteraction = &core.Interaction{
+	Consent:    askTerminalConsent,
+	Passphrase: askTerminalPassphrase,
+}
+
+func Panic(message string) {
+	color.Red(fmt.Sprintf("error: %s\n\n", message))
+
+	finishCommand(errors.New(message))
+	if isJSONOutput() {
+		exitWithOutput(&OutputError{Message: message})
+	}
+	os.Exit(1)
+}
+
+func PanicOnError(message string, err error) {
+	if err != nil {
+		color.Red(fmt.Sprintf("error: %s\n\n", message))
+
+		info := color.New(color.FgRed, color.Italic)
+		info.Printf(fmt.Sprintf("caused by: %s\n", err))
+
+		finishCommand(fmt.Errorf("%s: %w", message, err))
+		if isJSONOutput() {
+			exitWithOutput(&OutputError{Message: message, Cause: err.Error()})
+		}
+		os.Exit(1)
+	}
+}
+
+func PanicIfNoConsent(prompt string) {
+	if !askTerminalConsent(prompt) {
+		Panic("abort.")
+	}
+}
+
+func askTerminalConsent(prompt string) bool {
+	color.New(color.Bold).Printf("%s - Do you want to proceed? (y/n): ", prompt)
+	var reply string
+
+	fmt.Scanln(&reply)
+	return reply == "y"
+}
+
+func askTerminalPassphrase(path string) (string, error) {
+	fmt.Fprintf(color.Output, "Passphrase for %s: ", path)
+	defer fmt.Fprintln(color.Output)
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		// e.g piped in, so there's nothing to echo
+		passphrase, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && passphrase == "" {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		return strings.TrimRight(passphrase, "\r\n"), nil
+	}
+
+	passphrase, err := term.ReadPassword(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(passphrase), nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/fatih/color"
 )
 
@@ -22,6 +23,10 @@ type TFixStaleBalanceArgs struct {
 	Verbose               bool
 	CheckpointBatchSize   uint64
 	NoPrompt              bool
+	// Write a plan for `apply` to this file, instead of sending anything.
+	Plan string
+	// Export the transactions as a Safe batch for this Safe, instead of sending them.
+	Safe string
 }
 
 type TransactionDescription struct {
@@ -39,76 +44,155 @@ func proofCast(proof []eigenpodproofs.Bytes32) [][32]byte {
 }
 
 func FixStaleBalance(args TFixStaleBalanceArgs) error {
-	ctx := context.Background()
+	ctx := commandContext()
 
 	sentTxns := []TransactionDescription{}
+	result := newTransactionsResult("", nil, true /* sent */, false /* gasEstimated */)
 
 	eth, beacon, chainId, err := core.GetClients(ctx, args.EthNode, args.BeaconNode, args.Verbose)
-	core.PanicOnError("failed to get clients", err)
+	PanicOnError("failed to get clients", err)
 
 	validator, err := beacon.GetValidator(ctx, args.SlashedValidatorIndex)
-	core.PanicOnError("failed to fetch validator state", err)
+	PanicOnError("failed to fetch validator state", err)
 
 	if !validator.Validator.Slashed {
-		core.Panic("Provided validator was not slashed.")
+		Panic("Provided validator was not slashed.")
 		return nil
 	}
 
-	ownerAccount, err := core.PrepareAccount(&args.Sender, chainId, false /* noSend */)
-	core.PanicOnError("failed to parse sender PK", err)
+	if args.Safe != "" {
+		args.Sender = safeSender(args.Safe)
+	} else if args.Sender == "" {
+		Panic("one of --sender or --safe is required")
+	}
+	ownerAccount, err := core.PrepareAccount(ctx, &args.Sender, chainId, args.Safe != "" /* noSend */)
+	PanicOnError("failed to parse sender PK", err)
 
 	eigenpod, err := onchain.NewEigenPod(common.HexToAddress(args.EigenpodAddress), eth)
-	core.PanicOnError("failed to reach eigenpod", err)
+	PanicOnError("failed to reach eigenpod", err)
 
 	currentCheckpointTimestamp, err := eigenpod.CurrentCheckpointTimestamp(nil)
-	core.PanicOnError("failed to fetch any existing checkpoint info", err)
+	PanicOnError("failed to fetch any existing checkpoint info", err)
+
+	setResult(result)
+	if args.Plan != "" {
+		return planFixStaleBalance(ctx, args, eth, beacon, chainId, ownerAccount, eigenpod, validator.Validator.PublicKey[:], currentCheckpointTimestamp)
+	}
+	if args.Safe != "" {
+		return exportFixStaleBalance(ctx, args, eth, beacon, chainId, ownerAccount, eigenpod, currentCheckpointTimestamp)
+	}
 
 	if currentCheckpointTimestamp > 0 {
 		if !args.NoPrompt {
-			core.PanicIfNoConsent(fmt.Sprintf("This eigenpod has an outstanding checkpoint (since %d). You must complete it before continuing. This will invoke `EigenPod.verifyCheckpointProofs()`, which will end the checkpoint. This may be expensive.", currentCheckpointTimestamp))
+			PanicIfNoConsent(fmt.Sprintf("This eigenpod has an outstanding checkpoint (since %d). You must complete it before continuing. This will invoke `EigenPod.verifyCheckpointProofs()`, which will end the checkpoint. This may be expensive.", currentCheckpointTimestamp))
 		}
 
 		proofs, err := core.GenerateCheckpointProof(ctx, args.EigenpodAddress, eth, chainId, beacon, args.Verbose)
-		core.PanicOnError("failed to generate checkpoint proofs", err)
+		PanicOnError("failed to generate checkpoint proofs", err)
 
-		txns, err := core.SubmitCheckpointProof(ctx, args.Sender, args.EigenpodAddress, chainId, proofs, eth, args.CheckpointBatchSize, args.NoPrompt, false /* noSend */, args.Verbose)
-		core.PanicOnError("failed to submit checkpoint proofs", err)
+		txns, err := core.SubmitCheckpointProof(ctx, args.Sender, args.EigenpodAddress, chainId, proofs, eth, args.CheckpointBatchSize, args.NoPrompt, false /* noSend */, args.Verbose, nil /* journal */)
+		PanicOnError("failed to submit checkpoint proofs", err)
 
 		for i, txn := range txns {
 			if args.Verbose {
-				fmt.Printf("sending txn[%d/%d]: %s (waiting)...", i, len(txns), txn.Hash())
+				fmt.Fprintf(color.Output, "sending txn[%d/%d]: %s (waiting)...", i, len(txns), txn.Hash())
 			}
-			bind.WaitMined(ctx, eth, txn)
+			_, err := core.WaitMined(ctx, eth, txn)
+			PanicOnError("failed to complete existing checkpoint", err)
 			sentTxns = append(sentTxns, TransactionDescription{Type: "complete_existing_checkpoint", Hash: txn.Hash().Hex()})
+			result.add("complete_existing_checkpoint", []*types.Transaction{txn}, true /* sent */, false /* gasEstimated */)
 		}
 	}
 
 	proof, oracleBeaconTimesetamp, err := core.GenerateValidatorProof(ctx, args.EigenpodAddress, eth, chainId, beacon, new(big.Int).SetUint64(args.SlashedValidatorIndex), args.Verbose)
-	core.PanicOnError("failed to generate credential proof for slashed validator", err)
+	PanicOnError("failed to generate credential proof for slashed validator", err)
 
 	if !args.NoPrompt {
-		core.PanicIfNoConsent("This will invoke `EigenPod.verifyStaleBalance()` on the given eigenpod, which will start a checkpoint. Once started, this checkpoint must be completed.")
+		PanicIfNoConsent("This will invoke `EigenPod.verifyStaleBalance()` on the given eigenpod, which will start a checkpoint. Once started, this checkpoint must be completed.")
 	}
 
 	if args.Verbose {
 		color.Black("Calling EigenPod.verifyStaleBalance() to force checkpoint this pod.")
 	}
 
-	txn, err := eigenpod.VerifyStaleBalance(
-		ownerAccount.TransactionOptions,
-		oracleBeaconTimesetamp,
-		onchain.BeaconChainProofsStateRootProof{
-			Proof:           proof.StateRootProof.Proof.ToByteSlice(),
-			BeaconStateRoot: proof.StateRootProof.BeaconStateRoot,
-		},
-		onchain.BeaconChainProofsValidatorProof{
-			ValidatorFields: proofCast(proof.ValidatorFields[0]),
-			Proof:           proof.ValidatorFieldsProofs[0].ToByteSlice(),
-		},
-	)
-	core.PanicOnError("failed to call verifyStaleBalance()", err)
+	txn, err := core.SendTransaction(ctx, eth, ownerAccount, verifyStaleBalance(eigenpod, proof, oracleBeaconTimesetamp), args.Verbose)
+	PanicOnError("failed to call verifyStaleBalance()", err)
 	sentTxns = append(sentTxns, TransactionDescription{Type: "verify_stale_balance", Hash: txn.Hash().Hex()})
+	result.add("verify_stale_balance", []*types.Transaction{txn}, true /* sent */, false /* gasEstimated */)
 
 	printAsJSON(sentTxns)
 	return nil
 }
+
+func verifyStaleBalance(eigenpod *onchain.EigenPod, proof *eigenpodproofs.VerifyValidatorFieldsCallParams, oracleBeaconTimestamp uint64) core.TxBuilder {
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return eigenpod.VerifyStaleBalance(
+			opts,
+			oracleBeaconTimestamp,
+			onchain.BeaconChainProofsStateRootProof{
+				Proof:           proof.StateRootProof.Proof.ToByteSlice(),
+				BeaconStateRoot: proof.StateRootProof.BeaconStateRoot,
+			},
+			onchain.BeaconChainProofsValidatorProof{
+				ValidatorFields: proofCast(proof.ValidatorFields[0]),
+				Proof:           proof.ValidatorFieldsProofs[0].ToByteSlice(),
+			},
+		)
+	}
+}
+
+// writes what `correct-stale-pod` would send to `args.Plan`.
+func planFixStaleBalance(ctx context.Context, args TFixStaleBalanceArgs, eth core.ExecutionClient, beacon core.BeaconClient, chainId *big.Int, ownerAccount *core.Owner, eigenpod *onchain.EigenPod, validatorPubkey []byte, currentCheckpointTimestamp uint64) error {
+	plan, err := core.NewPlan(ctx, "correct-stale-pod", eth, chainId, args.EigenpodAddress, ownerAccount.FromAddress, [][]byte{validatorPubkey})
+	PanicOnError("failed to read pod state", err)
+
+	if currentCheckpointTimestamp > 0 {
+		proofs, err := core.GenerateCheckpointProof(ctx, args.EigenpodAddress, eth, chainId, beacon, args.Verbose)
+		PanicOnError("failed to generate checkpoint proofs", err)
+
+		txns, err := core.SubmitCheckpointProof(ctx, args.Sender, args.EigenpodAddress, chainId, proofs, eth, args.CheckpointBatchSize, true /* noPrompt */, true /* noSend */, args.Verbose, nil /* journal */)
+		PanicOnError("failed to simulate checkpoint proofs", err)
+		plan.AddTransactions(core.PlanStepCheckpointProof, "EigenPod.verifyCheckpointProofs(), completing the outstanding checkpoint", txns...)
+	}
+
+	proof, oracleBeaconTimestamp, err := core.GenerateValidatorProof(ctx, args.EigenpodAddress, eth, chainId, beacon, new(big.Int).SetUint64(args.SlashedValidatorIndex), args.Verbose)
+	PanicOnError("failed to generate credential proof for slashed validator", err)
+
+	// with a checkpoint outstanding, verifyStaleBalance() can't be simulated until it's complete.
+	err = plan.AddCall(core.PlanStepVerifyStaleBalance, fmt.Sprintf("EigenPod.verifyStaleBalance() for validator %d, starting a checkpoint", args.SlashedValidatorIndex), ownerAccount.TransactionOptions, verifyStaleBalance(eigenpod, proof, oracleBeaconTimestamp), currentCheckpointTimestamp == 0)
+	PanicOnError("failed to simulate verifyStaleBalance()", err)
+
+	plan.Notes = append(plan.Notes,
+		fmt.Sprintf("The verifyStaleBalance() proof is against the beacon block root at timestamp %d, and must be applied within ~27 hours of it.", oracleBeaconTimestamp),
+		"verifyStaleBalance() starts a checkpoint, which must then be completed with `checkpoint`.",
+	)
+	return savePlan(plan, args.Plan)
+}
+
+// prints what `correct-stale-pod` would send as a Safe batch. The Safe executes the batch in order, so
+// completing an outstanding checkpoint and verifyStaleBalance() can go in one batch.
+func exportFixStaleBalance(ctx context.Context, args TFixStaleBalanceArgs, eth core.ExecutionClient, beacon core.BeaconClient, chainId *big.Int, ownerAccount *core.Owner, eigenpod *onchain.EigenPod, currentCheckpointTimestamp uint64) error {
+	txns := []*types.Transaction{}
+	result := newTransactionsResult("", nil, false /* sent */, false /* gasEstimated */)
+	setResult(result)
+	if currentCheckpointTimestamp > 0 {
+		proofs, err := core.GenerateCheckpointProof(ctx, args.EigenpodAddress, eth, chainId, beacon, args.Verbose)
+		PanicOnError("failed to generate checkpoint proofs", err)
+
+		checkpointTxns, err := core.SubmitCheckpointProof(ctx, args.Sender, args.EigenpodAddress, chainId, proofs, eth, args.CheckpointBatchSize, true /* noPrompt */, true /* noSend */, args.Verbose, nil /* journal */)
+		PanicOnError("failed to build checkpoint proof transactions", err)
+		txns = append(txns, checkpointTxns...)
+		result.add("checkpoint_proof", checkpointTxns, false /* sent */, false /* gasEstimated */)
+	}
+
+	proof, oracleBeaconTimestamp, err := core.GenerateValidatorProof(ctx, args.EigenpodAddress, eth, chainId, beacon, new(big.Int).SetUint64(args.SlashedValidatorIndex), args.Verbose)
+	PanicOnError("failed to generate credential proof for slashed validator", err)
+
+	txn, err := core.SendTransaction(ctx, eth, ownerAccount, verifyStaleBalance(eigenpod, proof, oracleBeaconTimestamp), args.Verbose)
+	PanicOnError("failed to build verifyStaleBalance() transaction", err)
+	txns = append(txns, txn)
+	result.add("verify_stale_balance", []*types.Transaction{txn}, false /* sent */, false /* gasEstimated */)
+
+	printSafeBatch(chainId, args.Safe, "Correct stale pod", fmt.Sprintf("Completes EigenPod(%s)'s outstanding checkpoint, if any (%d txn(s)), then calls verifyStaleBalance() for validator %d, which starts a checkpoint that must be completed with `checkpoint`.", args.EigenpodAddress, len(txns)-1, args.SlashedValidatorIndex), txns)
+	return nil
+}
@@ -1,7 +1,6 @@
 package commands
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -23,7 +22,7 @@ type TStatusArgs struct {
 }
 
 func StatusCommand(args TStatusArgs) error {
-	ctx := context.Background()
+	ctx := commandContext()
 	if args.DisableColor {
 		color.NoColor = true
 	}
@@ -31,15 +30,17 @@ func StatusCommand(args TStatusArgs) error {
 	isVerbose := !args.UseJSON
 
 	eth, beaconClient, _, err := core.GetClients(ctx, args.Node, args.BeaconNode, isVerbose)
-	core.PanicOnError("failed to load ethereum clients", err)
+	PanicOnError("failed to load ethereum clients", err)
 
-	status := core.GetStatus(ctx, args.EigenpodAddress, eth, beaconClient)
+	status, err := core.GetStatus(ctx, args.EigenpodAddress, eth, beaconClient)
+	PanicOnError("failed to get status", err)
+	setResult(newStatusResult(&status))
 
 	if args.UseJSON {
 		bytes, err := json.MarshalIndent(status, "", "      ")
-		core.PanicOnError("failed to get status", err)
+		PanicOnError("failed to get status", err)
 		statusStr := string(bytes)
-		fmt.Println(statusStr)
+		fmt.Fprintln(color.Output, statusStr)
 		return nil
 	} else {
 		bold := color.New(color.Bold, color.FgBlue)
@@ -51,7 +52,7 @@ func StatusCommand(args TStatusArgs) error {
 		ylw.Printf("%s\n", status.PodOwner)
 		ital.Printf("- Proof submitter address: ")
 		ylw.Printf("%s\n", status.ProofSubmitter)
-		fmt.Println()
+		fmt.Fprintln(color.Output)
 
 		// sort validators by status
 		awaitingActivationQueueValidators, inactiveValidators, activeValidators, withdrawnValidators :=
@@ -85,7 +86,7 @@ func StatusCommand(args TStatusArgs) error {
 				}
 			}
 
-			fmt.Println()
+			fmt.Fprintln(color.Output)
 		}
 
 		// print info on inactive validators
@@ -107,7 +108,7 @@ func StatusCommand(args TStatusArgs) error {
 				}
 			}
 
-			fmt.Println()
+			fmt.Fprintln(color.Output)
 		}
 
 		// print info on active validators
@@ -130,7 +131,7 @@ func StatusCommand(args TStatusArgs) error {
 				}
 			}
 
-			fmt.Println()
+			fmt.Fprintln(color.Output)
 		}
 
 		// print info on withdrawn validators
@@ -153,7 +154,7 @@ func StatusCommand(args TStatusArgs) error {
 				}
 			}
 
-			fmt.Println()
+			fmt.Fprintln(color.Output)
 		}
 
 		// Calculate the change in shares for completing a checkpoint
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fatih/color"
+)
+
+type TSubmitCommandArgs struct {
+	ProofFile           string
+	EigenpodAddress     string
+	Node                string
+	Sender              string
+	DisableColor        bool
+	NoPrompt            bool
+	SimulateTransaction bool
+	// 0 sizes batches by gas.
+	BatchSize uint64
+	Verbose   bool
+}
+
+// SubmitCommand submits a proof written earlier by `checkpoint --out` or `credentials --out`.
+func SubmitCommand(args TSubmitCommandArgs) error {
+	ctx := commandContext()
+
+	if args.DisableColor {
+		color.NoColor = true
+	}
+	if args.ProofFile == "" {
+		return fmt.Errorf("usage: `submit [FLAGS] <proof.json>`")
+	}
+
+	isGasEstimate := args.SimulateTransaction && args.Sender != ""
+	isVerbose := !args.SimulateTransaction || args.Verbose
+
+	kind, err := core.DetectProofFileKind(args.ProofFile)
+	PanicOnError("failed to read proof file", err)
+
+	eth, err := core.DialExecutionClient(ctx, args.Node)
+	PanicOnError("failed to reach eth --node", err)
+
+	chainId, err := eth.ChainID(ctx)
+	PanicOnError("failed to fetch chain id", err)
+
+	switch kind {
+	case core.ProofFileCheckpoint:
+		proof, err := core.LoadCheckpointProofFromFile(args.ProofFile)
+		PanicOnError("failed to load checkpoint proof", err)
+
+		currentCheckpoint, err := core.GetCurrentCheckpoint(args.EigenpodAddress, eth)
+		PanicOnError("failed to load checkpoint", err)
+		PanicOnError("the checkpoint proof can't be submitted", proof.Check(args.EigenpodAddress, currentCheckpoint))
+
+		txns, err := core.SubmitCheckpointProof(ctx, args.Sender, args.EigenpodAddress, chainId, proof.VerifyCheckpointProofsCallParams, eth, args.BatchSize, args.NoPrompt, args.SimulateTransaction, isVerbose, nil /* journal */)
+		setResult(newTransactionsResult("checkpoint_proof", txns, !args.SimulateTransaction, isGasEstimate))
+		if args.SimulateTransaction {
+			printAsJSON(utils.Map(txns, func(txn *types.Transaction, _ uint64) Transaction {
+				gas := txn.Gas()
+				return Transaction{
+					To:       txn.To().Hex(),
+					CallData: common.Bytes2Hex(txn.Data()),
+					Type:     "checkpoint_proof",
+					GasEstimateGwei: func() *uint64 {
+						if isGasEstimate {
+							return &gas
+						}
+						return nil
+					}(),
+				}
+			}))
+		} else {
+			for i, txn := range txns {
+				color.Green("transaction(%d): %s", i, txn.Hash().Hex())
+			}
+		}
+		PanicOnError("an error occurred while submitting your checkpoint proofs", err)
+	case core.ProofFileCredentials:
+		proof, err := core.LoadValidatorProofFromFile(args.ProofFile)
+		PanicOnError("failed to load credential proof", err)
+
+		currentCheckpoint, err := core.GetCurrentCheckpoint(args.EigenpodAddress, eth)
+		PanicOnError("failed to load checkpoint", err)
+		PanicOnError("the credential proof can't be submitted", proof.Check(args.EigenpodAddress, currentCheckpoint))
+
+		txns, indices, err := core.SubmitValidatorProof(ctx, args.Sender, args.EigenpodAddress, chainId, eth, args.BatchSize, proof.ValidatorProofs, proof.OracleBeaconTimestamp, args.NoPrompt, args.SimulateTransaction, isVerbose)
+		PanicOnError("failed to submit credential proof", err)
+		setResult(newCredentialsResult(txns, indices, !args.SimulateTransaction, isGasEstimate))
+
+		if args.SimulateTransaction {
+			printAsJSON(utils.Map(txns, func(txn *types.Transaction, i uint64) CredentialProofTransaction {
+				gas := txn.Gas()
+				return CredentialProofTransaction{
+					Transaction: Transaction{
+						Type:     "credential_proof",
+						To:       txn.To().Hex(),
+						CallData: common.Bytes2Hex(txn.Data()),
+						GasEstimateGwei: func() *uint64 {
+							if isGasEstimate {
+								return &gas
+							}
+							return nil
+						}(),
+					},
+					ValidatorIndices: utils.Map(indices[i], func(index *big.Int, _ uint64) uint64 {
+						return index.Uint64()
+					}),
+				}
+			}))
+		} else {
+			for i, txn := range txns {
+				color.Green("transaction(%d): %s", i, txn.Hash().Hex())
+			}
+		}
+	}
+
+	return nil
+}
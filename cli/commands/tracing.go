@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+)
+
+// set by main with `--trace`
+var tracingCallbacks *core.TracerCallbacks
+var flushTraces func(ctx context.Context) error
+
+// the command's own span, which everything it does is nested in
+var commandTraceCtx = context.Background()
+var endCommandTrace = func(err error) {}
+
+// SetTracing traces every command with `callbacks`, calling `flush` once it's done (or has failed).
+func SetTracing(callbacks *core.TracerCallbacks, flush func(ctx context.Context) error) {
+	tracingCallbacks = callbacks
+	flushTraces = flush
+}
+
+func startCommandTrace(name string) {
+	if tracingCallbacks == nil || tracingCallbacks.OnStartSpan == nil {
+		return
+	}
+	ctx := tracingCallbacks.OnStartSpan(context.Background(), "eigenpod::"+strings.ReplaceAll(name, " ", "::"), map[string]string{})
+	commandTraceCtx = ctx
+	endCommandTrace = func(err error) {
+		endCommandTrace = func(error) {}
+		if tracingCallbacks.OnEndSpan != nil {
+			tracingCallbacks.OnEndSpan(ctx, err)
+		}
+		if flushTraces != nil {
+			// don't hang on an unreachable collector
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = flushTraces(flushCtx)
+		}
+	}
+}
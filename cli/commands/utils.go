@@ -1,12 +1,75 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/big"
+	"sync"
 
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/rpcreplay"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fatih/color"
 )
 
+// set by the global `--record` / `--replay` flags
+var rpcInterceptor rpcreplay.Interceptor
+
+func SetRPCInterceptor(interceptor rpcreplay.Interceptor) {
+	rpcInterceptor = interceptor
+}
+
+// what the command opens (e.g the servers intercepting beacon traffic), released when it ends
+var commandResources = &core.Resources{}
+var finishOnce sync.Once
+
+// validator infos the command has read (see core.ValidatorInfoCache)
+var commandValidatorInfo = core.NewValidatorInfoCache()
+
+// finishCommand releases what the command opened (and the `--record` file), and ends its trace. It's called
+// however the command ends, including by Panic.
+func finishCommand(err error) {
+	finishOnce.Do(func() {
+		_ = commandResources.Close()
+		if closer, ok := rpcInterceptor.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		endCommandTrace(err)
+	})
+}
+
+// set by the global transaction flags (e.g `--inFlight`)
+var txConfig core.TxConfig
+
+func SetTxConfig(config core.TxConfig) {
+	txConfig = config
+}
+
+// set by the global `--passwordFile` flag
+var signerConfig core.SignerConfig
+
+func SetSignerConfig(config core.SignerConfig) {
+	signerConfig = config
+}
+
+func commandContext() context.Context {
+	ctx := commandTraceCtx
+	if tracingCallbacks != nil {
+		ctx = core.ContextWithTracing(ctx, tracingCallbacks)
+	}
+	if rpcInterceptor != nil {
+		ctx = core.ContextWithRPCInterceptor(ctx, rpcInterceptor)
+	}
+	ctx = core.ContextWithResources(ctx, commandResources)
+	ctx = core.ContextWithValidatorInfoCache(ctx, commandValidatorInfo)
+	ctx = core.ContextWithSignerConfig(ctx, signerConfig)
+	ctx = core.ContextWithInteraction(ctx, terminalInteraction)
+	return core.ContextWithTxConfig(ctx, txConfig)
+}
+
 type Transaction struct {
 	Type            string  `json:"type"`
 	To              string  `json:"to"`
@@ -22,6 +85,23 @@ type CredentialProofTransaction struct {
 
 func printAsJSON(txns any) {
 	out, err := json.Marshal(txns)
-	core.PanicOnError("failed to serialize", err)
-	fmt.Println(string(out))
+	PanicOnError("failed to serialize", err)
+	fmt.Fprintln(color.Output, string(out))
+}
+
+// checks a `--safe` address, and returns the sender that builds transactions for it.
+func safeSender(safe string) string {
+	if !common.IsHexAddress(safe) {
+		Panic(fmt.Sprintf("invalid --safe address: %s", safe))
+	}
+	return core.SafeSender(safe)
+}
+
+// prints transactions built for a Safe (see safeSender) as a Safe Transaction Builder batch.
+func printSafeBatch(chainId *big.Int, safe, name, description string, txns []*types.Transaction) {
+	batch := core.NewSafeBatch(chainId, common.HexToAddress(safe), name, description, txns)
+	if result, ok := currentResult.(*TransactionsResult); ok {
+		result.SafeBatch = batch
+	}
+	printAsJSON(batch)
 }
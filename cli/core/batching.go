@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// Batches are sized to stay under this much gas (or the block gas limit, if lower), unless `--gasTarget` says otherwise.
+const DEFAULT_GAS_TARGET = 15_000_000
+
+// the largest batch estimated when measuring how much gas each proof adds.
+const gasSampleSize = 8
+
+// batchRange is the proofs [start, end) of one transaction.
+type batchRange struct {
+	start int
+	end   int
+}
+
+func (r batchRange) size() int {
+	return r.end - r.start
+}
+
+func proofsIn(batches []batchRange) int {
+	count := 0
+	for _, batch := range batches {
+		count += batch.size()
+	}
+	return count
+}
+
+// estimates the gas of a transaction submitting proofs [start, end).
+type gasEstimator func(start, end int) (uint64, error)
+
+// fixedBatches splits `count` proofs into batches of `batchSize`, as `chunk` does.
+func fixedBatches(count int, batchSize uint64) []batchRange {
+	batches := []batchRange{}
+	for start := 0; start < count; start += int(batchSize) {
+		batches = append(batches, batchRange{start: start, end: min(start+int(batchSize), count)})
+	}
+	return batches
+}
+
+// planBatchesByGas partitions `count` proofs into the fewest transactions that each stay under the gas
+// target (capped at the current block gas limit).
+//
+// The gas of one proof, and of a small batch, give the fixed and per-proof cost of a transaction, and so
+// the largest batch that fits. Every planned batch is then estimated on its own, and split in half if the
+// estimate fails or comes in over the target.
+func planBatchesByGas(ctx context.Context, eth ExecutionClient, count int, gasTarget uint64, estimate gasEstimator, verbose bool) ([]batchRange, error) {
+	if count == 0 {
+		return []batchRange{}, nil
+	}
+
+	header, err := eth.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest block: %w", err)
+	}
+	if gasTarget == 0 {
+		gasTarget = DEFAULT_GAS_TARGET
+	}
+	gasTarget = min(gasTarget, header.GasLimit)
+
+	single, err := estimate(0, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate gas for a single proof: %w", err)
+	}
+
+	// the per-proof cost. Without a usable sample, assume nothing is shared between proofs.
+	perProof := single
+	for sample := min(count, gasSampleSize); sample > 1; sample /= 2 {
+		gas, err := estimate(0, sample)
+		if err == nil && gas > single {
+			perProof = (gas - single) / uint64(sample-1)
+			break
+		}
+	}
+	fixed := single - min(perProof, single)
+
+	batchSize := 1
+	if fixed < gasTarget && perProof > 0 {
+		batchSize = max(1, int((gasTarget-fixed)/perProof))
+	}
+	numBatches := (count + batchSize - 1) / batchSize
+
+	// spread the proofs evenly, rather than leaving a small last batch
+	candidates := []batchRange{}
+	for i := 0; i < numBatches; i++ {
+		candidates = append(candidates, batchRange{start: i * count / numBatches, end: (i + 1) * count / numBatches})
+	}
+
+	batches := []batchRange{}
+	var fit func(batch batchRange) error
+	fit = func(batch batchRange) error {
+		gas, err := estimate(batch.start, batch.end)
+		if err == nil && gas <= gasTarget {
+			batches = append(batches, batch)
+			return nil
+		}
+		if batch.size() == 1 {
+			if err != nil {
+				return fmt.Errorf("failed to estimate gas for proof %d: %w", batch.start, err)
+			}
+			return fmt.Errorf("proof %d alone needs %d gas, over the target of %d", batch.start, gas, gasTarget)
+		}
+
+		mid := batch.start + batch.size()/2
+		if err := fit(batchRange{start: batch.start, end: mid}); err != nil {
+			return err
+		}
+		return fit(batchRange{start: mid, end: batch.end})
+	}
+	for _, batch := range candidates {
+		if err := fit(batch); err != nil {
+			return nil, err
+		}
+	}
+
+	if verbose {
+		sizes := make([]int, len(batches))
+		for i, batch := range batches {
+			sizes[i] = batch.size()
+		}
+		logInfo(ctx, "planned %d txn(s) to stay under %d gas (~%d gas + %d per proof): batch sizes %v", len(batches), gasTarget, fixed, perProof, sizes)
+	}
+	return batches, nil
+}
+
+// planBatches uses `batchSize` if it's set, and otherwise sizes batches by gas. If gas can't be estimated
+// (e.g there's no sender to estimate from), it falls back to `defaultBatchSize`.
+func planBatches(ctx context.Context, eth ExecutionClient, count int, batchSize, defaultBatchSize uint64, canEstimate bool, estimate gasEstimator, verbose bool) []batchRange {
+	if batchSize > 0 {
+		return fixedBatches(count, batchSize)
+	}
+	if canEstimate {
+		batches, err := planBatchesByGas(ctx, eth, count, GetContextTxConfig(ctx).GasTarget, estimate, verbose)
+		if err == nil {
+			return batches
+		}
+		if verbose {
+			logWarn(ctx, "failed to size batches by gas, using %d proofs per txn: %s", defaultBatchSize, err.Error())
+		}
+	}
+	return fixedBatches(count, defaultBatchSize)
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestPlanBatchesByGas(t *testing.T) {
+	_, eth := newFakeNode(t, big.NewInt(10e9), big.NewInt(0))
+	ctx := context.Background()
+
+	// 50k gas per transaction, and 100k per proof
+	gas := func(start, end int) (uint64, error) {
+		return 50_000 + 100_000*uint64(end-start), nil
+	}
+
+	batches, err := planBatchesByGas(ctx, eth, 20, 1_000_000, gas, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBatches(t, batches, 20, []int{6, 7, 7})
+
+	// estimates of more than 5 proofs fail, so planned batches of 7 get split
+	batches, err = planBatchesByGas(ctx, eth, 20, 1_000_000, func(start, end int) (uint64, error) {
+		if end-start > 5 {
+			return 0, errors.New("execution reverted")
+		}
+		return gas(start, end)
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBatches(t, batches, 20, []int{3, 3, 3, 4, 3, 4})
+
+	if _, err := planBatchesByGas(ctx, eth, 3, 100_000, gas, false); err == nil {
+		t.Error("expected an error when a single proof is over the target")
+	}
+}
+
+func assertBatches(t *testing.T, batches []batchRange, count int, sizes []int) {
+	t.Helper()
+
+	if len(batches) != len(sizes) {
+		t.Fatalf("expected %d batches, got %v", len(sizes), batches)
+	}
+	next := 0
+	for i, batch := range batches {
+		if batch.start != next || batch.size() != sizes[i] {
+			t.Fatalf("expected batch sizes %v, got %v", sizes, batches)
+		}
+		next = batch.end
+	}
+	if next != count {
+		t.Fatalf("batches cover %d of %d proofs", next, count)
+	}
+}
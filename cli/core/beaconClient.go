@@ -25,15 +25,19 @@ type BeaconClient interface {
 	GetBeaconHeader(ctx context.Context, blockId string) (*v1.BeaconBlockHeader, error)
 	GetBeaconState(ctx context.Context, stateId string) (*spec.VersionedBeaconState, error)
 	GetValidator(ctx context.Context, index uint64) (*v1.Validator, error)
+
+	// Subscribe streams beacon chain events for `topics` until `ctx` is done.
+	Subscribe(ctx context.Context, topics ...EventTopic) (*BeaconEvents, error)
 }
 
 type beaconClient struct {
 	eth2client eth2client.Service
+	endpoint   string
 	verbose    bool
 }
 
 func NewBeaconClient(endpoint string, verbose bool) (BeaconClient, context.CancelFunc, error) {
-	beaconClient := beaconClient{verbose: verbose}
+	beaconClient := beaconClient{endpoint: endpoint, verbose: verbose}
 	ctx, cancel := context.WithCancel(context.Background())
 
 	client, err := http.New(ctx,
@@ -91,6 +95,10 @@ func (b *beaconClient) GetValidator(ctx context.Context, index uint64) (*v1.Vali
 	return nil, ErrBeaconClientNotSupported
 }
 
+func (b *beaconClient) Subscribe(ctx context.Context, topics ...EventTopic) (*BeaconEvents, error) {
+	return subscribeEvents(ctx, b.endpoint, topics, b.verbose)
+}
+
 func (b *beaconClient) GetBeaconState(ctx context.Context, stateId string) (*spec.VersionedBeaconState, error) {
 	timeout, _ := time.ParseDuration("200s")
 	if provider, ok := b.eth2client.(eth2client.BeaconStateProvider); ok {
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beaconmock"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacontest"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+)
+
+func startMockBeaconNode(t *testing.T, scenarios ...beaconmock.Scenario) BeaconClient {
+	t.Helper()
+
+	fixtures := beaconmock.NewFixtures()
+	for _, slot := range []phase0.Slot{64, 96} {
+		fixture, err := beacontest.NewStateBuilder(slot).AddValidators(4, beacontest.Validator{}).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, fixtures.AddState(fixture.State))
+	}
+
+	mock, err := beaconmock.New(fixtures, scenarios...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+
+	client, cancel, err := NewBeaconClient(server.URL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cancel)
+	return client
+}
+
+func TestBeaconClientAgainstMockNode(t *testing.T) {
+	client := startMockBeaconNode(t)
+	ctx := context.Background()
+
+	header, err := client.GetBeaconHeader(ctx, "head")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, phase0.Slot(96), header.Header.Message.Slot)
+
+	state, err := client.GetBeaconState(ctx, "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot, err := state.Slot()
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Slot(64), slot)
+
+	// the header's state root must commit to the state served for that slot
+	stateRoot, err := state.Deneb.HashTreeRoot()
+	assert.Nil(t, err)
+	header, err = client.GetBeaconHeader(ctx, "64")
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Root(stateRoot), header.Header.Message.StateRoot)
+
+	validator, err := client.GetValidator(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, beacontest.Pubkey(2), validator.Validator.PublicKey)
+
+	_, err = client.GetBeaconState(ctx, "65")
+	assert.NotNil(t, err)
+}
+
+func TestBeaconClientScenarios(t *testing.T) {
+	reorged := &phase0.BeaconBlockHeader{Slot: 96, ProposerIndex: 7}
+	client := startMockBeaconNode(t,
+		beaconmock.Scenario{Path: "/eth/v2/debug/beacon/states/64", Action: beaconmock.ActionMissing, Times: 1},
+		beaconmock.Scenario{Path: "/eth/v2/debug/beacon/states/96", Action: beaconmock.ActionSlow, Delay: beaconmock.Duration(2 * time.Second)},
+		beaconmock.Scenario{Path: "/eth/v1/beacon/headers/head", Action: beaconmock.ActionReorg, Header: reorged, After: 1},
+	)
+	ctx := context.Background()
+
+	// missing once, then served
+	_, err := client.GetBeaconState(ctx, "64")
+	assert.NotNil(t, err)
+	_, err = client.GetBeaconState(ctx, "64")
+	assert.Nil(t, err)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = client.GetBeaconState(timeoutCtx, "96")
+	assert.NotNil(t, err)
+
+	header, err := client.GetBeaconHeader(ctx, "head")
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.ValidatorIndex(0), header.Header.Message.ProposerIndex)
+
+	header, err = client.GetBeaconHeader(ctx, "head")
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.ValidatorIndex(7), header.Header.Message.ProposerIndex)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"os"
@@ -11,72 +12,145 @@ import (
 
 	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/utils"
 	v1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/fatih/color"
 )
 
-func SubmitCheckpointProof(ctx context.Context, owner, eigenpodAddress string, chainId *big.Int, proof *eigenpodproofs.VerifyCheckpointProofsCallParams, eth *ethclient.Client, batchSize uint64, noPrompt bool, noSend bool, verbose bool) ([]*types.Transaction, error) {
-	tracing := GetContextTracingCallbacks(ctx)
+// If `journal` is set, each batch is recorded in it as it's sent, and proofs for validators the journal
+// already has as checkpointed are skipped.
+func SubmitCheckpointProof(ctx context.Context, owner, eigenpodAddress string, chainId *big.Int, proof *eigenpodproofs.VerifyCheckpointProofsCallParams, eth ExecutionClient, batchSize uint64, noPrompt bool, noSend bool, verbose bool, journal *CheckpointJournal) ([]*types.Transaction, error) {
+	balanceProofs := proof.BalanceProofs
+	var validatorIndices []uint64
+	if journal != nil {
+		var err error
+		balanceProofs, validatorIndices, err = skipCheckpointedProofs(ctx, eigenpodAddress, eth, balanceProofs, journal)
+		if err != nil {
+			return nil, err
+		}
+		if verbose && len(balanceProofs) < len(proof.BalanceProofs) {
+			logWarn(ctx, "skipping %d proof(s) already submitted (journal: %s)", len(proof.BalanceProofs)-len(balanceProofs), journal.Path())
+		}
+	}
 
-	allProofChunks := chunk(proof.BalanceProofs, batchSize)
-	transactions := []*types.Transaction{}
+	ownerAccount, err := PrepareAccount(ctx, &owner, chainId, noSend)
+	if err != nil {
+		return nil, err
+	}
 	if verbose {
-		color.Green("calling EigenPod.VerifyCheckpointProofs() (using %d txn(s), max(%d) proofs per txn)", len(allProofChunks), batchSize)
+		logPlain(ctx, "Using account(0x%s) to submit onchain\n", common.Bytes2Hex(ownerAccount.FromAddress[:]))
+	}
+
+	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
+	if err != nil {
+		return nil, err
 	}
 
-	for i := 0; i < len(allProofChunks); i++ {
-		balanceProofs := allProofChunks[i]
-		tracing.OnStartSection("pepe::proof::checkpoint::batch::submit", map[string]string{
-			"chunk": fmt.Sprintf("%d", i),
-		})
-		txn, err := SubmitCheckpointProofBatch(ctx, owner, eigenpodAddress, chainId, proof.ValidatorBalancesRootProof, balanceProofs, eth, noSend, verbose)
-		tracing.OnEndSection()
+	// a batch size of 0 sizes batches by gas
+	batches := planBatches(ctx, eth, len(balanceProofs), batchSize, utils.DEFAULT_BATCH_CHECKPOINT, owner != "", func(start, end int) (uint64, error) {
+		txn, err := verifyCheckpointProofs(ctx, eigenPod, withDryRun(ownerAccount.TransactionOptions), eigenpodAddress, proof.ValidatorBalancesRootProof, balanceProofs[start:end])
 		if err != nil {
-			// failed to submit batch.
-			return transactions, err
+			return 0, err
 		}
-		transactions = append(transactions, txn)
-		if verbose {
-			fmt.Printf("Submitted chunk %d/%d -- waiting for transaction...: ", i+1, len(allProofChunks))
+		return txn.Gas(), nil
+	}, verbose)
+
+	if owner != "" {
+		batches, err = preflight(ctx, batches, func(start, end int) error {
+			return simulateTx(ctx, eth, ownerAccount.TransactionOptions, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return verifyCheckpointProofs(ctx, eigenPod, opts, eigenpodAddress, proof.ValidatorBalancesRootProof, balanceProofs[start:end])
+			})
+		}, func(i int) string {
+			if validatorIndices != nil {
+				return fmt.Sprintf("validator %d", validatorIndices[i])
+			}
+			if info, err := eigenPod.ValidatorPubkeyHashToInfo(nil, balanceProofs[i].PubkeyHash); err == nil {
+				return fmt.Sprintf("validator %d", info.ValidatorIndex)
+			}
+			return fmt.Sprintf("validator with pubkey hash 0x%s", common.Bytes2Hex(balanceProofs[i].PubkeyHash[:]))
+		}, verbose)
+		if err != nil {
+			return nil, err
 		}
-		tracing.OnStartSection("pepe::proof::checkpoint::batch::wait", map[string]string{
-			"chunk": fmt.Sprintf("%d", i),
-		})
+		if verbose && proofsIn(batches) < len(balanceProofs) {
+			logWarn(ctx, "the checkpoint can't complete until every validator is proven")
+		}
+	}
 
-		if !noSend {
-			bind.WaitMined(ctx, eth, txn)
+	if verbose {
+		logInfo(ctx, "calling EigenPod.VerifyCheckpointProofs() (using %d txn(s))", len(batches))
+	}
+
+	builders := make([]TxBuilder, len(batches))
+	for i, batch := range batches {
+		i, batch := i, batch
+		builders[i] = func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			// the manager builds batches from the submit section's context
+			batchCtx := ctx
+			if opts.Context != nil {
+				batchCtx = opts.Context
+			}
+			batchCtx, endSection := startSection(batchCtx, "pepe::proof::checkpoint::batch::submit", map[string]string{
+				"chunk":  fmt.Sprintf("%d", i),
+				"proofs": fmt.Sprintf("%d", batch.end-batch.start),
+			})
+			txn, err := verifyCheckpointProofs(batchCtx, eigenPod, opts, eigenpodAddress, proof.ValidatorBalancesRootProof, balanceProofs[batch.start:batch.end])
+			endSection(err)
+			return txn, err
 		}
-		tracing.OnEndSection()
-		if verbose {
-			color.Green("OK")
+	}
+
+	manager := NewTxManager(ctx, eth, ownerAccount, verbose)
+	if journal != nil {
+		journaled := make([]*JournalBatch, len(batches))
+		manager.OnSent = func(i int, txn *types.Transaction) error {
+			if journaled[i] != nil && journaled[i].Status == BatchPending {
+				// a replacement for a stuck transaction
+				return journal.ReplaceTx(journaled[i], txn.Hash())
+			}
+			batch, err := journal.RecordBatch(validatorIndices[batches[i].start:batches[i].end], txn.Hash())
+			journaled[i] = batch
+			return err
+		}
+		manager.OnMined = func(i int, receipt *types.Receipt) error {
+			status := BatchSucceeded
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				status = BatchReverted
+			}
+			return journal.SetStatus(journaled[i], status)
 		}
 	}
 
+	submitCtx, endSection := startSection(ctx, "pepe::proof::checkpoint::submit", map[string]string{
+		"batches": fmt.Sprintf("%d", len(batches)),
+	})
+	transactions, err := manager.Send(submitCtx, builders)
+	endSection(err)
+	if err != nil {
+		return transactions, err
+	}
+
 	if verbose {
 		if !noSend {
-			color.Green("Complete! re-run with `status` to see the updated Eigenpod state.")
+			logInfo(ctx, "Complete! re-run with `status` to see the updated Eigenpod state.")
 		} else {
-			color.Yellow("Submit these proofs to network and re-run with `status` to see the updated Eigenpod state.")
+			logWarn(ctx, "Submit these proofs to network and re-run with `status` to see the updated Eigenpod state.")
 		}
 	}
 	return transactions, nil
 }
 
-func SubmitCheckpointProofBatch(ctx context.Context, owner, eigenpodAddress string, chainId *big.Int, proof *eigenpodproofs.ValidatorBalancesRootProof, balanceProofs []*eigenpodproofs.BalanceProof, eth *ethclient.Client, noSend bool, verbose bool) (*types.Transaction, error) {
-	tracing := GetContextTracingCallbacks(ctx)
-
-	ownerAccount, err := PrepareAccount(&owner, chainId, noSend)
+func SubmitCheckpointProofBatch(ctx context.Context, owner, eigenpodAddress string, chainId *big.Int, proof *eigenpodproofs.ValidatorBalancesRootProof, balanceProofs []*eigenpodproofs.BalanceProof, eth ExecutionClient, noSend bool, verbose bool) (*types.Transaction, error) {
+	ownerAccount, err := PrepareAccount(ctx, &owner, chainId, noSend)
 	if err != nil {
 		return nil, err
 	}
 
 	if verbose {
-		fmt.Printf("Using account(0x%s) to submit onchain\n", common.Bytes2Hex(ownerAccount.FromAddress[:]))
+		logPlain(ctx, "Using account(0x%s) to submit onchain\n", common.Bytes2Hex(ownerAccount.FromAddress[:]))
 	}
 
 	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
@@ -84,18 +158,22 @@ func SubmitCheckpointProofBatch(ctx context.Context, owner, eigenpodAddress stri
 		return nil, err
 	}
 
-	tracing.OnStartSection("pepe::proof::checkpoint::onchain::VerifyCheckpointProofs", map[string]string{
+	return verifyCheckpointProofs(ctx, eigenPod, ownerAccount.TransactionOptions, eigenpodAddress, proof, balanceProofs)
+}
+
+func verifyCheckpointProofs(ctx context.Context, eigenPod *onchain.EigenPod, opts *bind.TransactOpts, eigenpodAddress string, proof *eigenpodproofs.ValidatorBalancesRootProof, balanceProofs []*eigenpodproofs.BalanceProof) (*types.Transaction, error) {
+	_, endSection := startSection(ctx, "pepe::proof::checkpoint::onchain::VerifyCheckpointProofs", map[string]string{
 		"eigenpod": eigenpodAddress,
 	})
 	txn, err := eigenPod.VerifyCheckpointProofs(
-		ownerAccount.TransactionOptions,
+		opts,
 		onchain.BeaconChainProofsBalanceContainerProof{
 			BalanceContainerRoot: proof.ValidatorBalancesRoot,
 			Proof:                proof.Proof.ToByteSlice(),
 		},
 		CastBalanceProofs(balanceProofs),
 	)
-	tracing.OnEndSection()
+	endSection(err)
 	if err != nil {
 		return nil, err
 	}
@@ -103,9 +181,56 @@ func SubmitCheckpointProofBatch(ctx context.Context, owner, eigenpodAddress stri
 	return txn, nil
 }
 
-// , out, owner *string, forceCheckpoint bool
-func LoadCheckpointProofFromFile(path string) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
-	res := eigenpodproofs.VerifyCheckpointProofsCallParams{}
+// drops the proofs of validators `journal` has as checkpointed, and returns the rest with their validator indices.
+func skipCheckpointedProofs(ctx context.Context, eigenpodAddress string, eth ExecutionClient, balanceProofs []*eigenpodproofs.BalanceProof, journal *CheckpointJournal) ([]*eigenpodproofs.BalanceProof, []uint64, error) {
+	pubkeyHashes := make([][32]byte, len(balanceProofs))
+	for i, balanceProof := range balanceProofs {
+		pubkeyHashes[i] = balanceProof.PubkeyHash
+	}
+	infos, err := GetValidatorInfoByPubkeyHash(ctx, eth, eigenpodAddress, pubkeyHashes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	remaining := []*eigenpodproofs.BalanceProof{}
+	indices := []uint64{}
+	for i, balanceProof := range balanceProofs {
+		if journal.IsCheckpointed(infos[i].ValidatorIndex) {
+			continue
+		}
+		remaining = append(remaining, balanceProof)
+		indices = append(indices, infos[i].ValidatorIndex)
+	}
+	return remaining, indices, nil
+}
+
+// SerializableCheckpointProof is what `checkpoint --out` writes: the proof, and the pod and checkpoint it's for.
+type SerializableCheckpointProof struct {
+	*eigenpodproofs.VerifyCheckpointProofsCallParams
+	EigenpodAddress     string `json:"eigenpodAddress"`
+	CheckpointTimestamp uint64 `json:"checkpointTimestamp"`
+}
+
+// Check makes sure the proof is for `eigenpodAddress`'s current checkpoint, which is the only one it can be
+// submitted for.
+func (p *SerializableCheckpointProof) Check(eigenpodAddress string, currentCheckpointTimestamp uint64) error {
+	if p.EigenpodAddress == "" || p.CheckpointTimestamp == 0 {
+		return errors.New("the proof file doesn't say which pod and checkpoint it's for (it was written by an older version) -- regenerate it with `checkpoint --out`")
+	}
+	if !common.IsHexAddress(p.EigenpodAddress) || common.HexToAddress(p.EigenpodAddress) != common.HexToAddress(eigenpodAddress) {
+		return fmt.Errorf("the proof is for pod %s, not %s", p.EigenpodAddress, eigenpodAddress)
+	}
+	if currentCheckpointTimestamp == 0 {
+		return errors.New("pod has no active checkpoint -- this proof can no longer be submitted")
+	}
+	if p.CheckpointTimestamp != currentCheckpointTimestamp {
+		return fmt.Errorf("the proof is for the checkpoint started at %d, but the pod's current checkpoint started at %d -- regenerate it with `checkpoint --out`", p.CheckpointTimestamp, currentCheckpointTimestamp)
+	}
+	return nil
+}
+
+func LoadCheckpointProofFromFile(path string) (*SerializableCheckpointProof, error) {
+	res := SerializableCheckpointProof{}
 	bytes, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
@@ -116,6 +241,10 @@ func LoadCheckpointProofFromFile(path string) (*eigenpodproofs.VerifyCheckpointP
 		return nil, err
 	}
 
+	if res.VerifyCheckpointProofsCallParams == nil || res.ValidatorBalancesRootProof == nil {
+		return nil, fmt.Errorf("%s is not a checkpoint proof file", path)
+	}
+
 	return &res, nil
 }
 
@@ -124,25 +253,38 @@ func asJSON(obj interface{}) string {
 	return string(bytes)
 }
 
-func GenerateCheckpointProof(ctx context.Context, eigenpodAddress string, eth *ethclient.Client, chainId *big.Int, beaconClient BeaconClient, verbose bool) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
-	tracing := GetContextTracingCallbacks(ctx)
+func GenerateCheckpointProof(ctx context.Context, eigenpodAddress string, eth ExecutionClient, chainId *big.Int, beaconClient BeaconClient, verbose bool) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
+	return GenerateCheckpointProofWithProver(ctx, eigenpodAddress, eth, chainId, beaconClient, nil, verbose)
+}
+
+// GenerateCheckpointProofWithProver generates the pod's checkpoint proof with `proofs`, so its cached trees can be
+// reused across pods and checkpoints. If it's nil, a new prover is used.
+func GenerateCheckpointProofWithProver(ctx context.Context, eigenpodAddress string, eth ExecutionClient, chainId *big.Int, beaconClient BeaconClient, proofs *eigenpodproofs.EigenPodProofs, verbose bool) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
+	ctx, endSection := startSection(ctx, "pepe::proof::checkpoint::generate", map[string]string{
+		"eigenpod": eigenpodAddress,
+	})
+	proof, err := generateCheckpointProof(ctx, eigenpodAddress, eth, chainId, beaconClient, proofs, verbose)
+	endSection(err)
+	return proof, err
+}
 
-	tracing.OnStartSection("GetCurrentCheckpoint", map[string]string{})
+func generateCheckpointProof(ctx context.Context, eigenpodAddress string, eth ExecutionClient, chainId *big.Int, beaconClient BeaconClient, proofs *eigenpodproofs.EigenPodProofs, verbose bool) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
+	_, endSection := startSection(ctx, "GetCurrentCheckpoint", nil)
 	currentCheckpoint, err := GetCurrentCheckpoint(eigenpodAddress, eth)
+	endSection(err)
 	if err != nil {
 		return nil, err
 	}
-	tracing.OnEndSection()
 
-	tracing.OnStartSection("GetCurrentCheckpointBlockRoot", map[string]string{})
+	_, endSection = startSection(ctx, "GetCurrentCheckpointBlockRoot", nil)
 	blockRoot, err := GetCurrentCheckpointBlockRoot(eigenpodAddress, eth)
+	endSection(err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch last checkpoint: %w", err)
 	}
 	if blockRoot == nil {
 		return nil, fmt.Errorf("failed to fetch last checkpoint - nil blockRoot")
 	}
-	tracing.OnEndSection()
 
 	rootBytes := *blockRoot
 	if AllZero(rootBytes[:]) {
@@ -150,49 +292,54 @@ func GenerateCheckpointProof(ctx context.Context, eigenpodAddress string, eth *e
 	}
 
 	headerBlock := "0x" + hex.EncodeToString((*blockRoot)[:])
-	tracing.OnStartSection("GetBeaconHeader", map[string]string{})
-	header, err := beaconClient.GetBeaconHeader(ctx, headerBlock)
+	headerCtx, endSection := startSection(ctx, "GetBeaconHeader", map[string]string{"block": headerBlock})
+	header, err := beaconClient.GetBeaconHeader(headerCtx, headerBlock)
+	endSection(err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch beacon header (%s): %w", headerBlock, err)
 	}
-	tracing.OnEndSection()
 
-	tracing.OnStartSection("GetBeaconState", map[string]string{})
-	beaconState, err := beaconClient.GetBeaconState(ctx, strconv.FormatUint(uint64(header.Header.Message.Slot), 10))
+	slot := strconv.FormatUint(uint64(header.Header.Message.Slot), 10)
+	stateCtx, endSection := startSection(ctx, "GetBeaconState", map[string]string{"stateId": slot})
+	beaconState, err := beaconClient.GetBeaconState(stateCtx, slot)
+	endSection(err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch beacon state: %w", err)
 	}
-	tracing.OnEndSection()
 
-	proofs, err := eigenpodproofs.NewEigenPodProofs(chainId.Uint64(), 300 /* oracleStateCacheExpirySeconds - 5min */)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize prover: %w", err)
+	if proofs == nil {
+		proofs, err = eigenpodproofs.NewEigenPodProofs(chainId.Uint64(), 300 /* oracleStateCacheExpirySeconds - 5min */)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize prover: %w", err)
+		}
 	}
 
-	return GenerateCheckpointProofForState(ctx, eigenpodAddress, beaconState, header, eth, currentCheckpoint, proofs, verbose)
+	return GenerateCheckpointProofForState(ctx, eigenpodAddress, beaconState, nil, header, eth, currentCheckpoint, proofs, verbose)
 }
 
-func GenerateCheckpointProofForState(ctx context.Context, eigenpodAddress string, beaconState *spec.VersionedBeaconState, header *v1.BeaconBlockHeader, eth *ethclient.Client, currentCheckpointTimestamp uint64, proofs *eigenpodproofs.EigenPodProofs, verbose bool) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
-	tracing := GetContextTracingCallbacks(ctx)
-
+// GenerateCheckpointProofForState proves the pod's checkpoint against `beaconState`. `index` is the state's
+// ValidatorIndex, or nil.
+func GenerateCheckpointProofForState(ctx context.Context, eigenpodAddress string, beaconState *spec.VersionedBeaconState, index *ValidatorIndex, header *v1.BeaconBlockHeader, eth ExecutionClient, currentCheckpointTimestamp uint64, proofs *eigenpodproofs.EigenPodProofs, verbose bool) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
 	// filter through the beaconState's validators, and select only ones that have withdrawal address set to `eigenpod`.
-	tracing.OnStartSection("FindAllValidatorsForEigenpod", map[string]string{})
-	allValidators, err := FindAllValidatorsForEigenpod(eigenpodAddress, beaconState)
+	_, endSection := startSection(ctx, "FindAllValidatorsForEigenpod", nil)
+	allValidators, err := FindAllValidatorsForEigenpod(eigenpodAddress, beaconState, index)
+	endSection(err)
 	if err != nil {
 		return nil, err
 	}
-	tracing.OnEndSection()
 
 	if verbose {
-		color.Yellow("You have a total of %d validators pointed to this pod.", len(allValidators))
+		logWarn(ctx, "You have a total of %d validators pointed to this pod.", len(allValidators))
 	}
 
-	tracing.OnStartSection("SelectCheckpointableValidators", map[string]string{})
-	checkpointValidators, err := SelectCheckpointableValidators(eth, eigenpodAddress, allValidators, currentCheckpointTimestamp)
+	_, endSection = startSection(ctx, "SelectCheckpointableValidators", map[string]string{
+		"validators": fmt.Sprintf("%d", len(allValidators)),
+	})
+	checkpointValidators, err := SelectCheckpointableValidators(ctx, eth, eigenpodAddress, allValidators, currentCheckpointTimestamp)
+	endSection(err)
 	if err != nil {
 		return nil, err
 	}
-	tracing.OnEndSection()
 
 	validatorIndices := make([]uint64, len(checkpointValidators))
 	for i, v := range checkpointValidators {
@@ -200,15 +347,58 @@ func GenerateCheckpointProofForState(ctx context.Context, eigenpodAddress string
 	}
 
 	if verbose {
-		color.Yellow("Proving validators at indices: %s", asJSON(validatorIndices))
+		logWarn(ctx, "Proving validators at indices: %s", asJSON(validatorIndices))
 	}
 
-	tracing.OnStartSection("ProveCheckpointProofs", map[string]string{})
+	_, endSection = startSection(ctx, "ProveCheckpointProofs", map[string]string{
+		"validators": fmt.Sprintf("%d", len(validatorIndices)),
+	})
 	proof, err := proofs.ProveCheckpointProofs(header.Header.Message, beaconState, validatorIndices)
+	endSection(err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prove checkpoint: %w", err)
 	}
-	tracing.OnEndSection()
 
 	return proof, nil
 }
+
+// PlanCheckpoint plans what `checkpoint` would send from `sender`: starting a checkpoint (if one isn't active) and
+// the checkpoint proofs. The proofs of a checkpoint that hasn't started yet can't be generated until it has, so
+// they're deferred until the plan is applied. `proofs` may be nil (see GenerateCheckpointProofWithProver).
+func PlanCheckpoint(ctx context.Context, eigenpodAddress, sender string, eth ExecutionClient, chainId *big.Int, beaconClient BeaconClient, proofs *eigenpodproofs.EigenPodProofs, batchSize uint64, forceCheckpoint bool, verbose bool) (*Plan, error) {
+	ownerAccount, err := PrepareAccount(ctx, &sender, chainId, true /* noSend */)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sender: %w", err)
+	}
+
+	currentCheckpoint, err := GetCurrentCheckpoint(eigenpodAddress, eth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	plan, err := NewPlan(ctx, "checkpoint", eth, chainId, eigenpodAddress, ownerAccount.FromAddress, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pod state: %w", err)
+	}
+
+	if currentCheckpoint == 0 {
+		txn, err := StartCheckpoint(ctx, eigenpodAddress, sender, chainId, eth, forceCheckpoint, true /* noSend */)
+		if err != nil {
+			return nil, fmt.Errorf("failed to simulate starting a checkpoint: %w", err)
+		}
+		plan.AddTransactions(PlanStepCheckpointStart, "EigenPod.startCheckpoint()", txn)
+		plan.AddDeferred(PlanStepCheckpointProof, "EigenPod.verifyCheckpointProofs() for every validator, in batches. Generated once the checkpoint has started.")
+		return plan, nil
+	}
+
+	proof, err := GenerateCheckpointProofWithProver(ctx, eigenpodAddress, eth, chainId, beaconClient, proofs, verbose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate checkpoint proof: %w", err)
+	}
+	txns, err := SubmitCheckpointProof(ctx, sender, eigenpodAddress, chainId, proof, eth, batchSize, true /* noPrompt */, true /* noSend */, verbose, nil /* journal */)
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate checkpoint proofs: %w", err)
+	}
+	plan.AddTransactions(PlanStepCheckpointProof, "EigenPod.verifyCheckpointProofs()", txns...)
+	return plan, nil
+}
@@ -0,0 +1,257 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/era"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/transition"
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/rs/zerolog/log"
+)
+
+// Prefix for `--beaconNode` values that point at a local directory of era files / beacon states,
+// e.g `era:///mnt/era` or `era:///mnt/era?fallback=https://my-beacon-node`.
+const ERA_SCHEME = "era://"
+
+// eraBeaconClient serves beacon states from era files on disk, and (optionally) falls back to a
+// live beacon node for anything the archive cannot answer.
+type eraBeaconClient struct {
+	archive  *era.Archive
+	fallback BeaconClient
+	verbose  bool
+}
+
+// NewEraBeaconClient creates a BeaconClient backed by the era files and `.ssz`/`.ssz_snappy` states in `dir`.
+//
+// Block headers and "head" lookups are cheap on a regular beacon node, so if `fallback` is set those are
+// served by it. Historical states are always read from the archive first; states it doesn't store are rebuilt by
+// replaying its blocks (Deneb only) on top of the nearest earlier state, for whichever chain that state is from.
+func NewEraBeaconClient(dir string, fallback BeaconClient, verbose bool) (BeaconClient, error) {
+	return NewEraBeaconClientWithTransition(dir, fallback, transition.New(nil), verbose)
+}
+
+// NewEraBeaconClientWithTransition is NewEraBeaconClient with another state transition. If `stateTransition` is nil,
+// only stored states are served.
+func NewEraBeaconClientWithTransition(dir string, fallback BeaconClient, stateTransition era.StateTransition, verbose bool) (BeaconClient, error) {
+	archive, err := era.OpenArchive(dir)
+	if err != nil {
+		return nil, err
+	}
+	archive.Transition = stateTransition
+
+	if verbose {
+		slots := archive.StateSlots()
+		log.Info().Msgf("loaded era archive %s (%d states)", dir, len(slots))
+	}
+
+	return &eraBeaconClient{
+		archive:  archive,
+		fallback: fallback,
+		verbose:  verbose,
+	}, nil
+}
+
+// Parses an `era://<dir>[?fallback=<url>]` beacon node uri.
+func parseEraUri(uri string) (dir string, fallback string, err error) {
+	rest := strings.TrimPrefix(uri, ERA_SCHEME)
+	dir, rawQuery, _ := strings.Cut(rest, "?")
+	if dir == "" {
+		return "", "", fmt.Errorf("missing directory in %s", uri)
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid era uri %s: %w", uri, err)
+	}
+	return dir, query.Get("fallback"), nil
+}
+
+func isNamedStateId(stateId string) bool {
+	switch stateId {
+	case "head", "finalized", "justified", "genesis":
+		return true
+	}
+	return false
+}
+
+func (e *eraBeaconClient) GetBeaconHeader(ctx context.Context, blockId string) (*v1.BeaconBlockHeader, error) {
+	if e.fallback != nil {
+		header, err := e.fallback.GetBeaconHeader(ctx, blockId)
+		if err == nil {
+			return header, nil
+		}
+		if e.verbose {
+			log.Warn().Msgf("fallback beacon node failed to return header %s (%s), searching era archive", blockId, err)
+		}
+	}
+
+	var signedHeader *phase0.SignedBeaconBlockHeader
+	switch {
+	case blockId == "head":
+		slot, ok := e.archive.LatestStateSlot()
+		if !ok {
+			return nil, errors.New("era archive is empty")
+		}
+		state, err := e.archive.State(ctx, slot)
+		if err != nil {
+			return nil, err
+		}
+		signedHeader, err = latestBlockHeaderForState(state)
+		if err != nil {
+			return nil, err
+		}
+	case strings.HasPrefix(blockId, "0x"):
+		rootBytes, err := hexutil.Decode(blockId)
+		if err != nil || len(rootBytes) != len(phase0.Root{}) {
+			return nil, fmt.Errorf("invalid block root %s: expected 32 bytes of hex", blockId)
+		}
+		block, err := e.archive.BlockByRoot(phase0.Root(rootBytes))
+		if err != nil {
+			return nil, err
+		}
+		signedHeader, err = beacon.GetSignedBlockHeader(block)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		slot, err := strconv.ParseUint(blockId, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported block id for era archive: %s", blockId)
+		}
+		block, err := e.archive.Block(phase0.Slot(slot))
+		if err != nil {
+			return nil, err
+		}
+		signedHeader, err = beacon.GetSignedBlockHeader(block)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	root, err := signedHeader.Message.HashTreeRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.BeaconBlockHeader{
+		Root:      root,
+		Canonical: true,
+		Header:    signedHeader,
+	}, nil
+}
+
+// The state's latest block header has a zeroed state root until the following slot is processed,
+// so fill it in from the state itself.
+func latestBlockHeaderForState(state *spec.VersionedBeaconState) (*phase0.SignedBeaconBlockHeader, error) {
+	if state.Version != spec.DataVersionDeneb {
+		return nil, errors.New("unsupported beacon state version")
+	}
+
+	header := *state.Deneb.LatestBlockHeader
+	if AllZero(header.StateRoot[:]) {
+		stateRoot, err := state.Deneb.HashTreeRoot()
+		if err != nil {
+			return nil, err
+		}
+		header.StateRoot = stateRoot
+	}
+
+	// era archives do not keep the proposer's signature for the latest header
+	return &phase0.SignedBeaconBlockHeader{Message: &header}, nil
+}
+
+func (e *eraBeaconClient) GetBeaconState(ctx context.Context, stateId string) (*spec.VersionedBeaconState, error) {
+	if isNamedStateId(stateId) {
+		if e.fallback != nil {
+			return e.fallback.GetBeaconState(ctx, stateId)
+		}
+		if stateId != "head" {
+			return nil, fmt.Errorf("unsupported state id for era archive: %s", stateId)
+		}
+
+		slot, ok := e.archive.LatestStateSlot()
+		if !ok {
+			return nil, errors.New("era archive is empty")
+		}
+		return e.archive.State(ctx, slot)
+	}
+
+	slot, err := strconv.ParseUint(stateId, 10, 64)
+	if err != nil {
+		if e.fallback != nil {
+			return e.fallback.GetBeaconState(ctx, stateId)
+		}
+		return nil, fmt.Errorf("unsupported state id for era archive: %s", stateId)
+	}
+
+	if e.verbose {
+		log.Info().Msgf("loading beacon state %d from era archive", slot)
+	}
+	state, err := e.archive.State(ctx, phase0.Slot(slot))
+	if (errors.Is(err, era.ErrStateNotArchived) || errors.Is(err, transition.ErrUnsupportedFork)) && e.fallback != nil {
+		if e.verbose {
+			log.Warn().Msgf("%s -- downloading from fallback beacon node", err)
+		}
+		return e.fallback.GetBeaconState(ctx, stateId)
+	}
+	if errors.Is(err, era.ErrStateNotArchived) {
+		return nil, fmt.Errorf("%w. Add the state to the archive as a loose .ssz file, or add ?fallback=<beacon node> to the era uri", err)
+	}
+	return state, err
+}
+
+func (e *eraBeaconClient) GetValidator(ctx context.Context, index uint64) (*v1.Validator, error) {
+	if e.fallback != nil {
+		return e.fallback.GetValidator(ctx, index)
+	}
+
+	state, err := e.GetBeaconState(ctx, "head")
+	if err != nil {
+		return nil, err
+	}
+
+	validators, err := state.Validators()
+	if err != nil {
+		return nil, err
+	}
+	balances, err := state.ValidatorBalances()
+	if err != nil {
+		return nil, err
+	}
+	if index >= uint64(len(validators)) {
+		return nil, ErrValidatorNotFound
+	}
+
+	slot, err := state.Slot()
+	if err != nil {
+		return nil, err
+	}
+	epoch := phase0.Epoch(uint64(slot) / beacon.SLOTS_PER_EPOCH)
+
+	return &v1.Validator{
+		Index:     phase0.ValidatorIndex(index),
+		Balance:   balances[index],
+		Status:    v1.ValidatorToState(validators[index], &balances[index], epoch, phase0.Epoch(FAR_FUTURE_EPOCH)),
+		Validator: validators[index],
+	}, nil
+}
+
+func (e *eraBeaconClient) Close() error {
+	return e.archive.Close()
+}
+
+func (e *eraBeaconClient) Subscribe(ctx context.Context, topics ...EventTopic) (*BeaconEvents, error) {
+	if e.fallback != nil {
+		return e.fallback.Subscribe(ctx, topics...)
+	}
+	return nil, ErrEventsNotSupported
+}
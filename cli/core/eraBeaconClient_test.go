@@ -0,0 +1,29 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/era"
+)
+
+func TestEraBeaconClientErrors(t *testing.T) {
+	client, err := NewEraBeaconClient(t.TempDir(), nil /* fallback */, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for _, blockId := range []string{"0x1234", "0xzz", "0x" + strings.Repeat("ab", 33)} {
+		if _, err := client.GetBeaconHeader(ctx, blockId); err == nil || !strings.Contains(err.Error(), "invalid block root") {
+			t.Errorf("expected %s to be rejected, got %v", blockId, err)
+		}
+	}
+
+	_, err = client.GetBeaconState(ctx, "8200")
+	if !errors.Is(err, era.ErrStateNotArchived) || !strings.Contains(err.Error(), "fallback") {
+		t.Errorf("expected a missing state to explain where to get it, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrEventsNotSupported = errors.New("beacon client does not support event subscriptions")
+
+type EventTopic string
+
+const (
+	EventTopicHead                EventTopic = "head"
+	EventTopicFinalizedCheckpoint EventTopic = "finalized_checkpoint"
+	EventTopicAttesterSlashing    EventTopic = "attester_slashing"
+	EventTopicProposerSlashing    EventTopic = "proposer_slashing"
+)
+
+// wait between reconnection attempts, doubling after every failed attempt.
+const (
+	minEventsReconnectDelay = 500 * time.Millisecond
+	maxEventsReconnectDelay = 30 * time.Second
+)
+
+// BeaconEvents delivers the events of a subscription. Only the channels of subscribed topics receive
+// anything, and every channel is closed once the subscription's context is done.
+type BeaconEvents struct {
+	Head                <-chan *v1.HeadEvent
+	FinalizedCheckpoint <-chan *v1.FinalizedCheckpointEvent
+	AttesterSlashing    <-chan *phase0.AttesterSlashing
+	ProposerSlashing    <-chan *phase0.ProposerSlashing
+
+	// Receives an error whenever the stream drops (before reconnecting) or an event can't be decoded.
+	// Errors are discarded if nobody is reading.
+	Errors <-chan error
+}
+
+type eventStream struct {
+	head                chan *v1.HeadEvent
+	finalizedCheckpoint chan *v1.FinalizedCheckpointEvent
+	attesterSlashing    chan *phase0.AttesterSlashing
+	proposerSlashing    chan *phase0.ProposerSlashing
+	errors              chan error
+
+	verbose bool
+}
+
+// subscribeEvents streams `topics` from the beacon API's /eth/v1/events until `ctx` is done,
+// reconnecting whenever the connection drops.
+func subscribeEvents(ctx context.Context, endpoint string, topics []EventTopic, verbose bool) (*BeaconEvents, error) {
+	if len(topics) == 0 {
+		return nil, errors.New("no event topics requested")
+	}
+
+	// go-eth2-client accepts addresses without a scheme
+	if !strings.HasPrefix(endpoint, "http") {
+		endpoint = "http://" + endpoint
+	}
+	eventsUrl, err := url.Parse(strings.TrimSuffix(endpoint, "/") + "/eth/v1/events")
+	if err != nil {
+		return nil, fmt.Errorf("invalid beacon node url: %w", err)
+	}
+	query := url.Values{}
+	for _, topic := range topics {
+		switch topic {
+		case EventTopicHead, EventTopicFinalizedCheckpoint, EventTopicAttesterSlashing, EventTopicProposerSlashing:
+			query.Add("topics", string(topic))
+		default:
+			return nil, fmt.Errorf("unsupported event topic: %s", topic)
+		}
+	}
+	eventsUrl.RawQuery = query.Encode()
+
+	stream := &eventStream{
+		head:                make(chan *v1.HeadEvent, 16),
+		finalizedCheckpoint: make(chan *v1.FinalizedCheckpointEvent, 16),
+		attesterSlashing:    make(chan *phase0.AttesterSlashing, 16),
+		proposerSlashing:    make(chan *phase0.ProposerSlashing, 16),
+		errors:              make(chan error, 1),
+		verbose:             verbose,
+	}
+	go stream.run(ctx, eventsUrl.String())
+
+	return &BeaconEvents{
+		Head:                stream.head,
+		FinalizedCheckpoint: stream.finalizedCheckpoint,
+		AttesterSlashing:    stream.attesterSlashing,
+		ProposerSlashing:    stream.proposerSlashing,
+		Errors:              stream.errors,
+	}, nil
+}
+
+func (s *eventStream) run(ctx context.Context, eventsUrl string) {
+	defer func() {
+		close(s.head)
+		close(s.finalizedCheckpoint)
+		close(s.attesterSlashing)
+		close(s.proposerSlashing)
+		close(s.errors)
+	}()
+
+	delay := minEventsReconnectDelay
+	for {
+		connected, err := s.stream(ctx, eventsUrl)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = minEventsReconnectDelay
+		}
+
+		if s.verbose {
+			log.Warn().Msgf("beacon event stream disconnected (%s), reconnecting in %s", err, delay)
+		}
+		s.reportError(fmt.Errorf("beacon event stream disconnected: %w", err))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay = min(delay*2, maxEventsReconnectDelay)
+	}
+}
+
+func (s *eventStream) reportError(err error) {
+	select {
+	case s.errors <- err:
+	default:
+	}
+}
+
+// reads server-sent events until the connection drops. `connected` is whether the stream was opened at all.
+func (s *eventStream) stream(ctx context.Context, eventsUrl string) (connected bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, eventsUrl, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var topic string
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return true, errors.New("stream closed by beacon node")
+			}
+			return true, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "":
+			// a blank line ends the event
+			if data.Len() > 0 {
+				if !s.dispatch(ctx, topic, []byte(data.String())) {
+					return true, ctx.Err()
+				}
+			}
+			topic = ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// comment / keep-alive
+		case strings.HasPrefix(line, "event:"):
+			topic = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+}
+
+// decodes and delivers an event, returning false if `ctx` finished while waiting for a reader.
+func (s *eventStream) dispatch(ctx context.Context, topic string, data []byte) bool {
+	var err error
+	switch EventTopic(topic) {
+	case EventTopicHead:
+		event := &v1.HeadEvent{}
+		if err = json.Unmarshal(data, event); err == nil {
+			return send(ctx, s.head, event)
+		}
+	case EventTopicFinalizedCheckpoint:
+		event := &v1.FinalizedCheckpointEvent{}
+		if err = json.Unmarshal(data, event); err == nil {
+			return send(ctx, s.finalizedCheckpoint, event)
+		}
+	case EventTopicAttesterSlashing:
+		event := &phase0.AttesterSlashing{}
+		if err = json.Unmarshal(data, event); err == nil {
+			return send(ctx, s.attesterSlashing, event)
+		}
+	case EventTopicProposerSlashing:
+		event := &phase0.ProposerSlashing{}
+		if err = json.Unmarshal(data, event); err == nil {
+			return send(ctx, s.proposerSlashing, event)
+		}
+	default:
+		return true
+	}
+
+	s.reportError(fmt.Errorf("failed to decode %s event: %w", topic, err))
+	return true
+}
+
+func send[T any](ctx context.Context, ch chan T, value T) bool {
+	select {
+	case ch <- value:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
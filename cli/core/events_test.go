@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beaconmock"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacontest"
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForSubscribers(t *testing.T, server *beaconmock.Server, count int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for server.EventSubscribers() != count {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d event subscribers", count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubscribeReconnects(t *testing.T) {
+	fixture, err := beacontest.NewStateBuilder(64).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := beaconmock.NewFixtures()
+	assert.Nil(t, fixtures.AddState(fixture.State))
+
+	mock, err := beaconmock.New(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	client, cancelClient, err := NewBeaconClient(server.URL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancelClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	events, err := client.Subscribe(ctx, EventTopicHead, EventTopicProposerSlashing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForSubscribers(t, mock, 1)
+
+	assert.Nil(t, mock.Publish("head", &v1.HeadEvent{Slot: 65, Block: fixture.BlockRoot, State: fixture.StateRoot}))
+	head := <-events.Head
+	assert.Equal(t, phase0.Slot(65), head.Slot)
+	assert.Equal(t, fixture.BlockRoot, head.Block)
+
+	mock.DropEventStreams()
+	assert.NotNil(t, <-events.Errors)
+	waitForSubscribers(t, mock, 1)
+
+	slashing := &phase0.ProposerSlashing{
+		SignedHeader1: &phase0.SignedBeaconBlockHeader{Message: fixture.Header},
+		SignedHeader2: &phase0.SignedBeaconBlockHeader{Message: &phase0.BeaconBlockHeader{Slot: 64, ProposerIndex: fixture.Header.ProposerIndex}},
+	}
+	assert.Nil(t, mock.Publish("proposer_slashing", slashing))
+	received := <-events.ProposerSlashing
+	assert.Equal(t, fixture.Header.StateRoot, received.SignedHeader1.Message.StateRoot)
+
+	cancel()
+	_, open := <-events.Head
+	assert.False(t, open)
+}
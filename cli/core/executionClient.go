@@ -0,0 +1,29 @@
+package core
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// ExecutionClient is everything cli/core needs from an execution node. *ethclient.Client implements it, as do
+// go-ethereum's simulated backend (ethclient/simulated) and mocks, so core can be driven by any RPC stack.
+type ExecutionClient interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ethereum.BlockNumberReader
+	ethereum.ChainIDReader
+	ethereum.FeeHistoryReader
+	ethereum.TransactionReader
+
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+}
+
+var _ ExecutionClient = (*ethclient.Client)(nil)
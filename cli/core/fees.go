@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const DEFAULT_FEE_BUMP_PERCENT = 20
+
+// nodes reject a replacement transaction unless both of its fees are at least this much higher.
+const minFeeBumpPercent = 10
+
+// how many recent blocks `TipPercentile` is measured over.
+const feeHistoryBlocks = 20
+
+var ErrFeeCeiling = errors.New("fees would exceed the --maxFeePerGas ceiling")
+
+// FeePolicy decides the EIP-1559 fees of the transactions sent by a TxManager.
+type FeePolicy struct {
+	// Hard ceiling on the fee cap of any transaction (in wei per gas). Nothing is sent, or replaced, above it.
+	// nil means no ceiling.
+	MaxFeePerGas *big.Int
+	// The percentile of recent priority fees (from eth_feeHistory) to tip. 0 uses the node's suggestion.
+	TipPercentile float64
+
+	// Replace a transaction that has been pending for this many blocks with one paying BumpPercent more.
+	// 0 disables replacement.
+	BumpAfterBlocks uint64
+	BumpPercent     uint64
+}
+
+type Fees struct {
+	TipCap *big.Int
+	FeeCap *big.Int
+}
+
+func (f *Fees) String() string {
+	return fmt.Sprintf("maxFeePerGas=%s gwei, maxPriorityFeePerGas=%s gwei", gwei(f.FeeCap), gwei(f.TipCap))
+}
+
+func gwei(wei *big.Int) string {
+	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(params.GWei)).Text('f', 3)
+}
+
+// SuggestFees prices a new transaction: a tip per the policy, and a fee cap of twice the next block's
+// base fee plus the tip (as geth does), limited to the policy's ceiling.
+func SuggestFees(ctx context.Context, eth ExecutionClient, policy FeePolicy) (*Fees, error) {
+	percentiles := []float64{}
+	if policy.TipPercentile > 0 {
+		percentiles = append(percentiles, policy.TipPercentile)
+	}
+	history, err := eth.FeeHistory(ctx, feeHistoryBlocks, nil, percentiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fee history: %w", err)
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, errors.New("node returned no base fees")
+	}
+	// the last entry is the base fee of the next block
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+
+	var tip *big.Int
+	if policy.TipPercentile > 0 {
+		tip = medianReward(history.Reward)
+	} else {
+		tip, err = eth.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tip: %w", err)
+		}
+	}
+
+	fees := &Fees{
+		TipCap: tip,
+		FeeCap: new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip),
+	}
+	if policy.MaxFeePerGas == nil {
+		return fees, nil
+	}
+
+	if baseFee.Cmp(policy.MaxFeePerGas) > 0 {
+		return nil, fmt.Errorf("%w: base fee is %s gwei, ceiling is %s gwei", ErrFeeCeiling, gwei(baseFee), gwei(policy.MaxFeePerGas))
+	}
+	return policy.limit(fees), nil
+}
+
+// BumpFees prices a replacement for a transaction sent with `previous` fees. Returns ErrFeeCeiling if the
+// ceiling doesn't leave room for a replacement the node would accept.
+func BumpFees(ctx context.Context, eth ExecutionClient, policy FeePolicy, previous *Fees) (*Fees, error) {
+	percent := policy.BumpPercent
+	if percent < minFeeBumpPercent {
+		percent = DEFAULT_FEE_BUMP_PERCENT
+	}
+
+	fees := &Fees{
+		TipCap: bumpBy(previous.TipCap, percent),
+		FeeCap: bumpBy(previous.FeeCap, percent),
+	}
+	// if the market has moved further than the bump, follow it.
+	if current, err := SuggestFees(ctx, eth, policy); err == nil {
+		if current.TipCap.Cmp(fees.TipCap) > 0 {
+			fees.TipCap = current.TipCap
+		}
+		if current.FeeCap.Cmp(fees.FeeCap) > 0 {
+			fees.FeeCap = current.FeeCap
+		}
+	}
+	fees = policy.limit(fees)
+
+	if fees.TipCap.Cmp(bumpBy(previous.TipCap, minFeeBumpPercent)) < 0 || fees.FeeCap.Cmp(bumpBy(previous.FeeCap, minFeeBumpPercent)) < 0 {
+		return nil, fmt.Errorf("%w: can't replace a transaction sent with %s", ErrFeeCeiling, previous)
+	}
+	return fees, nil
+}
+
+func (policy FeePolicy) limit(fees *Fees) *Fees {
+	if policy.MaxFeePerGas == nil || fees.FeeCap.Cmp(policy.MaxFeePerGas) <= 0 {
+		return fees
+	}
+	limited := &Fees{TipCap: fees.TipCap, FeeCap: policy.MaxFeePerGas}
+	if limited.TipCap.Cmp(limited.FeeCap) > 0 {
+		limited.TipCap = limited.FeeCap
+	}
+	return limited
+}
+
+func bumpBy(value *big.Int, percent uint64) *big.Int {
+	bumped := new(big.Int).Mul(value, new(big.Int).SetUint64(100+percent))
+	bumped.Add(bumped, big.NewInt(99)) // round up, so small values still increase
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// the median, across blocks, of each block's reward at the (single) requested percentile.
+func medianReward(rewards [][]*big.Int) *big.Int {
+	values := []*big.Int{}
+	for _, blockRewards := range rewards {
+		if len(blockRewards) > 0 {
+			values = append(values, blockRewards[0])
+		}
+	}
+	if len(values) == 0 {
+		return big.NewInt(0)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Cmp(values[j]) < 0
+	})
+	return values[len(values)/2]
+}
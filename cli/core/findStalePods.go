@@ -5,25 +5,17 @@ import (
 	"fmt"
 	"log"
 	"math/big"
-	"strings"
 
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/utils"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/pkg/errors"
 )
 
-func PodManagerContracts() map[uint64]string {
-	return map[uint64]string{
-		0:     "0x91E677b07F7AF907ec9a428aafA9fc14a0d3A338",
-		17000: "0x30770d7E3e71112d7A6b7259542D1f680a70e315", //testnet holesky
-	}
-}
-
 type Cache struct {
 	PodOwnerShares map[string]PodOwnerShare
 }
@@ -49,7 +41,7 @@ type PodOwnerShare struct {
 
 var cache Cache // valid for the duration of a command.
 
-func isEigenpod(eth *ethclient.Client, chainId uint64, eigenpodAddress string) (bool, error) {
+func isEigenpod(eth ExecutionClient, chainId uint64, eigenpodAddress string) (bool, error) {
 	if cache.PodOwnerShares == nil {
 		cache.PodOwnerShares = make(map[string]PodOwnerShare)
 	}
@@ -65,11 +57,11 @@ func isEigenpod(eth *ethclient.Client, chainId uint64, eigenpodAddress string) (
 		IsEigenpod:               false,
 	}
 
-	podManAddress, ok := PodManagerContracts()[chainId]
-	if !ok {
-		return false, fmt.Errorf("chain %d not supported", chainId)
+	chain, err := chains.Get(chainId)
+	if err != nil {
+		return false, err
 	}
-	podMan, err := onchain.NewEigenPodManager(common.HexToAddress(podManAddress), eth)
+	podMan, err := onchain.NewEigenPodManager(chain.EigenPodManager, eth)
 	if err != nil {
 		return false, err
 	}
@@ -118,42 +110,36 @@ func isEigenpod(eth *ethclient.Client, chainId uint64, eigenpodAddress string) (
 }
 
 func executionWithdrawalAddress(withdrawalCredentials []byte) *string {
-	if withdrawalCredentials[0] != 1 {
+	if withdrawalCredentials[0] != 1 && withdrawalCredentials[0] != 2 {
 		return nil
 	}
 	addr := common.Bytes2Hex(withdrawalCredentials[12:])
 	return &addr
 }
 
-func FindStaleEigenpods(ctx context.Context, eth *ethclient.Client, nodeUrl string, beacon BeaconClient, chainId *big.Int, verbose bool, tolerance float64) (map[string][]ValidatorWithIndex, error) {
+func FindStaleEigenpods(ctx context.Context, eth ExecutionClient, nodeUrl string, beacon BeaconClient, chainId *big.Int, verbose bool, tolerance float64) (map[string][]ValidatorWithIndex, error) {
 	beaconState, err := beacon.GetBeaconState(ctx, "head")
 	if err != nil {
 		return nil, fmt.Errorf("error downloading beacon state: %s", err.Error())
 	}
 
-	// Simulate fetching validators
-	_allValidators, err := beaconState.Validators()
+	index, err := NewValidatorIndex(beaconState)
 	if err != nil {
 		return nil, err
 	}
 
-	allValidatorsWithIndices := utils.Map(_allValidators, func(validator *phase0.Validator, index uint64) ValidatorWithIndex {
-		return ValidatorWithIndex{
-			Validator: validator,
-			Index:     index,
-		}
-	})
-
 	// TODO(pectra): this logic changes after the pectra upgrade.
-	allSlashedValidators := utils.Filter(allValidatorsWithIndices, func(v ValidatorWithIndex) bool {
-		if !v.Validator.Slashed {
-			return false // we only care about slashed validators.
+	allSlashedValidators := []ValidatorWithIndex{}
+	for i := 0; i < index.Len(); i++ {
+		v := index.Validator(uint64(i))
+		if v.Validator == nil || !v.Validator.Slashed {
+			continue // we only care about slashed validators.
 		}
-		if v.Validator.WithdrawalCredentials[0] != 1 {
-			return false // not an execution withdrawal address
+		if executionWithdrawalAddress(v.Validator.WithdrawalCredentials) == nil {
+			continue // not an execution withdrawal address
 		}
-		return true
-	})
+		allSlashedValidators = append(allSlashedValidators, v)
+	}
 
 	withdrawalAddressesToCheck := make(map[uint64]string)
 	for _, validator := range allSlashedValidators {
@@ -221,10 +207,7 @@ func FindStaleEigenpods(ctx context.Context, eth *ethclient.Client, nodeUrl stri
 		//		- native ETH in the pod
 		//		- any active validators and their associated balances
 		// 	)
-		allValidatorsForEigenpod := utils.Filter(allValidatorsWithIndices, func(v ValidatorWithIndex) bool {
-			withdrawal := executionWithdrawalAddress(v.Validator.WithdrawalCredentials)
-			return withdrawal != nil && strings.EqualFold(*withdrawal, eigenpod)
-		})
+		allValidatorsForEigenpod := index.ValidatorsWithdrawingTo(common.HexToAddress(eigenpod))
 
 		allValidatorBalancesSummedGwei := utils.Reduce(allValidatorsForEigenpod, func(accum phase0.Gwei, validator ValidatorWithIndex) phase0.Gwei {
 			return accum + allValidatorBalances[validator.Index]
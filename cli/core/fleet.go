@@ -0,0 +1,542 @@
+package core
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	v1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// FleetConfig lists the pods operated by `fleet` commands, e.g:
+//
+//	{
+//	  "sender": "keystore:/keys/operator.json",
+//	  "policy": {"startCheckpoint": true},
+//	  "pods": [
+//	    {"name": "pod-1", "address": "0x..."},
+//	    {"name": "pod-2", "address": "0x...", "sender": "env:POD_2_KEY", "policy": {"disabled": true}}
+//	  ]
+//	}
+type FleetConfig struct {
+	// The default sender (any `--sender` form) for pods that don't set one.
+	Sender string `json:"sender,omitempty"`
+	// The default policy for pods that don't set one.
+	Policy FleetPolicy `json:"policy"`
+	Pods   []FleetPod  `json:"pods"`
+}
+
+type FleetPod struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address"`
+	Sender  string `json:"sender,omitempty"`
+	// Replaces the fleet's policy for this pod.
+	Policy *FleetPolicy `json:"policy,omitempty"`
+}
+
+type FleetPolicy struct {
+	// Skip the pod, except in `fleet status`.
+	Disabled bool `json:"disabled,omitempty"`
+	// Start a checkpoint on the pod if it has none (`fleet checkpoint`). Otherwise, only active checkpoints are completed.
+	StartCheckpoint bool `json:"startCheckpoint,omitempty"`
+	// Start checkpoints even if the pod has no native ETH to award shares.
+	ForceCheckpoint bool `json:"forceCheckpoint,omitempty"`
+	// Proofs per transaction. 0 sizes batches by gas.
+	BatchSize uint64 `json:"batchSize,omitempty"`
+}
+
+func LoadFleetConfig(path string) (*FleetConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet config: %w", err)
+	}
+	config := &FleetConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse fleet config %s: %w", path, err)
+	}
+
+	seen := map[string]bool{}
+	for i, pod := range config.Pods {
+		if !common.IsHexAddress(pod.Address) {
+			return nil, fmt.Errorf("pod %d of %s: invalid address %q", i, path, pod.Address)
+		}
+		address := strings.ToLower(pod.Address)
+		if seen[address] {
+			return nil, fmt.Errorf("pod %d of %s: %s is listed more than once", i, path, pod.Address)
+		}
+		seen[address] = true
+	}
+	return config, nil
+}
+
+// SenderFor returns the pod's sender, falling back to the fleet's, then to `fallback` (i.e `--sender`).
+func (c *FleetConfig) SenderFor(pod FleetPod, fallback string) string {
+	if pod.Sender != "" {
+		return pod.Sender
+	}
+	if c.Sender != "" {
+		return c.Sender
+	}
+	return fallback
+}
+
+func (c *FleetConfig) PolicyFor(pod FleetPod) FleetPolicy {
+	if pod.Policy != nil {
+		return *pod.Policy
+	}
+	return c.Policy
+}
+
+// FleetReport is the result of a `fleet` command, for each pod in the config.
+type FleetReport struct {
+	Command string            `json:"command"`
+	Pods    []*FleetPodResult `json:"pods"`
+}
+
+type FleetPodResult struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address"`
+	// The slot of the beacon state the pod was read or proven against.
+	Slot   uint64          `json:"slot,omitempty"`
+	Status *EigenpodStatus `json:"status,omitempty"`
+	// The number of validators proven.
+	Proofs int `json:"proofs,omitempty"`
+	// Hashes of the transactions sent (or, if not sending, built) for the pod.
+	Transactions []string `json:"transactions,omitempty"`
+	Sent         bool     `json:"sent,omitempty"`
+	Skipped      string   `json:"skipped,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
+func (r *FleetReport) Failed() int {
+	failed := 0
+	for _, pod := range r.Pods {
+		if pod.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (r *FleetPodResult) fail(err error) {
+	r.Error = err.Error()
+}
+
+func (r *FleetPodResult) addTransactions(txns []*types.Transaction, sent bool) {
+	for _, txn := range txns {
+		if txn != nil {
+			r.Transactions = append(r.Transactions, txn.Hash().Hex())
+		}
+	}
+	r.Sent = sent
+}
+
+// FleetGroup is the pods that are proven against the same beacon block, so share a beacon state.
+type FleetGroup struct {
+	// A beacon block root, or "head".
+	BlockId string
+	Pods    []int // indices into the config's pods
+}
+
+// groupByBlock groups pods (by index) with the same block id, in the order each block is first seen. Pods with
+// an empty block id are left out.
+func groupByBlock(blockIds []string) []FleetGroup {
+	groups := []FleetGroup{}
+	groupOf := map[string]int{}
+	for i, blockId := range blockIds {
+		if blockId == "" {
+			continue
+		}
+		g, ok := groupOf[blockId]
+		if !ok {
+			g = len(groups)
+			groupOf[blockId] = g
+			groups = append(groups, FleetGroup{BlockId: blockId})
+		}
+		groups[g].Pods = append(groups[g].Pods, i)
+	}
+	return groups
+}
+
+// Fleet runs commands across every pod of a FleetConfig. Beacon states are fetched once per group of pods that
+// need them, and one EigenPodProofs (whose trees are cached by slot) is shared by every pod.
+type Fleet struct {
+	Config *FleetConfig
+	// The sender for pods whose config doesn't set one. Pods without a sender have their transactions built,
+	// but not sent.
+	Sender  string
+	NoSend  bool
+	Verbose bool
+
+	eth     ExecutionClient
+	beacon  BeaconClient
+	chainId *big.Int
+	proofs  *eigenpodproofs.EigenPodProofs
+}
+
+func NewFleet(config *FleetConfig, eth ExecutionClient, beacon BeaconClient, chainId *big.Int) (*Fleet, error) {
+	proofs, err := eigenpodproofs.NewEigenPodProofs(chainId.Uint64(), 3600 /* oracleStateCacheExpirySeconds - 1hr, long enough for a group */)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize prover: %w", err)
+	}
+	return &Fleet{
+		Config:  config,
+		eth:     eth,
+		beacon:  beacon,
+		chainId: chainId,
+		proofs:  proofs,
+	}, nil
+}
+
+func (f *Fleet) newReport(command string) *FleetReport {
+	report := &FleetReport{Command: command, Pods: make([]*FleetPodResult, len(f.Config.Pods))}
+	for i, pod := range f.Config.Pods {
+		report.Pods[i] = &FleetPodResult{Name: pod.Name, Address: pod.Address}
+	}
+	return report
+}
+
+// the pod's sender, and whether its transactions should only be built.
+func (f *Fleet) senderFor(pod FleetPod) (string, bool) {
+	sender := f.Config.SenderFor(pod, f.Sender)
+	return sender, f.NoSend || sender == ""
+}
+
+// fetches the header and state of a beacon block ("head", or a block root), and indexes the state's validators
+// for the group's pods to share.
+func (f *Fleet) beaconStateAt(ctx context.Context, blockId string) (*v1.BeaconBlockHeader, *spec.VersionedBeaconState, *ValidatorIndex, error) {
+	header, err := f.beacon.GetBeaconHeader(ctx, blockId)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch beacon header (%s): %w", blockId, err)
+	}
+	state, err := f.beacon.GetBeaconState(ctx, strconv.FormatUint(uint64(header.Header.Message.Slot), 10))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch beacon state: %w", err)
+	}
+	index, err := NewValidatorIndex(state)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if f.Verbose {
+		logWarn(ctx, "loaded beacon state at slot %d", header.Header.Message.Slot)
+	}
+	return header, state, index, nil
+}
+
+// the root of the pod's current checkpoint's block, or "" if it has none.
+func checkpointBlockId(eigenpodAddress string, eth ExecutionClient) (string, error) {
+	blockRoot, err := GetCurrentCheckpointBlockRoot(eigenpodAddress, eth)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch current checkpoint: %w", err)
+	}
+	if blockRoot == nil || AllZero(blockRoot[:]) {
+		return "", nil
+	}
+	return "0x" + hex.EncodeToString(blockRoot[:]), nil
+}
+
+// Status reads the status of every pod. Pods with an active checkpoint are read against the state at its
+// block, and the rest against the head state.
+func (f *Fleet) Status(ctx context.Context) *FleetReport {
+	report := f.newReport("status")
+
+	blockIds := make([]string, len(f.Config.Pods))
+	checkpoints := make([]uint64, len(f.Config.Pods))
+	for i, pod := range f.Config.Pods {
+		checkpoint, err := GetCurrentCheckpoint(pod.Address, f.eth)
+		if err != nil {
+			report.Pods[i].fail(fmt.Errorf("failed to fetch current checkpoint: %w", err))
+			continue
+		}
+		checkpoints[i] = checkpoint
+
+		blockIds[i] = "head"
+		if checkpoint != 0 {
+			if blockIds[i], err = checkpointBlockId(pod.Address, f.eth); err != nil {
+				report.Pods[i].fail(err)
+				blockIds[i] = ""
+			}
+		}
+	}
+
+	for _, group := range groupByBlock(blockIds) {
+		header, state, index, err := f.beaconStateAt(ctx, group.BlockId)
+		for _, i := range group.Pods {
+			result := report.Pods[i]
+			if err != nil {
+				result.fail(err)
+				continue
+			}
+			result.Slot = uint64(header.Header.Message.Slot)
+			status, err := GetStatusAtState(ctx, f.Config.Pods[i].Address, f.eth, checkpoints[i], state, index)
+			if err != nil {
+				result.fail(err)
+				continue
+			}
+			result.Status = &status
+		}
+	}
+	return report
+}
+
+// Checkpoint completes every pod's active checkpoint, first starting checkpoints on pods whose policy says to.
+// Pods are grouped by their checkpoint's block, so pods whose checkpoints started in the same block share one
+// beacon state.
+func (f *Fleet) Checkpoint(ctx context.Context) *FleetReport {
+	report := f.newReport("checkpoint")
+	pods := f.Config.Pods
+
+	blockIds := make([]string, len(pods))
+	readCheckpoints := func(indices []int) {
+		for _, i := range indices {
+			blockId, err := checkpointBlockId(pods[i].Address, f.eth)
+			if err != nil {
+				report.Pods[i].fail(err)
+				continue
+			}
+			blockIds[i] = blockId
+		}
+	}
+
+	enabled := []int{}
+	for i, pod := range pods {
+		if f.Config.PolicyFor(pod).Disabled {
+			report.Pods[i].Skipped = "disabled"
+			continue
+		}
+		enabled = append(enabled, i)
+	}
+	readCheckpoints(enabled)
+
+	toStart := []int{}
+	for _, i := range enabled {
+		if report.Pods[i].Error != "" || blockIds[i] != "" {
+			continue
+		}
+		if !f.Config.PolicyFor(pods[i]).StartCheckpoint {
+			report.Pods[i].Skipped = "no active checkpoint"
+			continue
+		}
+		if _, noSend := f.senderFor(pods[i]); noSend {
+			report.Pods[i].Skipped = "no active checkpoint (not sending, so none was started)"
+			continue
+		}
+		toStart = append(toStart, i)
+	}
+	if len(toStart) > 0 {
+		sendErrs := f.startCheckpoints(ctx, report, toStart)
+		readCheckpoints(toStart)
+		for _, i := range toStart {
+			if report.Pods[i].Error == "" && blockIds[i] == "" {
+				err := sendErrs[i]
+				if err == nil {
+					err = errors.New("no checkpoint after startCheckpoint()")
+				}
+				report.Pods[i].fail(fmt.Errorf("failed to start checkpoint: %w", err))
+			}
+		}
+	}
+
+	for _, group := range groupByBlock(blockIds) {
+		f.checkpointGroup(ctx, report, group)
+	}
+	return report
+}
+
+// starts checkpoints on the given pods, sending them together for pods that share a sender. A sender's
+// transactions fail together, so the returned errors (by pod) only say why a pod's checkpoint may not have started.
+func (f *Fleet) startCheckpoints(ctx context.Context, report *FleetReport, indices []int) map[int]error {
+	sendErrs := map[int]error{}
+	bySender := map[string][]int{}
+	senders := []string{}
+	for _, i := range indices {
+		sender, _ := f.senderFor(f.Config.Pods[i])
+		if _, ok := bySender[sender]; !ok {
+			senders = append(senders, sender)
+		}
+		bySender[sender] = append(bySender[sender], i)
+	}
+
+	for _, sender := range senders {
+		podIndices := bySender[sender]
+		owner, err := PrepareAccount(ctx, &sender, f.chainId, false /* noSend */)
+		if err != nil {
+			for _, i := range podIndices {
+				report.Pods[i].fail(fmt.Errorf("failed to load sender: %w", err))
+			}
+			continue
+		}
+
+		builders := make([]TxBuilder, 0, len(podIndices))
+		started := make([]int, 0, len(podIndices))
+		for _, i := range podIndices {
+			pod := f.Config.Pods[i]
+			eigenPod, err := onchain.NewEigenPod(common.HexToAddress(pod.Address), f.eth)
+			if err != nil {
+				report.Pods[i].fail(fmt.Errorf("failed to reach eigenpod: %w", err))
+				continue
+			}
+			revertIfNoBalance := !f.Config.PolicyFor(pod).ForceCheckpoint
+			builders = append(builders, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				return eigenPod.StartCheckpoint(opts, revertIfNoBalance)
+			})
+			started = append(started, i)
+		}
+
+		txns, err := NewTxManager(ctx, f.eth, owner, f.Verbose).Send(ctx, builders)
+		for j, i := range started {
+			if j < len(txns) {
+				report.Pods[i].addTransactions(txns[j:j+1], true)
+			}
+			sendErrs[i] = err
+		}
+	}
+	return sendErrs
+}
+
+// proves and submits the checkpoints of a group of pods. The group's state is dropped once every pod's proofs
+// have been generated, before anything is sent.
+func (f *Fleet) checkpointGroup(ctx context.Context, report *FleetReport, group FleetGroup) {
+	header, state, index, err := f.beaconStateAt(ctx, group.BlockId)
+	if err != nil {
+		for _, i := range group.Pods {
+			report.Pods[i].fail(err)
+		}
+		return
+	}
+
+	proofs := make([]*eigenpodproofs.VerifyCheckpointProofsCallParams, len(group.Pods))
+	checkpoints := make([]uint64, len(group.Pods))
+	for j, i := range group.Pods {
+		pod := f.Config.Pods[i]
+		report.Pods[i].Slot = uint64(header.Header.Message.Slot)
+
+		checkpoints[j], err = GetCurrentCheckpoint(pod.Address, f.eth)
+		if err != nil {
+			report.Pods[i].fail(fmt.Errorf("failed to fetch current checkpoint: %w", err))
+			continue
+		}
+		proofs[j], err = GenerateCheckpointProofForState(ctx, pod.Address, state, index, header, f.eth, checkpoints[j], f.proofs, f.Verbose)
+		if err != nil {
+			report.Pods[i].fail(err)
+		}
+	}
+
+	head, err := f.eth.BlockNumber(ctx)
+	if err != nil {
+		for _, i := range group.Pods {
+			report.Pods[i].fail(fmt.Errorf("failed to fetch latest block: %w", err))
+		}
+		return
+	}
+
+	for j, i := range group.Pods {
+		pod, result, proof := f.Config.Pods[i], report.Pods[i], proofs[j]
+		if proof == nil {
+			continue
+		}
+		if len(proof.BalanceProofs) == 0 {
+			result.Skipped = "no validators to checkpoint"
+			continue
+		}
+		result.Proofs = len(proof.BalanceProofs)
+
+		sender, noSend := f.senderFor(pod)
+		var journal *CheckpointJournal
+		if path := GetContextJournalPath(ctx, pod.Address, checkpoints[j]); !noSend && path != "" {
+			journal, err = OpenCheckpointJournal(path, pod.Address, checkpoints[j], head)
+			if err != nil {
+				result.fail(fmt.Errorf("failed to open checkpoint journal: %w", err))
+				continue
+			}
+		}
+
+		txns, err := SubmitCheckpointProof(ctx, sender, pod.Address, f.chainId, proof, f.eth, f.Config.PolicyFor(pod).BatchSize, true /* noPrompt */, noSend, f.Verbose, journal)
+		result.addTransactions(txns, !noSend)
+		if err != nil {
+			result.fail(err)
+		}
+	}
+}
+
+// Credentials verifies the withdrawal credentials of every pod's validators that are awaiting it. Every pod is
+// proven against the same (latest) beacon state.
+func (f *Fleet) Credentials(ctx context.Context) *FleetReport {
+	report := f.newReport("credentials")
+
+	enabled := []int{}
+	for i, pod := range f.Config.Pods {
+		if f.Config.PolicyFor(pod).Disabled {
+			report.Pods[i].Skipped = "disabled"
+			continue
+		}
+		enabled = append(enabled, i)
+	}
+	if len(enabled) == 0 {
+		return report
+	}
+	failAll := func(err error) *FleetReport {
+		for _, i := range enabled {
+			report.Pods[i].fail(err)
+		}
+		return report
+	}
+
+	latestBlock, err := f.eth.BlockByNumber(ctx, nil)
+	if err != nil {
+		return failAll(fmt.Errorf("failed to load latest block: %w", err))
+	}
+	// the parent block root comes from the EIP-4788 beacon roots contract, so is the same for every pod.
+	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(f.Config.Pods[enabled[0]].Address), f.eth)
+	if err != nil {
+		return failAll(fmt.Errorf("failed to reach eigenpod: %w", err))
+	}
+	blockRoot, err := eigenPod.GetParentBlockRoot(nil, latestBlock.Time())
+	if err != nil {
+		return failAll(fmt.Errorf("failed to load parent block root: %w", err))
+	}
+	header, state, index, err := f.beaconStateAt(ctx, "0x"+common.Bytes2Hex(blockRoot[:]))
+	if err != nil {
+		return failAll(err)
+	}
+
+	proofs := make([]*eigenpodproofs.VerifyValidatorFieldsCallParams, len(f.Config.Pods))
+	for _, i := range enabled {
+		pod := f.Config.Pods[i]
+		report.Pods[i].Slot = uint64(header.Header.Message.Slot)
+		proofs[i], err = GenerateValidatorProofAtState(ctx, f.proofs, pod.Address, state, index, f.eth, f.chainId, header, latestBlock.Time(), nil, f.Verbose)
+		if err != nil {
+			report.Pods[i].fail(err)
+		} else if proofs[i] == nil {
+			report.Pods[i].Skipped = "no validators awaiting a credential proof"
+		}
+	}
+
+	for _, i := range enabled {
+		pod, result, proof := f.Config.Pods[i], report.Pods[i], proofs[i]
+		if proof == nil {
+			continue
+		}
+		result.Proofs = len(proof.ValidatorIndices)
+
+		sender, noSend := f.senderFor(pod)
+		txns, _, err := SubmitValidatorProof(ctx, sender, pod.Address, f.chainId, f.eth, f.Config.PolicyFor(pod).BatchSize, proof, latestBlock.Time(), true /* noPrompt */, noSend, f.Verbose)
+		result.addTransactions(txns, !noSend)
+		if err != nil {
+			result.fail(err)
+		}
+	}
+	return report
+}
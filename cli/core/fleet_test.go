@@ -0,0 +1,76 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFleetConfig(t *testing.T, config string) string {
+	path := filepath.Join(t.TempDir(), "fleet.json")
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFleetConfig(t *testing.T) {
+	path := writeFleetConfig(t, `{
+		"sender": "env:FLEET_KEY",
+		"policy": {"startCheckpoint": true},
+		"pods": [
+			{"name": "a", "address": "0x000000000000000000000000000000000000000a"},
+			{"name": "b", "address": "0x000000000000000000000000000000000000000b", "sender": "env:B_KEY", "policy": {"disabled": true}}
+		]
+	}`)
+	config, err := LoadFleetConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b := config.Pods[0], config.Pods[1]
+	if sender := config.SenderFor(a, "0xfallback"); sender != "env:FLEET_KEY" {
+		t.Errorf("pod a's sender is %s, want the fleet's", sender)
+	}
+	if sender := config.SenderFor(b, "0xfallback"); sender != "env:B_KEY" {
+		t.Errorf("pod b's sender is %s, want its own", sender)
+	}
+	if policy := config.PolicyFor(a); !policy.StartCheckpoint || policy.Disabled {
+		t.Errorf("pod a's policy is %+v, want the fleet's", policy)
+	}
+	// a pod's policy replaces the fleet's, rather than being merged with it.
+	if policy := config.PolicyFor(b); policy.StartCheckpoint || !policy.Disabled {
+		t.Errorf("pod b's policy is %+v, want its own", policy)
+	}
+
+	config.Sender = ""
+	if sender := config.SenderFor(a, "0xfallback"); sender != "0xfallback" {
+		t.Errorf("pod a's sender is %s, want the fallback", sender)
+	}
+}
+
+func TestLoadFleetConfigRejectsBadPods(t *testing.T) {
+	for name, pods := range map[string]string{
+		"invalid address": `[{"address": "0x1234"}]`,
+		"duplicate pod":   `[{"address": "0x000000000000000000000000000000000000000A"}, {"address": "0x000000000000000000000000000000000000000a"}]`,
+	} {
+		_, err := LoadFleetConfig(writeFleetConfig(t, `{"pods": `+pods+`}`))
+		if err == nil || !strings.Contains(err.Error(), "pod 0") && !strings.Contains(err.Error(), "pod 1") {
+			t.Errorf("%s: got %v, want an error naming the pod", name, err)
+		}
+	}
+}
+
+func TestGroupByBlock(t *testing.T) {
+	groups := groupByBlock([]string{"0xaa", "head", "", "0xaa", "0xbb", "head"})
+	want := []FleetGroup{
+		{BlockId: "0xaa", Pods: []int{0, 3}},
+		{BlockId: "head", Pods: []int{1, 5}},
+		{BlockId: "0xbb", Pods: []int{4}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("got %+v, want %+v", groups, want)
+	}
+}
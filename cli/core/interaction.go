@@ -0,0 +1,42 @@
+package core
+
+import (
+	"context"
+	"errors"
+)
+
+const INTERACTION_KEY TEigenKey = "com.eigen.interaction"
+
+// ErrNoConsent is returned when the user (or, without an Interaction, the library) doesn't agree to go ahead.
+var ErrNoConsent = errors.New("aborted: consent was not given")
+
+// Interaction is how core asks the user things. Without one, nothing is asked: anything that needs consent fails
+// with ErrNoConsent (pass `noPrompt` to go ahead without asking), and keystore passphrases must come from a
+// password file.
+type Interaction struct {
+	// Returns whether the user agrees to `prompt`.
+	Consent func(prompt string) bool
+	// Returns the passphrase for the keystore at `path`.
+	Passphrase func(path string) (string, error)
+}
+
+func ContextWithInteraction(ctx context.Context, interaction *Interaction) context.Context {
+	return context.WithValue(ctx, INTERACTION_KEY, interaction)
+}
+
+func GetContextInteraction(ctx context.Context) *Interaction {
+	interaction, ok := ctx.Value(INTERACTION_KEY).(*Interaction)
+	if !ok || interaction == nil {
+		return &Interaction{}
+	}
+	return interaction
+}
+
+// askConsent returns ErrNoConsent unless the context's Interaction agrees to `prompt`.
+func askConsent(ctx context.Context, prompt string) error {
+	interaction := GetContextInteraction(ctx)
+	if interaction.Consent == nil || !interaction.Consent(prompt) {
+		return ErrNoConsent
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type BatchStatus string
+
+const (
+	BatchPending   BatchStatus = "pending"
+	BatchSucceeded BatchStatus = "succeeded"
+	BatchReverted  BatchStatus = "reverted"
+	// the transaction is unknown to the node, e.g it was never broadcast or was evicted from the mempool.
+	BatchDropped BatchStatus = "dropped"
+)
+
+// JournalBatch is one `verifyCheckpointProofs` transaction sent as part of a checkpoint.
+type JournalBatch struct {
+	ValidatorIndices []uint64    `json:"validatorIndices"`
+	TxHash           string      `json:"txHash"`
+	Status           BatchStatus `json:"status"`
+}
+
+// CheckpointJournal records the progress of a checkpoint submission on disk, so a run that dies
+// halfway through can be resumed (`checkpoint --resume`) without resubmitting proofs that landed.
+type CheckpointJournal struct {
+	EigenpodAddress     string `json:"eigenpodAddress"`
+	CheckpointTimestamp uint64 `json:"checkpointTimestamp"`
+	// The block the journal was created at. ValidatorCheckpointed events are searched for from here.
+	StartBlock uint64          `json:"startBlock"`
+	Batches    []*JournalBatch `json:"batches"`
+
+	path         string
+	checkpointed map[uint64]bool
+}
+
+const JOURNAL_DIR_KEY TEigenKey = "com.eigen.journalDir"
+
+// DefaultCheckpointJournalPath is where a checkpoint's journal is kept, unless `--journal` says otherwise.
+func DefaultCheckpointJournalPath(eigenpodAddress string, checkpointTimestamp uint64) string {
+	return fmt.Sprintf("checkpoint-%s-%d.journal.json", strings.ToLower(eigenpodAddress), checkpointTimestamp)
+}
+
+// ContextWithJournalDir sets the directory checkpoint journals are kept in when nothing names the file (e.g
+// `apply`, or a fleet). An empty `dir` turns those journals off.
+func ContextWithJournalDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, JOURNAL_DIR_KEY, dir)
+}
+
+// GetContextJournalPath returns where the journal of a pod's checkpoint is kept, or "" if journals are off. If
+// the context has no directory, it's DefaultCheckpointJournalPath, in the working directory.
+func GetContextJournalPath(ctx context.Context, eigenpodAddress string, checkpointTimestamp uint64) string {
+	dir, ok := ctx.Value(JOURNAL_DIR_KEY).(string)
+	if !ok {
+		return DefaultCheckpointJournalPath(eigenpodAddress, checkpointTimestamp)
+	}
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, DefaultCheckpointJournalPath(eigenpodAddress, checkpointTimestamp))
+}
+
+// OpenCheckpointJournal loads the journal at `path`, or starts a new one (at `startBlock`) if there is none.
+func OpenCheckpointJournal(path, eigenpodAddress string, checkpointTimestamp, startBlock uint64) (*CheckpointJournal, error) {
+	journal := &CheckpointJournal{
+		EigenpodAddress:     eigenpodAddress,
+		CheckpointTimestamp: checkpointTimestamp,
+		StartBlock:          startBlock,
+		Batches:             []*JournalBatch{},
+		path:                path,
+		checkpointed:        map[uint64]bool{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return journal, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint journal: %w", err)
+	}
+
+	if err := json.Unmarshal(data, journal); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint journal %s: %w", path, err)
+	}
+	if !strings.EqualFold(journal.EigenpodAddress, eigenpodAddress) || journal.CheckpointTimestamp != checkpointTimestamp {
+		return nil, fmt.Errorf("journal %s is for checkpoint %d of %s, not the active checkpoint (%d)", path, journal.CheckpointTimestamp, journal.EigenpodAddress, checkpointTimestamp)
+	}
+
+	for _, batch := range journal.Batches {
+		if batch.Status == BatchSucceeded {
+			journal.markCheckpointed(batch.ValidatorIndices)
+		}
+	}
+	return journal, nil
+}
+
+func (j *CheckpointJournal) Path() string {
+	return j.path
+}
+
+// IsCheckpointed returns whether the validator's proof is known to have landed for this checkpoint.
+func (j *CheckpointJournal) IsCheckpointed(validatorIndex uint64) bool {
+	return j.checkpointed[validatorIndex]
+}
+
+func (j *CheckpointJournal) markCheckpointed(validatorIndices []uint64) {
+	for _, index := range validatorIndices {
+		j.checkpointed[index] = true
+	}
+}
+
+// RecordBatch adds a just-sent batch, as pending.
+func (j *CheckpointJournal) RecordBatch(validatorIndices []uint64, txHash common.Hash) (*JournalBatch, error) {
+	batch := &JournalBatch{
+		ValidatorIndices: validatorIndices,
+		TxHash:           txHash.Hex(),
+		Status:           BatchPending,
+	}
+	j.Batches = append(j.Batches, batch)
+	return batch, j.save()
+}
+
+// ReplaceTx records that a pending batch's transaction was replaced (e.g with one paying higher fees).
+func (j *CheckpointJournal) ReplaceTx(batch *JournalBatch, txHash common.Hash) error {
+	batch.TxHash = txHash.Hex()
+	return j.save()
+}
+
+// SetStatus updates a batch once its fate is known.
+func (j *CheckpointJournal) SetStatus(batch *JournalBatch, status BatchStatus) error {
+	batch.Status = status
+	if status == BatchSucceeded {
+		j.markCheckpointed(batch.ValidatorIndices)
+	}
+	return j.save()
+}
+
+// written to a temporary file first, so a crash mid-write doesn't lose the journal.
+func (j *CheckpointJournal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint journal: %w", err)
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// ResumeCheckpointJournal settles the batches the journal still has as pending, by waiting for their
+// receipts, and then cross-checks the journal against the pod's ValidatorCheckpointed events.
+// Validators with an event are treated as checkpointed, whatever the journal says.
+func ResumeCheckpointJournal(ctx context.Context, eth ExecutionClient, journal *CheckpointJournal, verbose bool) error {
+	for _, batch := range journal.Batches {
+		if batch.Status != BatchPending {
+			continue
+		}
+		status, err := settleBatch(ctx, eth, common.HexToHash(batch.TxHash), verbose)
+		if err != nil {
+			return fmt.Errorf("failed to check transaction %s: %w", batch.TxHash, err)
+		}
+		if err := journal.SetStatus(batch, status); err != nil {
+			return err
+		}
+	}
+
+	checkpointed, err := GetCheckpointedValidators(ctx, eth, journal.EigenpodAddress, journal.CheckpointTimestamp, journal.StartBlock)
+	if err != nil {
+		return fmt.Errorf("failed to fetch ValidatorCheckpointed events: %w", err)
+	}
+
+	for index := range journal.checkpointed {
+		if !checkpointed[index] && verbose {
+			logWarn(ctx, "journal has validator %d as checkpointed, but no ValidatorCheckpointed event was found for it", index)
+		}
+	}
+	for index := range checkpointed {
+		journal.checkpointed[index] = true
+	}
+	return nil
+}
+
+func settleBatch(ctx context.Context, eth ExecutionClient, txHash common.Hash, verbose bool) (BatchStatus, error) {
+	receipt, err := eth.TransactionReceipt(ctx, txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		txn, isPending, err := eth.TransactionByHash(ctx, txHash)
+		if errors.Is(err, ethereum.NotFound) {
+			return BatchDropped, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if !isPending {
+			// mined in between the two calls
+			return settleBatch(ctx, eth, txHash, verbose)
+		}
+
+		if verbose {
+			logPlain(ctx, "waiting for pending transaction %s...", txHash.Hex())
+		}
+		receipt, err = bind.WaitMined(ctx, eth, txn)
+		if err != nil {
+			return "", err
+		}
+		if verbose {
+			logInfo(ctx, "OK")
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return BatchSucceeded, nil
+	}
+	if verbose {
+		if txn, _, err := eth.TransactionByHash(ctx, txHash); err == nil {
+			logWarn(ctx, "transaction %s reverted: %s", txHash.Hex(), ExplainRevertedTransaction(ctx, eth, txn, receipt).Error())
+		}
+	}
+	return BatchReverted, nil
+}
+
+// GetCheckpointedValidators returns the validators proven for `checkpointTimestamp`, from the pod's
+// ValidatorCheckpointed events since `fromBlock`.
+func GetCheckpointedValidators(ctx context.Context, eth ExecutionClient, eigenpodAddress string, checkpointTimestamp uint64, fromBlock uint64) (map[uint64]bool, error) {
+	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := eigenPod.FilterValidatorCheckpointed(&bind.FilterOpts{Start: fromBlock, Context: ctx}, []uint64{checkpointTimestamp}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer events.Close()
+
+	checkpointed := map[uint64]bool{}
+	for events.Next() {
+		checkpointed[events.Event.ValidatorIndex.Uint64()] = true
+	}
+	return checkpointed, events.Error()
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCheckpointJournalRoundTrip(t *testing.T) {
+	pod := "0x1234567890123456789012345678901234567890"
+	path := filepath.Join(t.TempDir(), DefaultCheckpointJournalPath(pod, 100))
+
+	journal, err := OpenCheckpointJournal(path, pod, 100, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	landed, err := journal.RecordBatch([]uint64{1, 2}, common.HexToHash("0x01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.SetStatus(landed, BatchSucceeded); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := journal.RecordBatch([]uint64{3}, common.HexToHash("0x02")); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenCheckpointJournal(path, pod, 100, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.StartBlock != 7 || len(reopened.Batches) != 2 {
+		t.Fatalf("journal was not reloaded: %+v", reopened)
+	}
+	if reopened.Batches[1].Status != BatchPending {
+		t.Errorf("expected the second batch to still be pending, got %s", reopened.Batches[1].Status)
+	}
+	for index, expected := range map[uint64]bool{1: true, 2: true, 3: false} {
+		if reopened.IsCheckpointed(index) != expected {
+			t.Errorf("IsCheckpointed(%d) = %v", index, !expected)
+		}
+	}
+
+	if _, err := OpenCheckpointJournal(path, pod, 101, 50); err == nil {
+		t.Error("expected a journal for a different checkpoint to be rejected")
+	}
+}
+
+func TestContextJournalPath(t *testing.T) {
+	pod := "0x1234567890123456789012345678901234567890"
+	ctx := context.Background()
+
+	if path := GetContextJournalPath(ctx, pod, 100); path != DefaultCheckpointJournalPath(pod, 100) {
+		t.Errorf("expected the default path without a directory, got %s", path)
+	}
+	if path := GetContextJournalPath(ContextWithJournalDir(ctx, "journals"), pod, 100); path != filepath.Join("journals", DefaultCheckpointJournalPath(pod, 100)) {
+		t.Errorf("expected a path in the directory, got %s", path)
+	}
+	if path := GetContextJournalPath(ContextWithJournalDir(ctx, ""), pod, 100); path != "" {
+		t.Errorf("expected journals to be off, got %s", path)
+	}
+}
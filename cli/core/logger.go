@@ -0,0 +1,46 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const LOGGER_KEY TEigenKey = "com.eigen.logger"
+
+// ContextWithLogger sends the progress core reports when it's verbose to `logger`, instead of printing it (in
+// color) to stdout.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, LOGGER_KEY, logger)
+}
+
+// GetContextLogger returns the context's logger, or nil if progress is printed.
+func GetContextLogger(ctx context.Context) *slog.Logger {
+	logger, _ := ctx.Value(LOGGER_KEY).(*slog.Logger)
+	return logger
+}
+
+// logs a line of progress at `level`, or prints it with `print` (e.g color.Green) if the context has no logger.
+func logWith(ctx context.Context, level slog.Level, print func(format string, a ...interface{}), format string, args ...interface{}) {
+	if logger := GetContextLogger(ctx); logger != nil {
+		logger.Log(ctx, level, strings.TrimSpace(fmt.Sprintf(format, args...)))
+		return
+	}
+	print(format, args...)
+}
+
+func logInfo(ctx context.Context, format string, args ...interface{}) {
+	logWith(ctx, slog.LevelInfo, color.Green, format, args...)
+}
+
+func logWarn(ctx context.Context, format string, args ...interface{}) {
+	logWith(ctx, slog.LevelWarn, color.Yellow, format, args...)
+}
+
+// progress printed without color.
+func logPlain(ctx context.Context, format string, args ...interface{}) {
+	logWith(ctx, slog.LevelInfo, func(format string, a ...interface{}) { fmt.Fprintf(color.Output, format, a...) }, format, args...)
+}
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogToContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := ContextWithLogger(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)))
+
+	logInfo(ctx, "planned %d txn(s)", 3)
+	logWarn(ctx, "dropping proof for %s\n", "validator 7")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected two records, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "level=INFO") || !strings.Contains(lines[0], `msg="planned 3 txn(s)"`) {
+		t.Errorf("unexpected record %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "level=WARN") || !strings.Contains(lines[1], `msg="dropping proof for validator 7"`) {
+		t.Errorf("unexpected record %s", lines[1])
+	}
+}
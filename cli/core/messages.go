@@ -36,3 +36,23 @@ func SubmitCredentialsProofConsent(numTransactions int) string {
 		plural("validator", numTransactions),
 	)
 }
+
+func ApplyPlanConsent(numSteps int) string {
+	return fmt.Sprintf(`This will send the %d %s above, in order. Deferred steps are generated as they're reached.
+
+	The pod's state has been checked against the plan. If it changes while the plan is being applied, some transactions may revert.`,
+		numSteps,
+		plural("transaction", numSteps),
+	)
+}
+
+func FleetConsent(action string, numSending, numPods int) string {
+	return fmt.Sprintf(`This will %s the pods in your fleet config, sending transactions for %d of its %d %s.
+
+	Pods are processed in groups that share a beacon state, and a pod failing doesn't stop the rest. Check the report for any that did.`,
+		action,
+		numSending,
+		numPods,
+		plural("pod", numPods),
+	)
+}
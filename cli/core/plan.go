@@ -0,0 +1,399 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Plan step types.
+const (
+	PlanStepCheckpointStart    = "checkpoint_start"
+	PlanStepCheckpointProof    = "checkpoint_proof"
+	PlanStepVerifyStaleBalance = "verify_stale_balance"
+	PlanStepVerifyCredentials  = "verify_credentials"
+)
+
+// the EigenPod method each type of step calls.
+var planStepMethods = map[string]string{
+	PlanStepCheckpointStart:    "startCheckpoint",
+	PlanStepCheckpointProof:    "verifyCheckpointProofs",
+	PlanStepVerifyStaleBalance: "verifyStaleBalance",
+	PlanStepVerifyCredentials:  "verifyWithdrawalCredentials",
+}
+
+// Plan is every transaction a multi-step command would send, in order, along with the onchain state
+// it was planned against. Written by `--plan`, and executed by `apply`.
+type Plan struct {
+	Command         string `json:"command"`
+	ChainId         uint64 `json:"chainId"`
+	EigenpodAddress string `json:"eigenpodAddress"`
+	Sender          string `json:"sender"`
+	// The block the plan was made at.
+	BlockNumber uint64 `json:"blockNumber"`
+	// `apply` refuses to run unless the pod is still in this state.
+	Preconditions PodState   `json:"preconditions"`
+	Steps         []PlanStep `json:"steps"`
+	Notes         []string   `json:"notes,omitempty"`
+}
+
+// PlanStep is one transaction of a plan. Transactions that depend on earlier steps (e.g checkpoint proofs,
+// which can't be generated until the checkpoint has started) are deferred: they have no calldata, and
+// `apply` generates them when it gets to them.
+type PlanStep struct {
+	Type        string  `json:"type"`
+	Description string  `json:"description"`
+	To          string  `json:"to,omitempty"`
+	CallData    string  `json:"calldata,omitempty"`
+	GasEstimate *uint64 `json:"gasEstimate,omitempty"`
+	Deferred    bool    `json:"deferred,omitempty"`
+}
+
+// PodState is the part of a pod's onchain state a plan depends on.
+type PodState struct {
+	CurrentCheckpointTimestamp uint64 `json:"currentCheckpointTimestamp"`
+	LastCheckpointTimestamp    uint64 `json:"lastCheckpointTimestamp"`
+	ProofsRemaining            uint64 `json:"proofsRemaining"`
+	ActiveValidatorCount       uint64 `json:"activeValidatorCount"`
+	// Validators the plan submits proofs for outside of a checkpoint (e.g `verifyStaleBalance`).
+	Validators []ValidatorState `json:"validators,omitempty"`
+}
+
+type ValidatorState struct {
+	Index              uint64 `json:"index"`
+	Pubkey             string `json:"pubkey"`
+	Status             uint8  `json:"status"`
+	LastCheckpointedAt uint64 `json:"lastCheckpointedAt"`
+}
+
+// GetPodState reads the pod's current state, including that of the validators with the given pubkeys.
+func GetPodState(eth ExecutionClient, eigenpodAddress string, pubkeys [][]byte) (PodState, error) {
+	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
+	if err != nil {
+		return PodState{}, err
+	}
+
+	state := PodState{Validators: []ValidatorState{}}
+	if state.CurrentCheckpointTimestamp, err = eigenPod.CurrentCheckpointTimestamp(nil); err != nil {
+		return PodState{}, fmt.Errorf("failed to fetch current checkpoint: %w", err)
+	}
+	if state.LastCheckpointTimestamp, err = eigenPod.LastCheckpointTimestamp(nil); err != nil {
+		return PodState{}, fmt.Errorf("failed to fetch last checkpoint: %w", err)
+	}
+	checkpoint, err := eigenPod.CurrentCheckpoint(nil)
+	if err != nil {
+		return PodState{}, fmt.Errorf("failed to fetch current checkpoint: %w", err)
+	}
+	state.ProofsRemaining = checkpoint.ProofsRemaining.Uint64()
+	activeValidators, err := eigenPod.ActiveValidatorCount(nil)
+	if err != nil {
+		return PodState{}, fmt.Errorf("failed to fetch active validator count: %w", err)
+	}
+	state.ActiveValidatorCount = activeValidators.Uint64()
+
+	for _, pubkey := range pubkeys {
+		info, err := eigenPod.ValidatorPubkeyToInfo(nil, pubkey)
+		if err != nil {
+			return PodState{}, fmt.Errorf("failed to fetch validator eigeninfo: %w", err)
+		}
+		state.Validators = append(state.Validators, ValidatorState{
+			Index:              info.ValidatorIndex,
+			Pubkey:             "0x" + common.Bytes2Hex(pubkey),
+			Status:             info.Status,
+			LastCheckpointedAt: info.LastCheckpointedAt,
+		})
+	}
+	return state, nil
+}
+
+// the ways `current` differs from `planned`.
+func (planned PodState) diff(current PodState) []string {
+	diffs := []string{}
+	check := func(name string, planned, current uint64) {
+		if planned != current {
+			diffs = append(diffs, fmt.Sprintf("%s is %d (planned at %d)", name, current, planned))
+		}
+	}
+	check("currentCheckpointTimestamp", planned.CurrentCheckpointTimestamp, current.CurrentCheckpointTimestamp)
+	check("lastCheckpointTimestamp", planned.LastCheckpointTimestamp, current.LastCheckpointTimestamp)
+	check("proofsRemaining", planned.ProofsRemaining, current.ProofsRemaining)
+	check("activeValidatorCount", planned.ActiveValidatorCount, current.ActiveValidatorCount)
+	for i, validator := range planned.Validators {
+		if i >= len(current.Validators) {
+			break
+		}
+		check(fmt.Sprintf("validator %d status", validator.Index), uint64(validator.Status), uint64(current.Validators[i].Status))
+		check(fmt.Sprintf("validator %d lastCheckpointedAt", validator.Index), validator.LastCheckpointedAt, current.Validators[i].LastCheckpointedAt)
+	}
+	return diffs
+}
+
+// NewPlan starts a plan for `command`, recording the pod's current state as its preconditions.
+func NewPlan(ctx context.Context, command string, eth ExecutionClient, chainId *big.Int, eigenpodAddress string, sender common.Address, validatorPubkeys [][]byte) (*Plan, error) {
+	head, err := eth.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch latest block: %w", err)
+	}
+	state, err := GetPodState(eth, eigenpodAddress, validatorPubkeys)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{
+		Command:         command,
+		ChainId:         chainId.Uint64(),
+		EigenpodAddress: eigenpodAddress,
+		Sender:          sender.Hex(),
+		BlockNumber:     head,
+		Preconditions:   state,
+		Steps:           []PlanStep{},
+	}, nil
+}
+
+// AddTransactions adds already-built (unsent) transactions as steps.
+func (p *Plan) AddTransactions(txType, description string, txns ...*types.Transaction) {
+	for i, txn := range txns {
+		stepDescription := description
+		if len(txns) > 1 {
+			stepDescription = fmt.Sprintf("%s (%d/%d)", description, i+1, len(txns))
+		}
+		gas := txn.Gas()
+		p.Steps = append(p.Steps, PlanStep{
+			Type:        txType,
+			Description: stepDescription,
+			To:          txn.To().Hex(),
+			CallData:    "0x" + common.Bytes2Hex(txn.Data()),
+			GasEstimate: &gas,
+		})
+	}
+}
+
+// AddCall builds a transaction with `build` and adds it as a step. If it can't be simulated yet (because it
+// depends on earlier steps), set `estimate` to false and it's added without a gas estimate.
+func (p *Plan) AddCall(txType, description string, opts *bind.TransactOpts, build TxBuilder, estimate bool) error {
+	if estimate {
+		txn, err := build(withDryRun(opts))
+		if err != nil {
+			return DecodeRevert(err)
+		}
+		p.AddTransactions(txType, description, txn)
+		return nil
+	}
+
+	txn, err := build(forSimulation(opts))
+	if err != nil {
+		return err
+	}
+	p.Steps = append(p.Steps, PlanStep{
+		Type:        txType,
+		Description: description,
+		To:          txn.To().Hex(),
+		CallData:    "0x" + common.Bytes2Hex(txn.Data()),
+	})
+	return nil
+}
+
+// AddDeferred adds a step that's generated when the plan is applied.
+func (p *Plan) AddDeferred(txType, description string) {
+	p.Steps = append(p.Steps, PlanStep{Type: txType, Description: description, Deferred: true})
+}
+
+func (p *Plan) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteOutputToFileOrStdout(data, &path)
+}
+
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	plan := &Plan{}
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	return plan, nil
+}
+
+// checkPlanStep returns an error unless the step calls the EigenPod method its type says it does, on the plan's pod.
+// Deferred steps have no calldata, so only their type is checked.
+func checkPlanStep(eigenpodAddress string, step PlanStep) error {
+	method, ok := planStepMethods[step.Type]
+	if !ok {
+		return fmt.Errorf("unknown step type %q", step.Type)
+	}
+	if step.Deferred {
+		return nil
+	}
+
+	if !common.IsHexAddress(step.To) || common.HexToAddress(step.To) != common.HexToAddress(eigenpodAddress) {
+		return fmt.Errorf("%s step is sent to %s, not the pod (%s)", step.Type, step.To, eigenpodAddress)
+	}
+	eigenPodAbi, err := onchain.EigenPodMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	callData, err := hexutil.Decode(step.CallData)
+	if err != nil {
+		return fmt.Errorf("%s step has invalid calldata: %w", step.Type, err)
+	}
+	if len(callData) < 4 || !bytes.Equal(callData[:4], eigenPodAbi.Methods[method].ID) {
+		return fmt.Errorf("%s step doesn't call EigenPod.%s()", step.Type, method)
+	}
+	return nil
+}
+
+// CheckPlan returns an error if the plan was made for another chain or sender, one of its steps doesn't call the
+// pod as its type says, or the pod's state has changed since it was made.
+func CheckPlan(plan *Plan, eth ExecutionClient, chainId *big.Int, sender common.Address) error {
+	if plan.ChainId != chainId.Uint64() {
+		return fmt.Errorf("plan is for chain %d, but the node is on chain %d", plan.ChainId, chainId.Uint64())
+	}
+	if !strings.EqualFold(plan.Sender, sender.Hex()) {
+		return fmt.Errorf("plan is for sender %s, not %s", plan.Sender, sender.Hex())
+	}
+	if !common.IsHexAddress(plan.EigenpodAddress) {
+		return fmt.Errorf("plan has an invalid pod address %q", plan.EigenpodAddress)
+	}
+	for i, step := range plan.Steps {
+		if err := checkPlanStep(plan.EigenpodAddress, step); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+
+	pubkeys := make([][]byte, len(plan.Preconditions.Validators))
+	for i, validator := range plan.Preconditions.Validators {
+		pubkeys[i] = common.FromHex(validator.Pubkey)
+	}
+	current, err := GetPodState(eth, plan.EigenpodAddress, pubkeys)
+	if err != nil {
+		return err
+	}
+	if diffs := plan.Preconditions.diff(current); len(diffs) > 0 {
+		return fmt.Errorf("the pod has changed since the plan was made (at block %d), re-run with --plan: %s", plan.BlockNumber, strings.Join(diffs, "; "))
+	}
+	return nil
+}
+
+// SendPlannedSteps sends the calldata of (non-deferred) plan steps as-is, through a TxManager. Every step must call
+// the pod at `eigenpodAddress` (see CheckPlan).
+func SendPlannedSteps(ctx context.Context, eth ExecutionClient, owner *Owner, eigenpodAddress string, steps []PlanStep, verbose bool) ([]*types.Transaction, error) {
+	builders := make([]TxBuilder, len(steps))
+	for i, step := range steps {
+		if step.Deferred {
+			return nil, fmt.Errorf("step %d (%s) is deferred, and has no calldata", i, step.Type)
+		}
+		if err := checkPlanStep(eigenpodAddress, step); err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		contract := bind.NewBoundContract(common.HexToAddress(step.To), abi.ABI{}, eth, eth, eth)
+		callData := common.FromHex(step.CallData)
+		builders[i] = func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return contract.RawTransact(opts, callData)
+		}
+	}
+	return NewTxManager(ctx, eth, owner, verbose).Send(ctx, builders)
+}
+
+// ApplyPlan sends a plan's steps from `sender` in order, generating deferred steps as they're reached. It refuses
+// to if the pod has changed since the plan was made (see CheckPlan), and unless `noPrompt` is set, asks for
+// consent first. `onSent` (if set) is called with each transaction as it's sent. Deferred checkpoint proofs are
+// batched by `batchSize` (0 sizes batches by gas) and generated with `proofs`, which may be nil.
+func ApplyPlan(ctx context.Context, plan *Plan, sender string, eth ExecutionClient, chainId *big.Int, beaconClient BeaconClient, proofs *eigenpodproofs.EigenPodProofs, batchSize uint64, noPrompt bool, verbose bool, onSent func(description string, txn *types.Transaction)) ([]*types.Transaction, error) {
+	if onSent == nil {
+		onSent = func(string, *types.Transaction) {}
+	}
+
+	ownerAccount, err := PrepareAccount(ctx, &sender, chainId, false /* noSend */)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sender: %w", err)
+	}
+	if err := CheckPlan(plan, eth, chainId, ownerAccount.FromAddress); err != nil {
+		return nil, fmt.Errorf("refusing to apply plan: %w", err)
+	}
+	if !noPrompt {
+		if err := askConsent(ctx, ApplyPlanConsent(len(plan.Steps))); err != nil {
+			return nil, err
+		}
+	}
+
+	sent := []*types.Transaction{}
+	for start := 0; start < len(plan.Steps); {
+		step := plan.Steps[start]
+		if step.Deferred {
+			txns, err := applyDeferredStep(ctx, plan, step, sender, eth, chainId, beaconClient, proofs, batchSize, verbose)
+			for i, txn := range txns {
+				onSent(fmt.Sprintf("%s (%d/%d)", step.Description, i+1, len(txns)), txn)
+			}
+			sent = append(sent, txns...)
+			if err != nil {
+				return sent, fmt.Errorf("failed to apply step %d (%s): %w", start+1, step.Type, err)
+			}
+			start++
+			continue
+		}
+
+		// consecutive steps of the same type (e.g proof batches) are sent together
+		end := start + 1
+		for end < len(plan.Steps) && !plan.Steps[end].Deferred && plan.Steps[end].Type == step.Type {
+			end++
+		}
+		txns, err := SendPlannedSteps(ctx, eth, ownerAccount, plan.EigenpodAddress, plan.Steps[start:end], verbose)
+		for i, txn := range txns {
+			if txn != nil {
+				onSent(plan.Steps[start+i].Description, txn)
+				sent = append(sent, txn)
+			}
+		}
+		if err != nil {
+			return sent, fmt.Errorf("failed to apply step %d (%s): %w", start+1, step.Type, err)
+		}
+		start = end
+	}
+	return sent, nil
+}
+
+// generates and sends a step that depended on earlier ones.
+func applyDeferredStep(ctx context.Context, plan *Plan, step PlanStep, sender string, eth ExecutionClient, chainId *big.Int, beaconClient BeaconClient, proofs *eigenpodproofs.EigenPodProofs, batchSize uint64, verbose bool) ([]*types.Transaction, error) {
+	switch step.Type {
+	case PlanStepCheckpointProof:
+		currentCheckpoint, err := GetCurrentCheckpoint(plan.EigenpodAddress, eth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+		}
+
+		var journal *CheckpointJournal
+		if path := GetContextJournalPath(ctx, plan.EigenpodAddress, currentCheckpoint); path != "" {
+			head, err := eth.BlockNumber(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch latest block: %w", err)
+			}
+			journal, err = OpenCheckpointJournal(path, plan.EigenpodAddress, currentCheckpoint, head)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open checkpoint journal: %w", err)
+			}
+		}
+
+		proof, err := GenerateCheckpointProofWithProver(ctx, plan.EigenpodAddress, eth, chainId, beaconClient, proofs, verbose)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate checkpoint proof: %w", err)
+		}
+		return SubmitCheckpointProof(ctx, sender, plan.EigenpodAddress, chainId, proof, eth, batchSize, true /* noPrompt */, false /* noSend */, verbose, journal)
+	default:
+		return nil, fmt.Errorf("don't know how to generate a deferred %s step", step.Type)
+	}
+}
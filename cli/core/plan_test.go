@@ -0,0 +1,96 @@
+package core
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestPlanRoundTripAndDivergence(t *testing.T) {
+	gas := uint64(120_000)
+	plan := &Plan{
+		Command:         "correct-stale-pod",
+		ChainId:         17000,
+		EigenpodAddress: "0x0000000000000000000000000000000000000001",
+		Preconditions: PodState{
+			CurrentCheckpointTimestamp: 100,
+			ProofsRemaining:            3,
+			ActiveValidatorCount:       3,
+			Validators:                 []ValidatorState{{Index: 7, Pubkey: "0x01", Status: 1, LastCheckpointedAt: 50}},
+		},
+		Steps: []PlanStep{{Type: PlanStepCheckpointProof, To: "0x0000000000000000000000000000000000000001", CallData: "0x1234", GasEstimate: &gas}},
+	}
+	plan.AddDeferred(PlanStepCheckpointProof, "later")
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := plan.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(plan, loaded) {
+		t.Errorf("plan changed in a round trip: %+v", loaded)
+	}
+
+	if diffs := plan.Preconditions.diff(loaded.Preconditions); len(diffs) != 0 {
+		t.Errorf("expected no divergence, got %v", diffs)
+	}
+
+	current := loaded.Preconditions
+	current.ProofsRemaining = 1
+	current.Validators = []ValidatorState{{Index: 7, Pubkey: "0x01", Status: 2, LastCheckpointedAt: 50}}
+	if diffs := plan.Preconditions.diff(current); len(diffs) != 2 {
+		t.Errorf("expected proofsRemaining and the validator's status to diverge, got %v", diffs)
+	}
+}
+
+func TestCheckPlanSteps(t *testing.T) {
+	pod := "0x0000000000000000000000000000000000000001"
+	eigenPodAbi, err := onchain.EigenPodMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	startCheckpoint, err := eigenPodAbi.Pack("startCheckpoint", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		step  PlanStep
+		valid bool
+	}{
+		{"matching", PlanStep{Type: PlanStepCheckpointStart, To: pod, CallData: hexutil.Encode(startCheckpoint)}, true},
+		{"deferred", PlanStep{Type: PlanStepCheckpointProof, Deferred: true}, true},
+		{"another contract", PlanStep{Type: PlanStepCheckpointStart, To: "0x0000000000000000000000000000000000000002", CallData: hexutil.Encode(startCheckpoint)}, false},
+		{"another method", PlanStep{Type: PlanStepCheckpointProof, To: pod, CallData: hexutil.Encode(startCheckpoint)}, false},
+		{"no selector", PlanStep{Type: PlanStepCheckpointStart, To: pod, CallData: "0x12"}, false},
+		{"unknown type", PlanStep{Type: "withdraw", To: pod, CallData: hexutil.Encode(startCheckpoint)}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := &Plan{ChainId: 17000, EigenpodAddress: pod, Sender: common.Address{}.Hex(), Steps: []PlanStep{test.step}}
+			err := checkPlanStep(pod, test.step)
+			if (err == nil) != test.valid {
+				t.Errorf("unexpected result: %v", err)
+			}
+			if !test.valid {
+				// rejected before the pod's state is read
+				if err := CheckPlan(plan, nil, big.NewInt(17000), common.Address{}); err == nil {
+					t.Error("expected CheckPlan to reject the step")
+				}
+				if _, err := SendPlannedSteps(context.Background(), nil, nil, pod, plan.Steps, false); err == nil {
+					t.Error("expected SendPlannedSteps to reject the step")
+				}
+			}
+		})
+	}
+}
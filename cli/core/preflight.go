@@ -0,0 +1,134 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// returns why a transaction submitting proofs [start, end) would revert, or nil if it wouldn't.
+type batchSimulator func(start, end int) error
+
+// ProofFailure is a proof that reverts on its own in pre-flight simulation.
+type ProofFailure struct {
+	Proof int
+	Err   error
+}
+
+// forSimulation only builds the transaction, without asking the node for a nonce, fees or a gas estimate,
+// so its calldata can be simulated with eth_call.
+func forSimulation(opts *bind.TransactOpts) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From:  opts.From,
+		Nonce: big.NewInt(0),
+		Signer: func(_ common.Address, txn *types.Transaction) (*types.Transaction, error) {
+			return txn, nil
+		},
+
+		Value:     opts.Value,
+		GasFeeCap: big.NewInt(0),
+		GasTipCap: big.NewInt(0),
+		GasLimit:  1, // unused, but skips estimation
+
+		Context: opts.Context,
+		NoSend:  true,
+	}
+}
+
+// simulateTx runs the transaction `build` would send with eth_call, from `opts.From`, against the latest block.
+func simulateTx(ctx context.Context, eth ExecutionClient, opts *bind.TransactOpts, build TxBuilder) error {
+	txn, err := build(forSimulation(opts))
+	if err != nil {
+		return DecodeRevert(err)
+	}
+	_, err = eth.CallContract(ctx, ethereum.CallMsg{
+		From:  opts.From,
+		To:    txn.To(),
+		Value: txn.Value(),
+		Data:  txn.Data(),
+	}, nil)
+	return DecodeRevert(err)
+}
+
+// preflightBatches simulates every batch. A batch that would revert is bisected to find the proofs
+// responsible; the rest of it is returned as smaller batches that pass.
+//
+// If a batch fails whichever of its proofs it's sent with (e.g the pod is paused), the problem isn't the
+// proofs, and that's returned as an error.
+func preflightBatches(batches []batchRange, simulate batchSimulator) ([]batchRange, []ProofFailure, error) {
+	passing := []batchRange{}
+	failures := []ProofFailure{}
+
+	var bisect func(batch batchRange, err error)
+	bisect = func(batch batchRange, err error) {
+		if batch.size() == 1 {
+			failures = append(failures, ProofFailure{Proof: batch.start, Err: err})
+			return
+		}
+		mid := batch.start + batch.size()/2
+		for _, half := range []batchRange{{start: batch.start, end: mid}, {start: mid, end: batch.end}} {
+			if err := simulate(half.start, half.end); err != nil {
+				bisect(half, err)
+			} else {
+				passing = append(passing, half)
+			}
+		}
+	}
+
+	for i, batch := range batches {
+		err := simulate(batch.start, batch.end)
+		if err == nil {
+			passing = append(passing, batch)
+			continue
+		}
+
+		failed := len(failures)
+		bisect(batch, err)
+		if batch.size() > 1 && len(failures)-failed == batch.size() {
+			return nil, nil, fmt.Errorf("batch %d reverts with any of its proofs: %w", i, err)
+		}
+	}
+	return passing, failures, nil
+}
+
+// preflight simulates each batch before anything is sent, unless `--noPreflight` is set. Proofs that would
+// revert fail the submission, or with `--dropFailing` are reported and left out. `describe` names a proof
+// (e.g by its validator) for the report.
+func preflight(ctx context.Context, batches []batchRange, simulate batchSimulator, describe func(proof int) string, verbose bool) ([]batchRange, error) {
+	config := GetContextTxConfig(ctx)
+	if config.SkipPreflight {
+		return batches, nil
+	}
+
+	passing, failures, err := preflightBatches(batches, simulate)
+	if err != nil {
+		return nil, fmt.Errorf("pre-flight simulation failed: %w", err)
+	}
+	if len(failures) == 0 {
+		if verbose {
+			logInfo(ctx, "pre-flight: all %d txn(s) simulated successfully", len(batches))
+		}
+		return passing, nil
+	}
+
+	errs := make([]error, len(failures))
+	for i, failure := range failures {
+		errs[i] = fmt.Errorf("%s: %w", describe(failure.Proof), failure.Err)
+	}
+	if !config.DropFailingProofs {
+		return nil, fmt.Errorf("pre-flight: %d proof(s) would revert (re-run with --dropFailing to submit the rest):\n%w", len(failures), errors.Join(errs...))
+	}
+
+	if verbose {
+		for _, err := range errs {
+			logWarn(ctx, "pre-flight: dropping proof for %s", err.Error())
+		}
+	}
+	return passing, nil
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPreflightBatchesBisectsFailingProofs(t *testing.T) {
+	// proofs 5 and 13 are invalid
+	invalid := map[int]bool{5: true, 13: true}
+	calls := 0
+	simulate := func(start, end int) error {
+		calls++
+		for i := start; i < end; i++ {
+			if invalid[i] {
+				return errors.New("execution reverted: invalid proof")
+			}
+		}
+		return nil
+	}
+
+	passing, failures, err := preflightBatches(fixedBatches(20, 8), simulate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 2 || failures[0].Proof != 5 || failures[1].Proof != 13 {
+		t.Fatalf("expected proofs 5 and 13 to fail, got %v", failures)
+	}
+	if proofsIn(passing) != 18 {
+		t.Errorf("expected the other 18 proofs to pass, got %v", passing)
+	}
+	for i := 1; i < len(passing); i++ {
+		if passing[i].start < passing[i-1].end {
+			t.Errorf("expected passing batches in order, got %v", passing)
+		}
+	}
+	if calls >= 20 {
+		t.Errorf("expected bisection to need fewer simulations than proofs, used %d", calls)
+	}
+
+	// a batch that fails no matter what's in it isn't a proof problem
+	if _, _, err := preflightBatches(fixedBatches(20, 8), func(_, _ int) error {
+		return errors.New("execution reverted: index is paused")
+	}); err == nil {
+		t.Error("expected an error when every proof of a batch fails")
+	}
+}
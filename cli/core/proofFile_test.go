@@ -0,0 +1,100 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
+)
+
+const testPod = "0x2bDcC0fD4A4dBCf5cE7f2A9e0f8E5F0bC1A2b3C4"
+
+func TestCheckpointProofFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "proof.json")
+	data, err := json.Marshal(SerializableCheckpointProof{
+		VerifyCheckpointProofsCallParams: &eigenpodproofs.VerifyCheckpointProofsCallParams{
+			ValidatorBalancesRootProof: &eigenpodproofs.ValidatorBalancesRootProof{},
+		},
+		EigenpodAddress:     testPod,
+		CheckpointTimestamp: 1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if kind, err := DetectProofFileKind(path); err != nil || kind != ProofFileCheckpoint {
+		t.Fatalf("expected a checkpoint proof file, got %s (%v)", kind, err)
+	}
+	proof, err := LoadCheckpointProofFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		pod        string
+		checkpoint uint64
+		err        string
+	}{
+		{pod: strings.ToLower(testPod), checkpoint: 1000},
+		{pod: "0x000000000000000000000000000000000000dEaD", checkpoint: 1000, err: "not 0x000000000000000000000000000000000000dEaD"},
+		{pod: testPod, checkpoint: 0, err: "no active checkpoint"},
+		{pod: testPod, checkpoint: 2000, err: "started at 1000"},
+	} {
+		err := proof.Check(test.pod, test.checkpoint)
+		if (err == nil) != (test.err == "") || (err != nil && !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("Check(%s, %d): expected %q, got %v", test.pod, test.checkpoint, test.err, err)
+		}
+	}
+
+	legacy := &SerializableCheckpointProof{VerifyCheckpointProofsCallParams: proof.VerifyCheckpointProofsCallParams}
+	if err := legacy.Check(testPod, 1000); err == nil || !strings.Contains(err.Error(), "older version") {
+		t.Errorf("expected a proof without a pod to be rejected, got %v", err)
+	}
+}
+
+func TestCredentialProofFileCheck(t *testing.T) {
+	proof := &SerializableCredentialProof{OracleBeaconTimestamp: 1000, EigenpodAddress: testPod}
+
+	if err := proof.Check(testPod, 0); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := proof.Check(testPod, 999); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := proof.Check(testPod, 1000); err == nil || !strings.Contains(err.Error(), "before the pod's current checkpoint") {
+		t.Errorf("expected a proof from before the checkpoint to be rejected, got %v", err)
+	}
+	if err := proof.Check("0x000000000000000000000000000000000000dEaD", 0); err == nil {
+		t.Error("expected a proof for another pod to be rejected")
+	}
+	if err := (&SerializableCredentialProof{OracleBeaconTimestamp: 1000}).Check(testPod, 0); err == nil {
+		t.Error("expected a proof without a pod to be rejected")
+	}
+}
+
+func TestLoadProofFilesRejectTheOtherKind(t *testing.T) {
+	dir := t.TempDir()
+	checkpointPath := filepath.Join(dir, "checkpoint.json")
+	credentialPath := filepath.Join(dir, "credentials.json")
+	if err := os.WriteFile(checkpointPath, []byte(`{"validatorBalancesRootProof": {}, "balanceProofs": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(credentialPath, []byte(`{"ValidatorProofs": {}, "OracleBeaconTimestamp": 1000}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadValidatorProofFromFile(checkpointPath); err == nil || !strings.Contains(err.Error(), "not a credential proof file") {
+		t.Errorf("expected a checkpoint proof to be rejected as a credential proof, got %v", err)
+	}
+	if _, err := LoadValidatorProofFromFile(credentialPath); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := LoadCheckpointProofFromFile(credentialPath); err == nil || !strings.Contains(err.Error(), "not a checkpoint proof file") {
+		t.Errorf("expected a credential proof to be rejected as a checkpoint proof, got %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beaconmock"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacontest"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/rpcreplay"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// The tests below run a command's core against fake nodes while recording its traffic, then run it again
+// from the recording alone, with the nodes stopped. The recordings are generated rather than checked in:
+// a synthetic beacon state is a few MB of SSZ, and cli/.gitignore ignores *.json.
+
+type fakeEigenPod struct {
+	owner                    common.Address
+	proofSubmitter           common.Address
+	checkpointTimestamp      uint64
+	checkpoint               onchain.IEigenPodCheckpoint
+	withdrawableRestakedGwei uint64
+	balanceWei               *big.Int
+	sharesWei                *big.Int
+	infos                    map[[32]byte]onchain.IEigenPodValidatorInfo
+}
+
+// an execution node serving eth_call for an EigenPodManager and its pods, which answers calls to any other
+// address as if it had no code.
+type fakeEigenLayer struct {
+	chainId uint64
+	manager common.Address
+	pods    map[common.Address]*fakeEigenPod
+}
+
+func (l *fakeEigenLayer) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(new(big.Int).SetUint64(l.chainId))
+}
+
+func (l *fakeEigenLayer) GetBalance(address common.Address, _ string) *hexutil.Big {
+	if pod, ok := l.pods[address]; ok {
+		return (*hexutil.Big)(pod.balanceWei)
+	}
+	return (*hexutil.Big)(new(big.Int))
+}
+
+func (l *fakeEigenLayer) GetCode(address common.Address, _ string) hexutil.Bytes {
+	if _, ok := l.pods[address]; ok || address == l.manager {
+		return hexutil.Bytes{0x00}
+	}
+	return hexutil.Bytes{}
+}
+
+func (l *fakeEigenLayer) Call(args fakeCallArgs, _ string) (hexutil.Bytes, error) {
+	data := args.Data
+	if len(args.Input) > 0 {
+		data = args.Input
+	}
+	if args.To == l.manager {
+		return l.callManager(data)
+	}
+	if pod, ok := l.pods[args.To]; ok {
+		return l.callPod(pod, data)
+	}
+	return hexutil.Bytes{}, nil
+}
+
+func (l *fakeEigenLayer) callManager(data []byte) (hexutil.Bytes, error) {
+	method, in, err := unpackCall(onchain.EigenPodManagerMetaData, data)
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "ownerToPod":
+		for address, pod := range l.pods {
+			if pod.owner == in[0].(common.Address) {
+				return method.Outputs.Pack(address)
+			}
+		}
+		return method.Outputs.Pack(common.Address{})
+	case "podOwnerShares":
+		for _, pod := range l.pods {
+			if pod.owner == in[0].(common.Address) {
+				return method.Outputs.Pack(pod.sharesWei)
+			}
+		}
+		return method.Outputs.Pack(new(big.Int))
+	}
+	return nil, fmt.Errorf("unexpected call to EigenPodManager.%s", method.Name)
+}
+
+func (l *fakeEigenLayer) callPod(pod *fakeEigenPod, data []byte) (hexutil.Bytes, error) {
+	method, in, err := unpackCall(onchain.EigenPodMetaData, data)
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "currentCheckpoint":
+		return method.Outputs.Pack(pod.checkpoint)
+	case "currentCheckpointTimestamp":
+		return method.Outputs.Pack(pod.checkpointTimestamp)
+	case "eigenPodManager":
+		return method.Outputs.Pack(l.manager)
+	case "podOwner":
+		return method.Outputs.Pack(pod.owner)
+	case "proofSubmitter":
+		return method.Outputs.Pack(pod.proofSubmitter)
+	case "withdrawableRestakedExecutionLayerGwei":
+		return method.Outputs.Pack(pod.withdrawableRestakedGwei)
+	case "validatorPubkeyHashToInfo":
+		return method.Outputs.Pack(pod.infos[in[0].([32]byte)])
+	case "validatorPubkeyToInfo":
+		return method.Outputs.Pack(pod.infos[PubkeyHash(phase0.BLSPubKey(in[0].([]byte)))])
+	}
+	return nil, fmt.Errorf("unexpected call to EigenPod.%s", method.Name)
+}
+
+func unpackCall(metadata *bind.MetaData, data []byte) (*abi.Method, []any, error) {
+	contractAbi, err := metadata.GetAbi()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) < 4 {
+		return nil, nil, fmt.Errorf("calldata too short")
+	}
+	method, err := contractAbi.MethodById(data[:4])
+	if err != nil {
+		return nil, nil, err
+	}
+	in, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return method, in, nil
+}
+
+func (l *fakeEigenLayer) serve(t *testing.T) *httptest.Server {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", l); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	return httptest.NewServer(server)
+}
+
+var (
+	replayPod       = common.HexToAddress("0x000000000000000000000000000000000000b0d5")
+	replayPodOwner  = common.HexToAddress("0x00000000000000000000000000000000000000e1")
+	replaySubmitter = common.HexToAddress("0x00000000000000000000000000000000000005b1")
+	// a slashed validator withdraws here, but it isn't a pod
+	replayNotAPod = common.HexToAddress("0x000000000000000000000000000000000000dead")
+)
+
+// a pod with a checkpoint in progress at the fixture's block. Its validators are 0 and 1 (active, not yet
+// checkpointed; 1 is slashed) and 2 (awaiting its withdrawal credential proof). Validator 3 has BLS credentials
+// and 4 is slashed, withdrawing to an address that isn't a pod.
+func newReplayScenario(t *testing.T) (*beacontest.Fixture, *fakeEigenLayer) {
+	fixture, err := beacontest.NewStateBuilder(64).
+		AddValidator(beacontest.Validator{Pod: replayPod, Balance: 5 * params.GWei}).
+		AddValidator(beacontest.Validator{Pod: replayPod, Balance: 4 * params.GWei, Slashed: true}).
+		AddValidator(beacontest.Validator{Pod: replayPod, Balance: 1 * params.GWei}).
+		AddValidator(beacontest.Validator{}).
+		AddValidator(beacontest.Validator{Pod: replayNotAPod, Slashed: true}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	validators := fixture.State.Deneb.Validators
+
+	chain, err := chains.Get(17000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpointTimestamp := fixture.Block.Message.Body.ExecutionPayload.Timestamp
+	pod := &fakeEigenPod{
+		owner:               replayPodOwner,
+		proofSubmitter:      replaySubmitter,
+		checkpointTimestamp: checkpointTimestamp,
+		checkpoint: onchain.IEigenPodCheckpoint{
+			BeaconBlockRoot:   fixture.BlockRoot,
+			ProofsRemaining:   big.NewInt(2),
+			PodBalanceGwei:    500_000_000,
+			BalanceDeltasGwei: new(big.Int),
+		},
+		balanceWei: new(big.Int).Mul(big.NewInt(500_000_000), big.NewInt(params.GWei)),
+		sharesWei:  new(big.Int).Mul(big.NewInt(16), big.NewInt(params.Ether)),
+		infos: map[[32]byte]onchain.IEigenPodValidatorInfo{
+			PubkeyHash(validators[0].PublicKey): {ValidatorIndex: 0, RestakedBalanceGwei: 5 * params.GWei, Status: ValidatorStatusActive},
+			PubkeyHash(validators[1].PublicKey): {ValidatorIndex: 1, RestakedBalanceGwei: 4 * params.GWei, Status: ValidatorStatusActive},
+		},
+	}
+	return fixture, &fakeEigenLayer{
+		chainId: chain.ChainID,
+		manager: chain.EigenPodManager,
+		pods:    map[common.Address]*fakeEigenPod{replayPod: pod},
+	}
+}
+
+// runs `run` against the nodes while recording their traffic, then again from the recording with the nodes
+// stopped, and returns both results.
+func recordAndReplay[T any](t *testing.T, fixture *beacontest.Fixture, layer *fakeEigenLayer, run func(ctx context.Context, eth *ethclient.Client, beaconClient BeaconClient, chainId *big.Int) (T, error)) (T, T) {
+	t.Helper()
+	fixtures := beaconmock.NewFixtures()
+	if err := fixtures.AddState(fixture.State); err != nil {
+		t.Fatal(err)
+	}
+	mock, err := beaconmock.New(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	beaconNode := httptest.NewServer(mock)
+	execNode := layer.serve(t)
+
+	runWith := func(interceptor rpcreplay.Interceptor) T {
+		ctx := ContextWithRPCInterceptor(context.Background(), interceptor)
+		eth, beaconClient, chainId, err := GetClients(ctx, execNode.URL, beaconNode.URL, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer eth.Close()
+		defer beaconClient.(io.Closer).Close()
+
+		cache = Cache{} // a new command
+		out, err := run(ctx, eth, beaconClient, chainId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	path := filepath.Join(t.TempDir(), "fixture.jsonl")
+	recorder, err := rpcreplay.NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := runWith(recorder)
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	execNode.Close()
+	beaconNode.Close()
+
+	replayer, err := rpcreplay.LoadReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recorded, runWith(replayer)
+}
+
+func TestReplayGetStatus(t *testing.T) {
+	fixture, layer := newReplayScenario(t)
+	recorded, replayed := recordAndReplay(t, fixture, layer, func(ctx context.Context, eth *ethclient.Client, beaconClient BeaconClient, _ *big.Int) (EigenpodStatus, error) {
+		return GetStatus(ctx, replayPod.Hex(), eth, beaconClient)
+	})
+
+	assert.Equal(t, replayPodOwner, recorded.PodOwner)
+	assert.Equal(t, replaySubmitter, recorded.ProofSubmitter)
+	assert.Equal(t, &Checkpoint{ProofsRemaining: 2, StartedAt: layer.pods[replayPod].checkpointTimestamp}, recorded.ActiveCheckpoint)
+	assert.Equal(t, []string{"0", "1", "2"}, sortedKeys(recorded.Validators))
+	assert.Equal(t, ValidatorStatusActive, recorded.Validators["1"].Status)
+	assert.True(t, recorded.Validators["1"].Slashed)
+	assert.True(t, recorded.Validators["2"].IsAwaitingWithdrawalCredentialProof)
+	assert.Equal(t, uint64(4*params.GWei), recorded.Validators["1"].CurrentBalance)
+
+	// 16 ETH of shares, plus the checkpoint's 0.5 ETH of native ETH (the beacon balances haven't changed)
+	sharesGwei, _ := recorded.TotalSharesAfterCheckpointGwei.Float64()
+	assert.Equal(t, float64(16_500_000_000), sharesGwei)
+
+	assert.Equal(t, recorded, replayed)
+}
+
+func TestReplayFindStaleEigenpods(t *testing.T) {
+	fixture, layer := newReplayScenario(t)
+	recorded, replayed := recordAndReplay(t, fixture, layer, func(ctx context.Context, eth *ethclient.Client, beaconClient BeaconClient, chainId *big.Int) (map[string][]ValidatorWithIndex, error) {
+		return FindStaleEigenpods(ctx, eth, "", beaconClient, chainId, false, 5)
+	})
+
+	// the pod holds 10.5 ETH against 16 ETH of shares
+	pod := strings.ToLower(replayPod.Hex()[2:])
+	assert.Equal(t, []string{pod}, sortedKeys(recorded))
+	if assert.Len(t, recorded[pod], 1) {
+		assert.Equal(t, uint64(1), recorded[pod][0].Index)
+	}
+
+	assert.Equal(t, recorded, replayed)
+}
+
+func TestReplayGenerateCheckpointProof(t *testing.T) {
+	fixture, layer := newReplayScenario(t)
+	recorded, replayed := recordAndReplay(t, fixture, layer, func(ctx context.Context, eth *ethclient.Client, beaconClient BeaconClient, chainId *big.Int) (*eigenpodproofs.VerifyCheckpointProofsCallParams, error) {
+		return GenerateCheckpointProof(ctx, replayPod.Hex(), eth, chainId, beaconClient, false)
+	})
+
+	// validators 0 and 1 are active and not yet checkpointed
+	assert.Len(t, recorded.BalanceProofs, 2)
+	assert.Equal(t, recorded, replayed)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := keys(m)
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+const RESOURCES_KEY TEigenKey = "com.eigen.resources"
+
+// Resources collects what GetClients opens (e.g the local servers intercepting beacon traffic) so it can all be
+// released at once, when a command or client is done with it.
+type Resources struct {
+	mu      sync.Mutex
+	closers []func() error
+}
+
+func (r *Resources) Add(close func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closers = append(r.closers, close)
+}
+
+// Close releases everything added so far, most recent first.
+func (r *Resources) Close() error {
+	r.mu.Lock()
+	closers := r.closers
+	r.closers = nil
+	r.mu.Unlock()
+
+	var errs []error
+	for i := len(closers) - 1; i >= 0; i-- {
+		errs = append(errs, closers[i]())
+	}
+	return errors.Join(errs...)
+}
+
+func ContextWithResources(ctx context.Context, resources *Resources) context.Context {
+	return context.WithValue(ctx, RESOURCES_KEY, resources)
+}
+
+func GetContextResources(ctx context.Context) *Resources {
+	resources, ok := ctx.Value(RESOURCES_KEY).(*Resources)
+	if !ok {
+		return nil
+	}
+	return resources
+}
+
+// a BeaconClient that releases what it was built on when closed.
+type closingBeaconClient struct {
+	BeaconClient
+	close func() error
+}
+
+func (c *closingBeaconClient) Close() error {
+	return c.close()
+}
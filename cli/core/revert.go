@@ -0,0 +1,234 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// RevertError is a reverted call or transaction, with its revert data decoded against the EigenPod and
+// EigenPodManager ABIs.
+type RevertError struct {
+	// The require string, panic reason, or custom error (e.g `Name(arg1, arg2)`). Empty if the data couldn't be decoded.
+	Reason string
+	// The custom error's name. For the require strings of older deployments, it's the name of the error that
+	// replaced it.
+	Name string
+	// What the revert most likely means for the user, if it's a revert we know about.
+	Explanation string
+	Data        []byte
+
+	err error
+}
+
+func (e *RevertError) Error() string {
+	message := "execution reverted"
+	if e.Reason != "" {
+		message += ": " + e.Reason
+	}
+	if e.Explanation != "" {
+		message += " (" + e.Explanation + ")"
+	}
+	return message
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.err
+}
+
+// The custom errors of EigenPod, EigenPodManager, the libraries they use (BeaconChainProofs) and Pausable. The
+// generated bindings predate them, so they're listed here by hand.
+const eigenPodErrorsABI = `[
+	{"type": "error", "name": "BeaconTimestampTooFarInPast", "inputs": []},
+	{"type": "error", "name": "CannotCheckpointTwiceInSingleBlock", "inputs": []},
+	{"type": "error", "name": "CheckpointAlreadyActive", "inputs": []},
+	{"type": "error", "name": "CredentialsAlreadyVerified", "inputs": []},
+	{"type": "error", "name": "CurrentlyPaused", "inputs": []},
+	{"type": "error", "name": "EigenPodAlreadyExists", "inputs": []},
+	{"type": "error", "name": "InputAddressZero", "inputs": []},
+	{"type": "error", "name": "InputArrayLengthMismatch", "inputs": []},
+	{"type": "error", "name": "InsufficientWithdrawableBalance", "inputs": []},
+	{"type": "error", "name": "InvalidEIP4788Response", "inputs": []},
+	{"type": "error", "name": "InvalidProof", "inputs": []},
+	{"type": "error", "name": "InvalidProofLength", "inputs": []},
+	{"type": "error", "name": "InvalidPubKeyLength", "inputs": []},
+	{"type": "error", "name": "InvalidValidatorFieldsLength", "inputs": []},
+	{"type": "error", "name": "MsgValueNot32ETH", "inputs": []},
+	{"type": "error", "name": "NoActiveCheckpoint", "inputs": []},
+	{"type": "error", "name": "NoBalanceToCheckpoint", "inputs": []},
+	{"type": "error", "name": "OnlyDelegationManager", "inputs": []},
+	{"type": "error", "name": "OnlyEigenPod", "inputs": []},
+	{"type": "error", "name": "OnlyEigenPodManager", "inputs": []},
+	{"type": "error", "name": "OnlyEigenPodOwner", "inputs": []},
+	{"type": "error", "name": "OnlyEigenPodOwnerOrProofSubmitter", "inputs": []},
+	{"type": "error", "name": "SharesNegative", "inputs": []},
+	{"type": "error", "name": "SharesNotMultipleOfGwei", "inputs": []},
+	{"type": "error", "name": "TimestampOutOfRange", "inputs": []},
+	{"type": "error", "name": "ValidatorInactiveOnBeaconChain", "inputs": []},
+	{"type": "error", "name": "ValidatorIsExitingBeaconChain", "inputs": []},
+	{"type": "error", "name": "ValidatorNotActiveInPod", "inputs": []},
+	{"type": "error", "name": "ValidatorNotSlashedOnBeaconChain", "inputs": []},
+	{"type": "error", "name": "WithdrawalCredentialsNotForEigenPod", "inputs": []}
+]`
+
+// explanations of EigenPod reverts, by error name.
+var revertExplanations = map[string]string{
+	"OnlyEigenPodOwner":                   "--sender is not the pod owner",
+	"OnlyEigenPodOwnerOrProofSubmitter":   "--sender is neither the pod owner nor its proof submitter (see `assign-submitter`)",
+	"CurrentlyPaused":                     "this EigenPod function is currently paused by EigenLayer",
+	"NoActiveCheckpoint":                  "the pod has no active checkpoint; it may have completed already",
+	"CheckpointAlreadyActive":             "a checkpoint is already active; complete it with `checkpoint` first",
+	"CannotCheckpointTwiceInSingleBlock":  "a checkpoint was already started in this block",
+	"NoBalanceToCheckpoint":               "the pod has no new native ETH to checkpoint (use `--force` to start one anyway)",
+	"TimestampOutOfRange":                 "timestamp out of 4788 window: the proof's beacon block root is no longer available onchain, regenerate the proof",
+	"BeaconTimestampTooFarInPast":         "the proof predates the pod's latest checkpoint, regenerate it",
+	"CredentialsAlreadyVerified":          "the validator's withdrawal credentials are already verified",
+	"ValidatorIsExitingBeaconChain":       "the validator is exiting, so its withdrawal credentials can't be verified",
+	"ValidatorInactiveOnBeaconChain":      "the validator isn't active on the beacon chain yet, so its withdrawal credentials can't be verified",
+	"WithdrawalCredentialsNotForEigenPod": "the validator's withdrawal credentials point to a different pod",
+	"ValidatorNotActiveInPod":             "the validator isn't active in the pod: its withdrawal credentials aren't verified, or it has exited",
+	"ValidatorNotSlashedOnBeaconChain":    "the validator has not been slashed",
+	"InvalidProof":                        "the proof doesn't match the onchain beacon block root; it may be for the wrong network or too old",
+}
+
+// the require strings of deployments from before the custom errors, by a substring, and the error that replaced them.
+var legacyRevertReasons = []struct {
+	match string
+	name  string
+}{
+	{"not podOwner", "OnlyEigenPodOwner"},
+	{"not pod owner or proof submitter", "OnlyEigenPodOwnerOrProofSubmitter"},
+	{"index is paused", "CurrentlyPaused"},
+	{"must have active checkpoint", "NoActiveCheckpoint"},
+	{"must finish previous checkpoint", "CheckpointAlreadyActive"},
+	{"cannot checkpoint twice in one block", "CannotCheckpointTwiceInSingleBlock"},
+	{"no balance available to checkpoint", "NoBalanceToCheckpoint"},
+	{"timestamp out of range", "TimestampOutOfRange"},
+	{"too far in past", "TimestampOutOfRange"},
+	{"must be after latest checkpoint", "BeaconTimestampTooFarInPast"},
+	{"proof is older than last checkpoint", "BeaconTimestampTooFarInPast"},
+	{"must be inactive to prove withdrawal credentials", "CredentialsAlreadyVerified"},
+	{"must not be exiting", "ValidatorIsExitingBeaconChain"},
+	{"proof is not for this EigenPod", "WithdrawalCredentialsNotForEigenPod"},
+	{"validator must be active", "ValidatorNotActiveInPod"},
+	{"must be slashed to be marked stale", "ValidatorNotSlashedOnBeaconChain"},
+	{"invalid proof", "InvalidProof"},
+}
+
+func legacyRevertName(reason string) string {
+	lower := strings.ToLower(reason)
+	for _, legacy := range legacyRevertReasons {
+		if strings.Contains(lower, strings.ToLower(legacy.match)) {
+			return legacy.name
+		}
+	}
+	return ""
+}
+
+var revertABIs = func() []*abi.ABI {
+	abis := []*abi.ABI{}
+	if parsed, err := abi.JSON(strings.NewReader(eigenPodErrorsABI)); err == nil {
+		abis = append(abis, &parsed)
+	}
+	for _, metadata := range []*bind.MetaData{onchain.EigenPodMetaData, onchain.EigenPodManagerMetaData} {
+		if parsed, err := metadata.GetAbi(); err == nil && parsed != nil {
+			abis = append(abis, parsed)
+		}
+	}
+	return abis
+}()
+
+// decodes `Error(string)`, `Panic(uint256)`, or one of the contracts' custom errors, and returns the reason and
+// error name (see RevertError).
+func decodeRevertData(data []byte) (string, string) {
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason, legacyRevertName(reason)
+	}
+	if len(data) < 4 {
+		return "", ""
+	}
+
+	for _, contract := range revertABIs {
+		for name, customError := range contract.Errors {
+			if string(customError.ID[:4]) != string(data[:4]) {
+				continue
+			}
+			values, err := customError.Inputs.Unpack(data[4:])
+			if err != nil {
+				return name, name
+			}
+			args := make([]string, len(values))
+			for i, value := range values {
+				args[i] = fmt.Sprintf("%v", value)
+			}
+			return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", ")), name
+		}
+	}
+	return "", ""
+}
+
+// DecodeRevert turns an error carrying revert data (e.g from eth_call or eth_estimateGas) into a *RevertError.
+// Any other error is returned as-is.
+func DecodeRevert(err error) error {
+	if err == nil {
+		return nil
+	}
+	var revertErr *RevertError
+	if errors.As(err, &revertErr) {
+		return err
+	}
+
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if encoded, ok := dataErr.ErrorData().(string); ok {
+			if data, decodeErr := hexutil.Decode(encoded); decodeErr == nil {
+				reason, name := decodeRevertData(data)
+				return &RevertError{Reason: reason, Name: name, Explanation: revertExplanations[name], Data: data, err: err}
+			}
+		}
+	}
+
+	// some nodes only include the require string in the message
+	if reason, ok := strings.CutPrefix(err.Error(), "execution reverted: "); ok {
+		name := legacyRevertName(reason)
+		return &RevertError{Reason: reason, Name: name, Explanation: revertExplanations[name], err: err}
+	}
+	return err
+}
+
+// ExplainRevertedTransaction replays a mined, reverted transaction with eth_call (against the state
+// before its block) to recover why it reverted.
+func ExplainRevertedTransaction(ctx context.Context, eth ExecutionClient, txn *types.Transaction, receipt *types.Receipt) error {
+	from, err := types.Sender(types.LatestSignerForChainID(txn.ChainId()), txn)
+	if err != nil {
+		return &RevertError{}
+	}
+
+	block := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+	_, err = eth.CallContract(ctx, ethereum.CallMsg{
+		From:  from,
+		To:    txn.To(),
+		Gas:   txn.Gas(),
+		Value: txn.Value(),
+		Data:  txn.Data(),
+	}, block)
+	if err == nil {
+		// succeeds in isolation, e.g it ran out of gas, or an earlier transaction in the block changed the state.
+		return &RevertError{Explanation: fmt.Sprintf("used %d of %d gas", receipt.GasUsed, txn.Gas())}
+	}
+
+	if revertErr := DecodeRevert(err); errors.As(revertErr, new(*RevertError)) {
+		return revertErr
+	}
+	return &RevertError{err: err}
+}
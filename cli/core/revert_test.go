@@ -0,0 +1,73 @@
+package core
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type testDataError struct {
+	data string
+}
+
+func (e testDataError) Error() string          { return "execution reverted" }
+func (e testDataError) ErrorData() interface{} { return e.data }
+
+func TestDecodeRevert(t *testing.T) {
+	// Error("EigenPod.startCheckpoint: must finish previous checkpoint"), from a deployment without custom errors
+	data := "0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000039" +
+		hexutil.Encode([]byte("EigenPod.startCheckpoint: must finish previous checkpoint"))[2:] + "00000000000000"
+
+	err := DecodeRevert(testDataError{data: data})
+	var revertErr *RevertError
+	if !errors.As(err, &revertErr) {
+		t.Fatalf("expected a RevertError, got %v", err)
+	}
+	if revertErr.Reason != "EigenPod.startCheckpoint: must finish previous checkpoint" {
+		t.Errorf("unexpected reason %q", revertErr.Reason)
+	}
+	if revertErr.Name != "CheckpointAlreadyActive" || revertErr.Explanation != revertExplanations["CheckpointAlreadyActive"] {
+		t.Errorf("unexpected name %q and explanation %q", revertErr.Name, revertErr.Explanation)
+	}
+
+	err = DecodeRevert(errors.New("execution reverted: EigenPod._verifyWithdrawalCredentials: proof is not for this EigenPod"))
+	if !errors.As(err, &revertErr) || revertErr.Explanation != "the validator's withdrawal credentials point to a different pod" {
+		t.Errorf("expected the reason to be recovered from the message, got %v", err)
+	}
+
+	other := errors.New("connection refused")
+	if DecodeRevert(other) != other {
+		t.Error("expected other errors to be returned as-is")
+	}
+}
+
+func TestDecodeRevertCustomErrors(t *testing.T) {
+	for _, test := range []struct {
+		selector string
+		name     string
+	}{
+		{"0xf289ccda", "TimestampOutOfRange"},
+		{"0xd49e19a7", "ValidatorNotActiveInPod"},
+		{"0xbe9bc300", "CheckpointAlreadyActive"},
+		{"0x840a48d5", "CurrentlyPaused"},
+	} {
+		var revertErr *RevertError
+		if err := DecodeRevert(testDataError{data: test.selector}); !errors.As(err, &revertErr) {
+			t.Fatalf("expected a RevertError, got %v", err)
+		}
+		if revertErr.Name != test.name || revertErr.Reason != test.name+"()" {
+			t.Errorf("%s: decoded as %q (%q), want %s", test.selector, revertErr.Name, revertErr.Reason, test.name)
+		}
+		if revertErr.Explanation == "" {
+			t.Errorf("%s: no explanation", test.name)
+		}
+	}
+
+	var revertErr *RevertError
+	if err := DecodeRevert(testDataError{data: "0xdeadbeef"}); !errors.As(err, &revertErr) || revertErr.Name != "" || revertErr.Explanation != "" {
+		t.Errorf("expected an unknown selector to be left undecoded, got %v", err)
+	}
+}
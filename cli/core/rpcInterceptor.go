@@ -0,0 +1,61 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/rpcreplay"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const RPC_INTERCEPTOR_KEY TEigenKey = "com.eigen.rpcInterceptor"
+
+// ContextWithRPCInterceptor routes the node traffic of GetClients through `interceptor`, e.g to record
+// it to a fixture file, or to replay one.
+func ContextWithRPCInterceptor(ctx context.Context, interceptor rpcreplay.Interceptor) context.Context {
+	return context.WithValue(ctx, RPC_INTERCEPTOR_KEY, interceptor)
+}
+
+func GetContextRPCInterceptor(ctx context.Context) rpcreplay.Interceptor {
+	interceptor, ok := ctx.Value(RPC_INTERCEPTOR_KEY).(rpcreplay.Interceptor)
+	if !ok {
+		return nil
+	}
+	return interceptor
+}
+
+// DialExecutionClient dials `node`, through the context's RPC interceptor if one is set.
+func DialExecutionClient(ctx context.Context, node string) (*ethclient.Client, error) {
+	interceptor := GetContextRPCInterceptor(ctx)
+	if interceptor == nil {
+		return ethclient.Dial(node)
+	}
+
+	client, err := rpc.DialOptions(ctx, node, rpc.WithHTTPClient(&http.Client{Transport: interceptor.ExecutionTransport()}))
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(client), nil
+}
+
+// Returns the uri of a local server that intercepts traffic to `beaconUri`, and stops the server. era archives are
+// read from disk, and are left as-is.
+func interceptBeaconUri(ctx context.Context, beaconUri string) (string, func() error, error) {
+	interceptor := GetContextRPCInterceptor(ctx)
+	if interceptor == nil || strings.HasPrefix(beaconUri, ERA_SCHEME) {
+		return beaconUri, func() error { return nil }, nil
+	}
+
+	handler, err := interceptor.BeaconHandler(beaconUri)
+	if err != nil {
+		return "", nil, err
+	}
+	uri, stop, err := rpcreplay.Serve(handler)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to intercept beacon node: %w", err)
+	}
+	return uri, stop, nil
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beaconmock"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacontest"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/rpcreplay"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+)
+
+// answers eth_chainId for holesky
+func fakeExecutionNode() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Method != "eth_chainId" {
+			http.Error(w, "unsupported", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(request.ID) + `,"result":"0x4268"}`))
+	}))
+}
+
+func TestRecordAndReplayClients(t *testing.T) {
+	fixture, err := beacontest.NewStateBuilder(64).AddValidators(4, beacontest.Validator{}).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := beaconmock.NewFixtures()
+	assert.Nil(t, fixtures.AddState(fixture.State))
+
+	execNode := fakeExecutionNode()
+	mock, err := beaconmock.New(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	beaconNode := httptest.NewServer(mock)
+
+	run := func(ctx context.Context) (uint64, phase0.Root, phase0.Gwei) {
+		eth, beaconClient, chainId, err := GetClients(ctx, execNode.URL, beaconNode.URL, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer eth.Close()
+
+		header, err := beaconClient.GetBeaconHeader(ctx, "head")
+		if err != nil {
+			t.Fatal(err)
+		}
+		validator, err := beaconClient.GetValidator(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return chainId.Uint64(), header.Header.Message.StateRoot, validator.Balance
+	}
+
+	path := filepath.Join(t.TempDir(), "fixture.jsonl")
+	recorder, err := rpcreplay.NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainId, stateRoot, balance := run(ContextWithRPCInterceptor(context.Background(), recorder))
+	assert.Nil(t, recorder.Close())
+
+	assert.Equal(t, uint64(17000), chainId)
+	assert.Equal(t, fixture.StateRoot, stateRoot)
+
+	// the nodes are gone, everything must come from the recording
+	execNode.Close()
+	beaconNode.Close()
+
+	replayer, err := rpcreplay.LoadReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayedChainId, replayedStateRoot, replayedBalance := run(ContextWithRPCInterceptor(context.Background(), replayer))
+	assert.Equal(t, chainId, replayedChainId)
+	assert.Equal(t, stateRoot, replayedStateRoot)
+	assert.Equal(t, balance, replayedBalance)
+}
+
+func TestGetClientsReleasesInterceptor(t *testing.T) {
+	fixture, err := beacontest.NewStateBuilder(64).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := beaconmock.NewFixtures()
+	assert.Nil(t, fixtures.AddState(fixture.State))
+
+	execNode := fakeExecutionNode()
+	defer execNode.Close()
+	mock, err := beaconmock.New(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	beaconNode := httptest.NewServer(mock)
+	defer beaconNode.Close()
+
+	recorder, err := rpcreplay.NewRecorder(filepath.Join(t.TempDir(), "fixture.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recorder.Close()
+
+	resources := &Resources{}
+	ctx := ContextWithResources(ContextWithRPCInterceptor(context.Background(), recorder), resources)
+	_, beaconClient, _, err := GetClients(ctx, execNode.URL, beaconNode.URL, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := beaconClient.GetBeaconHeader(ctx, "head"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the server intercepting beacon traffic is stopped
+	assert.Nil(t, resources.Close())
+	if _, err := beaconClient.GetBeaconHeader(ctx, "head"); err == nil {
+		t.Error("expected the beacon client to be closed")
+	}
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SafeBatch is a Safe{Wallet} Transaction Builder batch file. Loaded into the Transaction Builder app,
+// its transactions are proposed to the Safe as one (MultiSend) transaction, executed in order.
+type SafeBatch struct {
+	Version      string            `json:"version"`
+	ChainId      string            `json:"chainId"`
+	CreatedAt    int64             `json:"createdAt"`
+	Meta         SafeBatchMeta     `json:"meta"`
+	Transactions []SafeTransaction `json:"transactions"`
+}
+
+type SafeBatchMeta struct {
+	Name                   string `json:"name"`
+	Description            string `json:"description"`
+	TxBuilderVersion       string `json:"txBuilderVersion"`
+	CreatedFromSafeAddress string `json:"createdFromSafeAddress"`
+}
+
+// SafeTransaction is one call of a batch. Only the raw calldata is given, so the Transaction Builder shows
+// it as a custom transaction.
+type SafeTransaction struct {
+	To                   string `json:"to"`
+	Value                string `json:"value"`
+	Data                 string `json:"data"`
+	ContractMethod       any    `json:"contractMethod"`
+	ContractInputsValues any    `json:"contractInputsValues"`
+}
+
+// NewSafeBatch packages transactions built for `safeAddress` (see SafeSender) as a batch.
+func NewSafeBatch(chainId *big.Int, safeAddress common.Address, name, description string, txns []*types.Transaction) *SafeBatch {
+	transactions := make([]SafeTransaction, len(txns))
+	for i, txn := range txns {
+		transactions[i] = SafeTransaction{
+			To:    txn.To().Hex(),
+			Value: txn.Value().String(),
+			Data:  "0x" + common.Bytes2Hex(txn.Data()),
+		}
+	}
+
+	return &SafeBatch{
+		Version:   "1.0",
+		ChainId:   chainId.String(),
+		CreatedAt: time.Now().UnixMilli(),
+		Meta: SafeBatchMeta{
+			Name:                   name,
+			Description:            description,
+			TxBuilderVersion:       "1.16.5",
+			CreatedFromSafeAddress: safeAddress.Hex(),
+		},
+		Transactions: transactions,
+	}
+}
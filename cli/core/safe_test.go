@@ -0,0 +1,50 @@
+package core
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestNewSafeBatch(t *testing.T) {
+	safe := common.HexToAddress("0x00000000000000000000000000000000000005af")
+	pod := common.HexToAddress("0x000000000000000000000000000000000000b0d5")
+	txns := []*types.Transaction{
+		types.NewTx(&types.DynamicFeeTx{To: &pod, Data: []byte{0x01, 0x02}}),
+		types.NewTx(&types.DynamicFeeTx{To: &pod, Value: big.NewInt(7), Data: []byte{0x03}}),
+	}
+
+	batch := NewSafeBatch(big.NewInt(17000), safe, "Checkpoint proofs", "desc", txns)
+	out, err := json.Marshal(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := map[string]any{}
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if parsed["chainId"] != "17000" {
+		t.Errorf("chainId is %v, want \"17000\"", parsed["chainId"])
+	}
+	meta := parsed["meta"].(map[string]any)
+	if meta["createdFromSafeAddress"] != safe.Hex() {
+		t.Errorf("createdFromSafeAddress is %v, want %s", meta["createdFromSafeAddress"], safe.Hex())
+	}
+
+	transactions := parsed["transactions"].([]any)
+	if len(transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(transactions))
+	}
+	second := transactions[1].(map[string]any)
+	if second["to"] != pod.Hex() || second["value"] != "7" || second["data"] != "0x03" {
+		t.Errorf("unexpected transaction %v", second)
+	}
+	// the Transaction Builder expects these to be present, as null, for raw calldata.
+	if v, ok := second["contractMethod"]; !ok || v != nil {
+		t.Errorf("contractMethod is %v, want null", v)
+	}
+}
@@ -0,0 +1,395 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const SIGNER_CONFIG_KEY TEigenKey = "com.eigen.signerConfig"
+const SIGNER_KEY TEigenKey = "com.eigen.signer"
+
+// `--sender` schemes, besides a plain hex private key.
+const (
+	// a hex private key in an environment variable, e.g `env:POD_OWNER_KEY`
+	SENDER_ENV_SCHEME = "env:"
+	// a hex private key in a file
+	SENDER_FILE_SCHEME = "file:"
+	// a geth-style encrypted JSON keystore
+	SENDER_KEYSTORE_SCHEME = "keystore:"
+	// a Safe multisig. Its transactions can only be built (and exported as a Safe batch), not signed.
+	SENDER_SAFE_SCHEME = "safe:"
+	// the Signer in the context (see ContextWithSigner), for programs that sign transactions themselves.
+	SENDER_SIGNER_SCHEME = "signer:"
+)
+
+// SafeSender is the `--sender` for transactions exported for the Safe at `safeAddress`.
+func SafeSender(safeAddress string) string {
+	return SENDER_SAFE_SCHEME + safeAddress
+}
+
+// SignerSender is the sender for transactions signed by `signer`, which must be in the context they're sent with
+// (see ContextWithSigner).
+func SignerSender(signer Signer) string {
+	return SENDER_SIGNER_SCHEME + signer.Address().Hex()
+}
+
+type SignerConfig struct {
+	// Where to read the keystore passphrase from. If empty, it's prompted for.
+	PasswordFile string
+}
+
+func ContextWithSignerConfig(ctx context.Context, config SignerConfig) context.Context {
+	return context.WithValue(ctx, SIGNER_CONFIG_KEY, config)
+}
+
+func GetContextSignerConfig(ctx context.Context) SignerConfig {
+	config, ok := ctx.Value(SIGNER_CONFIG_KEY).(SignerConfig)
+	if !ok {
+		return SignerConfig{}
+	}
+	return config
+}
+
+// Signer signs transactions for one account.
+type Signer interface {
+	Address() common.Address
+	SignTx(ctx context.Context, txn *types.Transaction, chainId *big.Int) (*types.Transaction, error)
+}
+
+// ContextWithSigner sets the signer a SignerSender sender signs with.
+func ContextWithSigner(ctx context.Context, signer Signer) context.Context {
+	return context.WithValue(ctx, SIGNER_KEY, signer)
+}
+
+func GetContextSigner(ctx context.Context) Signer {
+	signer, _ := ctx.Value(SIGNER_KEY).(Signer)
+	return signer
+}
+
+// the context's signer, if it's the one `sender` (a SignerSender) names.
+func contextSigner(ctx context.Context, sender string) (Signer, error) {
+	address := strings.TrimPrefix(sender, SENDER_SIGNER_SCHEME)
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid signer address %s", address)
+	}
+	signer := GetContextSigner(ctx)
+	if signer == nil {
+		return nil, fmt.Errorf("no signer for %s (see ContextWithSigner)", address)
+	}
+	if signer.Address() != common.HexToAddress(address) {
+		return nil, fmt.Errorf("the signer is for %s, not %s", signer.Address().Hex(), address)
+	}
+	return signer, nil
+}
+
+type localSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s *localSigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *localSigner) SignTx(_ context.Context, txn *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	return types.SignTx(txn, types.LatestSignerForChainID(chainId), s.key)
+}
+
+// remoteSigner signs with `eth_signTransaction` on a JSON-RPC signer, e.g Web3Signer or Clef.
+type remoteSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+func (s *remoteSigner) Address() common.Address {
+	return s.address
+}
+
+// the transaction, as eth_signTransaction takes it
+type signTxArgs struct {
+	From                 common.Address  `json:"from"`
+	To                   *common.Address `json:"to,omitempty"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big    `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big    `json:"value"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	Data                 hexutil.Bytes   `json:"data"`
+	ChainId              *hexutil.Big    `json:"chainId"`
+}
+
+func (s *remoteSigner) SignTx(ctx context.Context, txn *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	args := signTxArgs{
+		From:    s.address,
+		To:      txn.To(),
+		Gas:     hexutil.Uint64(txn.Gas()),
+		Value:   (*hexutil.Big)(txn.Value()),
+		Nonce:   hexutil.Uint64(txn.Nonce()),
+		Data:    txn.Data(),
+		ChainId: (*hexutil.Big)(chainId),
+	}
+	if txn.Type() == types.LegacyTxType {
+		args.GasPrice = (*hexutil.Big)(txn.GasPrice())
+	} else {
+		args.MaxFeePerGas = (*hexutil.Big)(txn.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(txn.GasTipCap())
+	}
+
+	// Web3Signer returns the raw transaction; Clef returns it as `{raw, tx}`.
+	var result json.RawMessage
+	if err := s.client.CallContext(ctx, &result, "eth_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("remote signer failed to sign: %w", err)
+	}
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(result, &raw); err != nil {
+		var response struct {
+			Raw hexutil.Bytes `json:"raw"`
+		}
+		if err := json.Unmarshal(result, &response); err != nil {
+			return nil, fmt.Errorf("unexpected response from remote signer: %s", string(result))
+		}
+		raw = response.Raw
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid transaction: %w", err)
+	}
+	// make sure the signer signed what it was asked to
+	if field := differingField(txn, signed, chainId); field != "" {
+		return nil, fmt.Errorf("remote signer returned a different transaction than the one it was asked to sign (its %s differs)", field)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(chainId), signed)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer returned an invalid signature: %w", err)
+	}
+	if sender != s.address {
+		return nil, fmt.Errorf("remote signer signed as %s, not %s", sender.Hex(), s.address.Hex())
+	}
+	return signed, nil
+}
+
+// the first field `signed` doesn't have as `requested` does, or "" if it's the same transaction.
+func differingField(requested, signed *types.Transaction, chainId *big.Int) string {
+	sameTo := (requested.To() == nil && signed.To() == nil) ||
+		(requested.To() != nil && signed.To() != nil && *requested.To() == *signed.To())
+	switch {
+	case signed.Type() != requested.Type():
+		return "type"
+	case signed.ChainId().Cmp(chainId) != 0:
+		return "chain id"
+	case signed.Nonce() != requested.Nonce():
+		return "nonce"
+	case !sameTo:
+		return "recipient"
+	case signed.Value().Cmp(requested.Value()) != 0:
+		return "value"
+	case signed.Gas() != requested.Gas():
+		return "gas limit"
+	// a legacy transaction's gas price is both its fee cap and its tip cap
+	case signed.GasFeeCap().Cmp(requested.GasFeeCap()) != 0:
+		return "max fee per gas"
+	case signed.GasTipCap().Cmp(requested.GasTipCap()) != 0:
+		return "max priority fee per gas"
+	case !bytes.Equal(signed.Data(), requested.Data()):
+		return "data"
+	}
+	return ""
+}
+
+func isRemoteSigner(sender string) bool {
+	return strings.HasPrefix(sender, "http://") || strings.HasPrefix(sender, "https://")
+}
+
+// signers are cached by `--sender`, so a keystore's passphrase is only asked for once.
+var (
+	signersMu sync.Mutex
+	signers   = map[string]Signer{}
+)
+
+// LoadSigner returns the signer for a `--sender` value, which is one of:
+//
+//	<hex private key>
+//	env:<VAR>          a hex private key in an environment variable
+//	file:<path>        a hex private key in a file
+//	keystore:<path>    an encrypted JSON keystore. The passphrase is read from `--passwordFile`, or prompted for.
+//	http(s)://<url>    a remote signer. Append `#<address>` to pick one of its accounts.
+//	signer:<address>   the Signer in the context (see SignerSender)
+func LoadSigner(ctx context.Context, sender string) (Signer, error) {
+	if strings.HasPrefix(sender, SENDER_SIGNER_SCHEME) {
+		// not cached: it's the caller's, and may differ between contexts
+		return contextSigner(ctx, sender)
+	}
+
+	signersMu.Lock()
+	defer signersMu.Unlock()
+
+	if signer, ok := signers[sender]; ok {
+		return signer, nil
+	}
+	signer, err := loadSigner(ctx, sender)
+	if err != nil {
+		return nil, err
+	}
+	signers[sender] = signer
+	return signer, nil
+}
+
+func loadSigner(ctx context.Context, sender string) (Signer, error) {
+	switch {
+	case strings.HasPrefix(sender, SENDER_SAFE_SCHEME):
+		return nil, errors.New("a Safe can't sign transactions; export them as a Safe batch with --safe instead")
+	case isRemoteSigner(sender):
+		return dialRemoteSigner(ctx, sender)
+	case strings.HasPrefix(sender, SENDER_KEYSTORE_SCHEME):
+		path := strings.TrimPrefix(sender, SENDER_KEYSTORE_SCHEME)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore: %w", err)
+		}
+		passphrase, err := keystorePassphrase(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := keystore.DecryptKey(data, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt keystore %s: %w", path, err)
+		}
+		return &localSigner{key: key.PrivateKey}, nil
+	default:
+		hexKey, err := senderPrivateKey(sender)
+		if err != nil {
+			return nil, err
+		}
+		key, err := crypto.HexToECDSA(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		return &localSigner{key: key}, nil
+	}
+}
+
+// the hex private key `sender` is or points to.
+func senderPrivateKey(sender string) (string, error) {
+	var key string
+	switch {
+	case strings.HasPrefix(sender, SENDER_ENV_SCHEME):
+		name := strings.TrimPrefix(sender, SENDER_ENV_SCHEME)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		key = value
+	case strings.HasPrefix(sender, SENDER_FILE_SCHEME):
+		data, err := os.ReadFile(strings.TrimPrefix(sender, SENDER_FILE_SCHEME))
+		if err != nil {
+			return "", fmt.Errorf("failed to read private key: %w", err)
+		}
+		key = string(data)
+	default:
+		key = sender
+	}
+	return strings.TrimPrefix(strings.TrimSpace(key), "0x"), nil
+}
+
+// SenderAddress returns the address of a `--sender`, without needing its secret (e.g for simulating
+// transactions). Keystores are read for their address, and remote signers are asked for their accounts.
+func SenderAddress(ctx context.Context, sender string) (common.Address, error) {
+	switch {
+	case strings.HasPrefix(sender, SENDER_SAFE_SCHEME):
+		address := strings.TrimPrefix(sender, SENDER_SAFE_SCHEME)
+		if !common.IsHexAddress(address) {
+			return common.Address{}, fmt.Errorf("invalid Safe address %s", address)
+		}
+		return common.HexToAddress(address), nil
+	case strings.HasPrefix(sender, SENDER_SIGNER_SCHEME):
+		address := strings.TrimPrefix(sender, SENDER_SIGNER_SCHEME)
+		if !common.IsHexAddress(address) {
+			return common.Address{}, fmt.Errorf("invalid signer address %s", address)
+		}
+		return common.HexToAddress(address), nil
+	case isRemoteSigner(sender):
+		signer, err := LoadSigner(ctx, sender)
+		if err != nil {
+			return common.Address{}, err
+		}
+		return signer.Address(), nil
+	case strings.HasPrefix(sender, SENDER_KEYSTORE_SCHEME):
+		data, err := os.ReadFile(strings.TrimPrefix(sender, SENDER_KEYSTORE_SCHEME))
+		if err != nil {
+			return common.Address{}, fmt.Errorf("failed to read keystore: %w", err)
+		}
+		var key struct {
+			Address string `json:"address"`
+		}
+		if err := json.Unmarshal(data, &key); err != nil || !common.IsHexAddress(key.Address) {
+			return common.Address{}, errors.New("keystore has no address")
+		}
+		return common.HexToAddress(key.Address), nil
+	default:
+		signer, err := LoadSigner(ctx, sender)
+		if err != nil {
+			return common.Address{}, err
+		}
+		return signer.Address(), nil
+	}
+}
+
+func dialRemoteSigner(ctx context.Context, sender string) (Signer, error) {
+	url, account, _ := strings.Cut(sender, "#")
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach remote signer: %w", err)
+	}
+
+	var accounts []common.Address
+	if err := client.CallContext(ctx, &accounts, "eth_accounts"); err != nil {
+		return nil, fmt.Errorf("failed to list remote signer accounts: %w", err)
+	}
+	if account == "" {
+		if len(accounts) != 1 {
+			return nil, fmt.Errorf("remote signer has %d accounts; pick one with %s#<address>", len(accounts), url)
+		}
+		return &remoteSigner{client: client, address: accounts[0]}, nil
+	}
+
+	if !common.IsHexAddress(account) {
+		return nil, fmt.Errorf("invalid remote signer account %s", account)
+	}
+	for _, candidate := range accounts {
+		if candidate == common.HexToAddress(account) {
+			return &remoteSigner{client: client, address: candidate}, nil
+		}
+	}
+	return nil, fmt.Errorf("remote signer has no account %s", account)
+}
+
+func keystorePassphrase(ctx context.Context, path string) (string, error) {
+	if passwordFile := GetContextSignerConfig(ctx).PasswordFile; passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	if prompt := GetContextInteraction(ctx).Passphrase; prompt != nil {
+		return prompt(path)
+	}
+	return "", fmt.Errorf("no passphrase for keystore %s: set a password file", path)
+}
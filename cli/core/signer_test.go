@@ -0,0 +1,235 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// a stand-in for Web3Signer: signs whatever it's asked to with one key (after `tamper`ing with it, if set).
+type fakeRemoteSigner struct {
+	key    *ecdsa.PrivateKey
+	tamper func(txn *types.DynamicFeeTx)
+}
+
+func (s *fakeRemoteSigner) Accounts() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *fakeRemoteSigner) SignTransaction(args signTxArgs) (hexutil.Bytes, error) {
+	txn := &types.DynamicFeeTx{
+		ChainID:   args.ChainId.ToInt(),
+		Nonce:     uint64(args.Nonce),
+		GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+		GasFeeCap: args.MaxFeePerGas.ToInt(),
+		Gas:       uint64(args.Gas),
+		To:        args.To,
+		Value:     args.Value.ToInt(),
+		Data:      args.Data,
+	}
+	if s.tamper != nil {
+		s.tamper(txn)
+	}
+	signed, err := types.SignTx(types.NewTx(txn), types.LatestSignerForChainID(txn.ChainID), s.key)
+	if err != nil {
+		return nil, err
+	}
+	return signed.MarshalBinary()
+}
+
+func testTransaction() *types.Transaction {
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1337),
+		Nonce:     3,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(30e9),
+		Gas:       100_000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0x12, 0x34},
+	})
+}
+
+func assertSignedBy(t *testing.T, owner *Owner, address common.Address) {
+	t.Helper()
+
+	if owner.FromAddress != address {
+		t.Fatalf("expected sender %s, got %s", address.Hex(), owner.FromAddress.Hex())
+	}
+	signed, err := owner.TransactionOptions.Signer(owner.FromAddress, testTransaction())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), signed)
+	if err != nil || sender != address {
+		t.Fatalf("expected the transaction to be signed by %s, got %s (%v)", address.Hex(), sender.Hex(), err)
+	}
+}
+
+func TestPrepareAccountWithRemoteSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &fakeRemoteSigner{key: key}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(server.Stop)
+
+	ctx := context.Background()
+	sender := httpServer.URL
+	owner, err := PrepareAccount(ctx, &sender, big.NewInt(1337), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSignedBy(t, owner, crypto.PubkeyToAddress(key.PublicKey))
+
+	unknown := httpServer.URL + "#0x000000000000000000000000000000000000dEaD"
+	if _, err := PrepareAccount(ctx, &unknown, big.NewInt(1337), false); err == nil {
+		t.Error("expected an error for an account the signer doesn't have")
+	}
+}
+
+func TestRemoteSignerMustSignWhatItWasAsked(t *testing.T) {
+	other := common.HexToAddress("0x000000000000000000000000000000000000bEEF")
+	for field, tamper := range map[string]func(txn *types.DynamicFeeTx){
+		"chain id":                 func(txn *types.DynamicFeeTx) { txn.ChainID = big.NewInt(1) },
+		"nonce":                    func(txn *types.DynamicFeeTx) { txn.Nonce++ },
+		"recipient":                func(txn *types.DynamicFeeTx) { txn.To = &other },
+		"value":                    func(txn *types.DynamicFeeTx) { txn.Value = big.NewInt(1) },
+		"gas limit":                func(txn *types.DynamicFeeTx) { txn.Gas++ },
+		"max fee per gas":          func(txn *types.DynamicFeeTx) { txn.GasFeeCap = big.NewInt(300e9) },
+		"max priority fee per gas": func(txn *types.DynamicFeeTx) { txn.GasTipCap = big.NewInt(30e9) },
+		"data":                     func(txn *types.DynamicFeeTx) { txn.Data = []byte{0x56} },
+	} {
+		key, _ := crypto.GenerateKey()
+		server := rpc.NewServer()
+		if err := server.RegisterName("eth", &fakeRemoteSigner{key: key, tamper: tamper}); err != nil {
+			t.Fatal(err)
+		}
+		httpServer := httptest.NewServer(server)
+		t.Cleanup(httpServer.Close)
+		t.Cleanup(server.Stop)
+
+		sender := httpServer.URL
+		owner, err := PrepareAccount(context.Background(), &sender, big.NewInt(1337), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = owner.TransactionOptions.Signer(owner.FromAddress, testTransaction())
+		if err == nil || !strings.Contains(err.Error(), "its "+field+" differs") {
+			t.Errorf("expected a transaction with a different %s to be rejected, got %v", field, err)
+		}
+	}
+}
+
+func TestPrepareAccountWithKeystore(t *testing.T) {
+	dir := t.TempDir()
+
+	account, err := keystore.StoreKey(dir, "hunter2", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwordPath := filepath.Join(dir, "password")
+	if err := os.WriteFile(passwordPath, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ContextWithSignerConfig(context.Background(), SignerConfig{PasswordFile: passwordPath})
+	sender := SENDER_KEYSTORE_SCHEME + account.URL.Path
+
+	// simulating only needs the address
+	owner, err := PrepareAccount(context.Background(), &sender, big.NewInt(1337), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner.FromAddress != account.Address {
+		t.Errorf("unexpected address %s", owner.FromAddress.Hex())
+	}
+
+	owner, err = PrepareAccount(ctx, &sender, big.NewInt(1337), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSignedBy(t, owner, account.Address)
+}
+
+func TestKeystorePassphraseFromInteraction(t *testing.T) {
+	dir := t.TempDir()
+
+	account, err := keystore.StoreKey(dir, "hunter2", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := SENDER_KEYSTORE_SCHEME + account.URL.Path
+
+	// without a password file or an Interaction, there's no one to ask
+	if _, err := PrepareAccount(context.Background(), &sender, big.NewInt(1337), false); err == nil {
+		t.Fatal("expected an error without a passphrase")
+	}
+
+	ctx := ContextWithInteraction(context.Background(), &Interaction{
+		Passphrase: func(path string) (string, error) { return "hunter2", nil },
+	})
+	owner, err := PrepareAccount(ctx, &sender, big.NewInt(1337), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSignedBy(t, owner, account.Address)
+
+	if err := askConsent(ctx, "go ahead?"); !errors.Is(err, ErrNoConsent) {
+		t.Errorf("expected ErrNoConsent without a Consent callback, got %v", err)
+	}
+}
+
+func TestPrepareAccountWithEnvKey(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	t.Setenv("TEST_POD_OWNER_KEY", "0x"+common.Bytes2Hex(crypto.FromECDSA(key)))
+
+	sender := SENDER_ENV_SCHEME + "TEST_POD_OWNER_KEY"
+	owner, err := PrepareAccount(context.Background(), &sender, big.NewInt(1337), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSignedBy(t, owner, crypto.PubkeyToAddress(key.PublicKey))
+}
+
+func TestPrepareAccountWithContextSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := &localSigner{key: key}
+	sender := SignerSender(signer)
+
+	if _, err := PrepareAccount(context.Background(), &sender, big.NewInt(1337), false); err == nil {
+		t.Fatal("expected an error without a signer in the context")
+	}
+	other, _ := crypto.GenerateKey()
+	if _, err := PrepareAccount(ContextWithSigner(context.Background(), &localSigner{key: other}), &sender, big.NewInt(1337), false); err == nil {
+		t.Fatal("expected an error for another account's signer")
+	}
+
+	owner, err := PrepareAccount(ContextWithSigner(context.Background(), signer), &sender, big.NewInt(1337), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSignedBy(t, owner, signer.Address())
+
+	// simulating only needs the address
+	address, err := SenderAddress(context.Background(), sender)
+	if err != nil || address != signer.Address() {
+		t.Errorf("unexpected address %s (%v)", address.Hex(), err)
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum/common"
 	gethCommon "github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 type Checkpoint struct {
@@ -57,13 +56,6 @@ type EigenpodStatus struct {
 	MustForceCheckpoint bool
 }
 
-func getRegularBalancesGwei(allValidators []ValidatorWithIndex, state *spec.VersionedBeaconState) []phase0.Gwei {
-	validatorBalances, err := state.ValidatorBalances()
-	PanicOnError("failed to load validator balances", err)
-
-	return validatorBalances
-}
-
 func sumActiveValidatorBeaconBalancesGwei(allValidators []ValidatorWithIndex, allBalances []phase0.Gwei, state *spec.VersionedBeaconState) phase0.Gwei {
 	var sumGwei phase0.Gwei = 0
 
@@ -75,10 +67,10 @@ func sumActiveValidatorBeaconBalancesGwei(allValidators []ValidatorWithIndex, al
 	return sumGwei
 }
 
-func sumRestakedBalancesGwei(eth *ethclient.Client, eigenpodAddress string, activeValidators []ValidatorWithIndex) (phase0.Gwei, error) {
+func sumRestakedBalancesGwei(ctx context.Context, eth ExecutionClient, eigenpodAddress string, activeValidators []ValidatorWithIndex) (phase0.Gwei, error) {
 	var sumGwei phase0.Gwei = 0
 
-	validatorInfos, err := GetOnchainValidatorInfo(eth, eigenpodAddress, activeValidators)
+	validatorInfos, err := GetOnchainValidatorInfo(ctx, eth, eigenpodAddress, activeValidators)
 	if err != nil {
 		return 0, err
 	}
@@ -92,43 +84,68 @@ func sumRestakedBalancesGwei(eth *ethclient.Client, eigenpodAddress string, acti
 	return sumGwei, nil
 }
 
-func GetStatus(ctx context.Context, eigenpodAddress string, eth *ethclient.Client, beaconClient BeaconClient) EigenpodStatus {
+func GetStatus(ctx context.Context, eigenpodAddress string, eth ExecutionClient, beaconClient BeaconClient) (EigenpodStatus, error) {
+	// Fetch the beacon state associated with the checkpoint (or "head" if there is no checkpoint)
+	checkpointTimestamp, state, err := GetCheckpointTimestampAndBeaconState(ctx, eigenpodAddress, eth, beaconClient)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to fetch checkpoint and beacon state: %w", err)
+	}
+	return GetStatusAtState(ctx, eigenpodAddress, eth, checkpointTimestamp, state, nil)
+}
+
+// GetStatusAtState computes the pod's status against `state`, which must be the state at the pod's current
+// checkpoint (or the head state, if there's no checkpoint). `index` is the state's ValidatorIndex, or nil.
+func GetStatusAtState(ctx context.Context, eigenpodAddress string, eth ExecutionClient, checkpointTimestamp uint64, state *spec.VersionedBeaconState, index *ValidatorIndex) (EigenpodStatus, error) {
 	validators := map[string]Validator{}
 	var activeCheckpoint *Checkpoint = nil
 
 	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
-	PanicOnError("failed to reach eigenpod", err)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to reach eigenpod: %w", err)
+	}
 
 	checkpoint, err := eigenPod.CurrentCheckpoint(nil)
-	PanicOnError("failed to fetch checkpoint information", err)
-
-	// Fetch the beacon state associated with the checkpoint (or "head" if there is no checkpoint)
-	checkpointTimestamp, state, err := GetCheckpointTimestampAndBeaconState(ctx, eigenpodAddress, eth, beaconClient)
-	PanicOnError("failed to fetch checkpoint and beacon state", err)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to fetch checkpoint information: %w", err)
+	}
 
-	allValidators, err := FindAllValidatorsForEigenpod(eigenpodAddress, state)
-	PanicOnError("failed to find validators", err)
+	allValidators, err := FindAllValidatorsForEigenpod(eigenpodAddress, state, index)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to find validators: %w", err)
+	}
 
-	allBeaconBalances := getRegularBalancesGwei(allValidators, state)
+	allBeaconBalances, err := state.ValidatorBalances()
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to load validator balances: %w", err)
+	}
 
-	activeValidators, err := SelectActiveValidators(eth, eigenpodAddress, allValidators)
-	PanicOnError("failed to find active validators", err)
+	activeValidators, err := SelectActiveValidators(ctx, eth, eigenpodAddress, allValidators)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to find active validators: %w", err)
+	}
 
-	checkpointableValidators, err := SelectCheckpointableValidators(eth, eigenpodAddress, allValidators, checkpointTimestamp)
-	PanicOnError("failed to find checkpointable validators", err)
+	checkpointableValidators, err := SelectCheckpointableValidators(ctx, eth, eigenpodAddress, allValidators, checkpointTimestamp)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to find checkpointable validators: %w", err)
+	}
 
 	sumBeaconBalancesGwei := new(big.Float).SetUint64(uint64(sumActiveValidatorBeaconBalancesGwei(activeValidators, allBeaconBalances, state)))
 
-	sumRestakedBalancesU64, err := sumRestakedBalancesGwei(eth, eigenpodAddress, activeValidators)
-	PanicOnError("failed to calculate sum of onchain validator balances", err)
+	sumRestakedBalancesU64, err := sumRestakedBalancesGwei(ctx, eth, eigenpodAddress, activeValidators)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to calculate sum of onchain validator balances: %w", err)
+	}
 	sumRestakedBalancesGwei := new(big.Float).SetUint64(uint64(sumRestakedBalancesU64))
 
+	validatorInfos, err := GetOnchainValidatorInfo(ctx, eth, eigenpodAddress, allValidators)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to fetch validator info: %w", err)
+	}
+
 	for i := 0; i < len(allValidators); i++ {
 		validator := allValidators[i].Validator
 		validatorIndex := allValidators[i].Index
-
-		validatorInfo, err := eigenPod.ValidatorPubkeyToInfo(nil, validator.PublicKey[:])
-		PanicOnError("failed to fetch validator info", err)
+		validatorInfo := validatorInfos[i]
 
 		validators[fmt.Sprintf("%d", validatorIndex)] = Validator{
 			Index:                               validatorIndex,
@@ -143,25 +160,37 @@ func GetStatus(ctx context.Context, eigenpodAddress string, eth *ethclient.Clien
 	}
 
 	eigenpodManagerContractAddress, err := eigenPod.EigenPodManager(nil)
-	PanicOnError("failed to get manager address", err)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to get manager address: %w", err)
+	}
 
 	eigenPodManager, err := onchain.NewEigenPodManager(eigenpodManagerContractAddress, eth)
-	PanicOnError("failed to get manager instance", err)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to get manager instance: %w", err)
+	}
 
 	eigenPodOwner, err := eigenPod.PodOwner(nil)
-	PanicOnError("failed to get eigenpod owner", err)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to get eigenpod owner: %w", err)
+	}
 
 	proofSubmitter, err := eigenPod.ProofSubmitter(nil)
-	PanicOnError("failed to get eigenpod proof submitter", err)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to get eigenpod proof submitter: %w", err)
+	}
 
 	currentOwnerShares, err := eigenPodManager.PodOwnerShares(nil, eigenPodOwner)
 	// currentOwnerShares = big.NewInt(0)
-	PanicOnError("failed to load pod owner shares", err)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to load pod owner shares: %w", err)
+	}
 	currentOwnerSharesETH := IweiToEther(currentOwnerShares)
 	currentOwnerSharesGwei := WeiToGwei(currentOwnerShares)
 
 	withdrawableRestakedExecutionLayerGwei, err := eigenPod.WithdrawableRestakedExecutionLayerGwei(nil)
-	PanicOnError("failed to fetch withdrawableRestakedExecutionLayerGwei", err)
+	if err != nil {
+		return EigenpodStatus{}, fmt.Errorf("failed to fetch withdrawableRestakedExecutionLayerGwei: %w", err)
+	}
 
 	// Estimate the total shares we'll have if we complete an existing checkpoint
 	// (or start a new one and complete that).
@@ -186,7 +215,9 @@ func GetStatus(ctx context.Context, eigenpodAddress string, eth *ethclient.Clien
 		}
 	} else {
 		latestPodBalanceWei, err := eth.BalanceAt(ctx, common.HexToAddress(eigenpodAddress), nil)
-		PanicOnError("failed to fetch pod balance", err)
+		if err != nil {
+			return EigenpodStatus{}, fmt.Errorf("failed to fetch pod balance: %w", err)
+		}
 		latestPodBalanceGwei := WeiToGwei(latestPodBalanceWei)
 
 		// We don't have a checkpoint currently, so we need to calculate what
@@ -235,5 +266,5 @@ func GetStatus(ctx context.Context, eigenpodAddress string, eth *ethclient.Clien
 		PodOwner:                       eigenPodOwner,
 		ProofSubmitter:                 proofSubmitter,
 		MustForceCheckpoint:            mustForceCheckpoint,
-	}
+	}, nil
 }
@@ -4,9 +4,16 @@ import (
 	"context"
 )
 
+// TracerCallbacks are told when core starts and ends sections of work (e.g `pepe::proof::checkpoint::submit`).
 type TracerCallbacks struct {
 	OnStartSection func(name string, metadata map[string]string)
 	OnEndSection   func()
+
+	// If set, these are called instead of the above. OnStartSpan returns the context sections nested in this one
+	// start from (e.g carrying its span), and OnEndSpan is given that context and the error the section failed
+	// with, if any. Unlike OnStartSection, these are safe for sections that run concurrently.
+	OnStartSpan func(ctx context.Context, name string, metadata map[string]string) context.Context
+	OnEndSpan   func(ctx context.Context, err error)
 }
 type TEigenKey string
 
@@ -27,3 +34,30 @@ func GetContextTracingCallbacks(ctx context.Context) *TracerCallbacks {
 
 	return tracing
 }
+
+// startSection starts a section, nested in the one `ctx` is from (if any). The section ends when the returned
+// function is called, with the error it failed with (or nil). Sections nested in it start from the returned context.
+func startSection(ctx context.Context, name string, metadata map[string]string) (context.Context, func(err error)) {
+	tracing := GetContextTracingCallbacks(ctx)
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	if tracing.OnStartSpan != nil {
+		spanCtx := tracing.OnStartSpan(ctx, name, metadata)
+		return spanCtx, func(err error) {
+			if tracing.OnEndSpan != nil {
+				tracing.OnEndSpan(spanCtx, err)
+			}
+		}
+	}
+
+	if tracing.OnStartSection != nil {
+		tracing.OnStartSection(name, metadata)
+	}
+	return ctx, func(error) {
+		if tracing.OnEndSection != nil {
+			tracing.OnEndSection()
+		}
+	}
+}
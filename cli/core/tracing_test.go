@@ -0,0 +1,49 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type spanKey struct{}
+
+func TestStartSection(t *testing.T) {
+	// only the old callbacks: sections are started and ended in order
+	calls := []string{}
+	ctx := ContextWithTracing(context.Background(), &TracerCallbacks{
+		OnStartSection: func(name string, metadata map[string]string) { calls = append(calls, "start "+name) },
+		OnEndSection:   func() { calls = append(calls, "end") },
+	})
+	_, end := startSection(ctx, "GetBeaconState", nil)
+	end(errors.New("not found"))
+	if len(calls) != 2 || calls[0] != "start GetBeaconState" || calls[1] != "end" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+
+	// spans nest in the context they're started from, and end with their error
+	ended := map[string]error{}
+	ctx = ContextWithTracing(context.Background(), &TracerCallbacks{
+		OnStartSpan: func(ctx context.Context, name string, metadata map[string]string) context.Context {
+			if parent, ok := ctx.Value(spanKey{}).(string); ok {
+				name = parent + "/" + name
+			}
+			return context.WithValue(ctx, spanKey{}, name)
+		},
+		OnEndSpan: func(ctx context.Context, err error) {
+			ended[ctx.Value(spanKey{}).(string)] = err
+		},
+	})
+	submitCtx, endSubmit := startSection(ctx, "submit", nil)
+	_, endBatch := startSection(submitCtx, "batch", map[string]string{"chunk": "0"})
+	reverted := errors.New("reverted")
+	endBatch(reverted)
+	endSubmit(nil)
+	if len(ended) != 2 || ended["submit/batch"] != reverted || ended["submit"] != nil {
+		t.Errorf("unexpected spans %v", ended)
+	}
+
+	// without any callbacks, sections are no-ops
+	_, end = startSection(context.Background(), "GetBeaconState", nil)
+	end(nil)
+}
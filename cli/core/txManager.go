@@ -0,0 +1,432 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const TX_CONFIG_KEY TEigenKey = "com.eigen.txConfig"
+
+const DEFAULT_MAX_IN_FLIGHT = 4
+
+// a transaction that fails to send is re-planned (rebuilt with a fresh nonce), and one dropped from the mempool is
+// rebroadcast (with its nonce), until it has been built this many times.
+const maxTxAttempts = 2
+
+var ErrTransactionReverted = errors.New("transaction reverted")
+
+// ErrTransactionDropped is returned for a transaction that the node no longer knows about, or whose nonce was used
+// by a transaction that wasn't sent by the TxManager.
+var ErrTransactionDropped = errors.New("transaction dropped")
+
+// TxConfig controls how a TxManager sends transactions.
+type TxConfig struct {
+	// How many transactions may be pending at once.
+	MaxInFlight int
+	Fees        FeePolicy
+	// Proof batches are sized to stay under this much gas. 0 uses DEFAULT_GAS_TARGET.
+	GasTarget uint64
+	// Don't simulate proof batches before sending them.
+	SkipPreflight bool
+	// Leave proofs that fail pre-flight simulation out, instead of failing.
+	DropFailingProofs bool
+}
+
+func ContextWithTxConfig(ctx context.Context, config TxConfig) context.Context {
+	return context.WithValue(ctx, TX_CONFIG_KEY, config)
+}
+
+func GetContextTxConfig(ctx context.Context) TxConfig {
+	config, ok := ctx.Value(TX_CONFIG_KEY).(TxConfig)
+	if !ok {
+		config = TxConfig{}
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = DEFAULT_MAX_IN_FLIGHT
+	}
+	return config
+}
+
+// TxBuilder builds (and, unless `opts.NoSend` is set, broadcasts) one transaction using `opts`.
+type TxBuilder func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// TxManager sends a sequence of independent transactions from one account. It assigns nonces itself,
+// so several transactions can be pending at once instead of waiting a block for each.
+type TxManager struct {
+	eth     ExecutionClient
+	owner   *Owner
+	config  TxConfig
+	verbose bool
+
+	// Called as each transaction is broadcast (including replacements of stuck ones), and when it's mined.
+	OnSent  func(index int, txn *types.Transaction) error
+	OnMined func(index int, receipt *types.Receipt) error
+
+	pollInterval time.Duration
+}
+
+func NewTxManager(ctx context.Context, eth ExecutionClient, owner *Owner, verbose bool) *TxManager {
+	return &TxManager{
+		eth:     eth,
+		owner:   owner,
+		config:  GetContextTxConfig(ctx),
+		verbose: verbose,
+
+		pollInterval: time.Second,
+	}
+}
+
+// SendTransaction sends one transaction through a TxManager, so it's priced (and replaced, if stuck) like
+// any other. Unless the owner is a dry run, it returns once the transaction is mined.
+func SendTransaction(ctx context.Context, eth ExecutionClient, owner *Owner, build TxBuilder, verbose bool) (*types.Transaction, error) {
+	txns, err := NewTxManager(ctx, eth, owner, verbose).Send(ctx, []TxBuilder{build})
+	if len(txns) == 0 {
+		return nil, err
+	}
+	return txns[0], err
+}
+
+// a transaction waiting to be mined, along with any replacements sent for it (all with the same nonce).
+type pendingTx struct {
+	index  int
+	txns   []*types.Transaction
+	fees   *Fees
+	sentAt uint64 // block number when last (re)sent
+	// set once the fee ceiling leaves no room to replace it.
+	atCeiling bool
+}
+
+// Send builds the transactions in order, keeping up to `MaxInFlight` of them pending, and waits for all of
+// them to be mined. If one fails to send, the rest go ahead, and it's rebuilt at the end of the queue with a
+// fresh nonce. One that reverts fails, with the decoded revert reason, and isn't retried. A transaction pending
+// for longer than the fee policy allows is replaced with one paying more, up to the policy's ceiling; one that
+// drops out of the node's mempool is rebroadcast, and one whose nonce is taken by another transaction fails.
+//
+// Returns, for each builder, the transaction that was mined (or the last one built, on failure). If the
+// owner is a dry run, the transactions are only built.
+func (m *TxManager) Send(ctx context.Context, builders []TxBuilder) ([]*types.Transaction, error) {
+	txns := make([]*types.Transaction, len(builders))
+
+	if m.owner.IsDryRun || m.owner.TransactionOptions.NoSend {
+		// priced as they would be sent, unless the options fix their fees (e.g a Safe batch, which only uses the calldata).
+		var fees *Fees
+		if opts := m.owner.TransactionOptions; opts.GasPrice == nil && opts.GasFeeCap == nil {
+			var err error
+			if fees, err = SuggestFees(ctx, m.eth, m.config.Fees); err != nil {
+				return nil, err
+			}
+		}
+		for i, build := range builders {
+			txn, err := build(m.transactOpts(ctx, nil, fees))
+			if err != nil {
+				return txns[:i], DecodeRevert(err)
+			}
+			txns[i] = txn
+		}
+		return txns, nil
+	}
+
+	nonce, err := m.eth.PendingNonceAt(ctx, m.owner.FromAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch nonce: %w", err)
+	}
+
+	queue := make([]int, len(builders))
+	for i := range queue {
+		queue[i] = i
+	}
+	attempts := make([]int, len(builders))
+	failures := []error{}
+	pending := []*pendingTx{}
+
+	for len(queue) > 0 || len(pending) > 0 {
+		if len(queue) > 0 && len(pending) < m.config.MaxInFlight {
+			head, err := m.eth.BlockNumber(ctx)
+			if err != nil {
+				return txns, fmt.Errorf("failed to fetch block number: %w", err)
+			}
+			// one price for everything sent in this round
+			fees, err := SuggestFees(ctx, m.eth, m.config.Fees)
+			if errors.Is(err, ErrFeeCeiling) && len(pending) > 0 {
+				// wait on what's already sent, and try again once fees come down.
+				if m.verbose {
+					logWarn(ctx, "not sending more transactions: %s", err.Error())
+				}
+			} else if err != nil {
+				return txns, err
+			}
+
+			for fees != nil && len(queue) > 0 && len(pending) < m.config.MaxInFlight {
+				i := queue[0]
+				queue = queue[1:]
+				attempts[i]++
+
+				txn, err := builders[i](m.transactOpts(ctx, new(big.Int).SetUint64(nonce), fees))
+				if err != nil {
+					err = DecodeRevert(err)
+					if attempts[i] >= maxTxAttempts {
+						failures = append(failures, fmt.Errorf("transaction %d: %w", i, err))
+						continue
+					}
+					if m.verbose {
+						logWarn(ctx, "failed to send transaction %d (%s), retrying later", i, err.Error())
+					}
+					// the nonce wasn't used, but re-sync in case the node saw something we didn't.
+					nonce, err = m.eth.PendingNonceAt(ctx, m.owner.FromAddress)
+					if err != nil {
+						return txns, fmt.Errorf("failed to fetch nonce: %w", err)
+					}
+					queue = append(queue, i)
+					continue
+				}
+
+				nonce++
+				txns[i] = txn
+				if m.OnSent != nil {
+					if err := m.OnSent(i, txn); err != nil {
+						return txns, err
+					}
+				}
+				if m.verbose {
+					logPlain(ctx, "sent transaction %d/%d: %s (nonce %d, %s)\n", i+1, len(builders), txn.Hash().Hex(), txn.Nonce(), fees)
+				}
+				pending = append(pending, &pendingTx{index: i, txns: []*types.Transaction{txn}, fees: fees, sentAt: head})
+			}
+		}
+
+		if len(pending) == 0 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return txns, ctx.Err()
+		case <-time.After(m.pollInterval):
+		}
+
+		head, err := m.eth.BlockNumber(ctx)
+		if err != nil {
+			return txns, fmt.Errorf("failed to fetch block number: %w", err)
+		}
+		// fetched before the receipts, so a transaction mined in between isn't mistaken for a stale one.
+		confirmed, known, err := m.nonces(ctx)
+		if err != nil {
+			return txns, err
+		}
+
+		stillPending := []*pendingTx{}
+		for _, tx := range pending {
+			txn, receipt, err := m.receipt(ctx, tx)
+			if err != nil {
+				return txns, err
+			}
+			if receipt == nil {
+				latest := tx.txns[len(tx.txns)-1]
+				dropped := false
+				if latest.Nonce() >= known && head > tx.sentAt {
+					// some nodes don't count their mempool in the pending nonce, so ask about the transaction too.
+					if dropped, err = m.dropped(ctx, tx); err != nil {
+						return txns, err
+					}
+				}
+				switch {
+				case latest.Nonce() < confirmed:
+					failures = append(failures, fmt.Errorf("transaction %d (%s): %w: nonce %d was used by another transaction", tx.index, latest.Hash().Hex(), ErrTransactionDropped, latest.Nonce()))
+					continue
+				case dropped:
+					if attempts[tx.index] >= maxTxAttempts {
+						failures = append(failures, fmt.Errorf("transaction %d (%s): %w from the mempool", tx.index, latest.Hash().Hex(), ErrTransactionDropped))
+						continue
+					}
+					attempts[tx.index]++
+					if err := m.rebroadcast(ctx, builders, tx, head); err != nil {
+						failures = append(failures, fmt.Errorf("transaction %d (%s): %w from the mempool, and failed to rebroadcast: %w", tx.index, latest.Hash().Hex(), ErrTransactionDropped, err))
+						continue
+					}
+				default:
+					if err := m.maybeReplace(ctx, builders, tx, head); err != nil {
+						return txns, err
+					}
+				}
+				txns[tx.index] = tx.txns[len(tx.txns)-1]
+				stillPending = append(stillPending, tx)
+				continue
+			}
+
+			txns[tx.index] = txn
+			GetContextValidatorInfoCache(ctx).Forget()
+			if m.OnMined != nil {
+				if err := m.OnMined(tx.index, receipt); err != nil {
+					return txns, err
+				}
+			}
+
+			if receipt.Status == types.ReceiptStatusSuccessful {
+				if m.verbose {
+					logInfo(ctx, "transaction %d/%d mined in block %d", tx.index+1, len(builders), receipt.BlockNumber.Uint64())
+				}
+				continue
+			}
+
+			// the transaction ran and failed. Sending it again would fail the same way (e.g the proof is already
+			// verified), so don't.
+			revertErr := ExplainRevertedTransaction(ctx, m.eth, txn, receipt)
+			failures = append(failures, fmt.Errorf("transaction %d (%s): %w: %w", tx.index, receipt.TxHash.Hex(), ErrTransactionReverted, revertErr))
+		}
+		pending = stillPending
+	}
+
+	return txns, errors.Join(failures...)
+}
+
+// the receipt of whichever of the transaction's versions was mined, if any.
+func (m *TxManager) receipt(ctx context.Context, tx *pendingTx) (*types.Transaction, *types.Receipt, error) {
+	for _, txn := range tx.txns {
+		receipt, err := m.eth.TransactionReceipt(ctx, txn.Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch receipt of %s: %w", txn.Hash().Hex(), err)
+		}
+		return txn, receipt, nil
+	}
+	return nil, nil, nil
+}
+
+// the account's confirmed nonce (the next one to be mined), and the next nonce the node would assign, which is
+// past every transaction of ours it still has in its mempool.
+func (m *TxManager) nonces(ctx context.Context) (uint64, uint64, error) {
+	confirmed, err := m.eth.NonceAt(ctx, m.owner.FromAddress, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch nonce: %w", err)
+	}
+	known, err := m.eth.PendingNonceAt(ctx, m.owner.FromAddress)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch pending nonce: %w", err)
+	}
+	return confirmed, known, nil
+}
+
+// whether the node has forgotten every version of the transaction.
+func (m *TxManager) dropped(ctx context.Context, tx *pendingTx) (bool, error) {
+	for _, txn := range tx.txns {
+		_, _, err := m.eth.TransactionByHash(ctx, txn.Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to fetch %s: %w", txn.Hash().Hex(), err)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// rebuilds a transaction the node dropped, with the same nonce and current fees.
+func (m *TxManager) rebroadcast(ctx context.Context, builders []TxBuilder, tx *pendingTx, head uint64) error {
+	previous := tx.txns[len(tx.txns)-1]
+	fees, err := SuggestFees(ctx, m.eth, m.config.Fees)
+	if err != nil {
+		return err
+	}
+	// a node may refuse a copy paying less than what it dropped.
+	if tx.fees != nil && fees.TipCap.Cmp(tx.fees.TipCap) < 0 {
+		fees = tx.fees
+	}
+
+	txn, err := builders[tx.index](m.transactOpts(ctx, new(big.Int).SetUint64(previous.Nonce()), fees))
+	if err != nil {
+		return DecodeRevert(err)
+	}
+	tx.txns = append(tx.txns, txn)
+	tx.fees = fees
+	tx.sentAt = head
+	if m.OnSent != nil {
+		if err := m.OnSent(tx.index, txn); err != nil {
+			return err
+		}
+	}
+	if m.verbose {
+		logWarn(ctx, "rebroadcast dropped transaction %s as %s (%s)", previous.Hash().Hex(), txn.Hash().Hex(), fees)
+	}
+	return nil
+}
+
+// re-sends a transaction that has been pending for `BumpAfterBlocks`, with the same nonce and higher fees.
+func (m *TxManager) maybeReplace(ctx context.Context, builders []TxBuilder, tx *pendingTx, head uint64) error {
+	policy := m.config.Fees
+	if policy.BumpAfterBlocks == 0 || tx.atCeiling || head < tx.sentAt+policy.BumpAfterBlocks {
+		return nil
+	}
+
+	previous := tx.txns[len(tx.txns)-1]
+	fees, err := BumpFees(ctx, m.eth, policy, tx.fees)
+	if errors.Is(err, ErrFeeCeiling) {
+		tx.atCeiling = true
+		if m.verbose {
+			logWarn(ctx, "transaction %s is stuck, but can't be replaced: %s", previous.Hash().Hex(), err.Error())
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	txn, err := builders[tx.index](m.transactOpts(ctx, new(big.Int).SetUint64(previous.Nonce()), fees))
+	if err != nil {
+		// e.g the original was mined in the meantime. Keep waiting on what was sent.
+		if m.verbose {
+			logWarn(ctx, "failed to replace transaction %s: %s", previous.Hash().Hex(), err.Error())
+		}
+		tx.sentAt = head
+		return nil
+	}
+
+	tx.txns = append(tx.txns, txn)
+	tx.fees = fees
+	tx.sentAt = head
+	if m.OnSent != nil {
+		if err := m.OnSent(tx.index, txn); err != nil {
+			return err
+		}
+	}
+	if m.verbose {
+		logWarn(ctx, "replaced stuck transaction %s with %s (%s)", previous.Hash().Hex(), txn.Hash().Hex(), fees)
+	}
+	return nil
+}
+
+// a copy of the owner's options for one transaction, so concurrent builders don't share a nonce.
+func (m *TxManager) transactOpts(ctx context.Context, nonce *big.Int, fees *Fees) *bind.TransactOpts {
+	opts := *m.owner.TransactionOptions
+	opts.Context = ctx
+	if nonce != nil {
+		opts.Nonce = nonce
+	}
+	if fees != nil {
+		opts.GasPrice = nil
+		opts.GasTipCap = fees.TipCap
+		opts.GasFeeCap = fees.FeeCap
+	}
+	return &opts
+}
+
+// WaitMined waits for `txn` to be mined, and fails (with the decoded revert reason) if it reverted.
+func WaitMined(ctx context.Context, eth ExecutionClient, txn *types.Transaction) (*types.Receipt, error) {
+	receipt, err := bind.WaitMined(ctx, eth, txn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for %s: %w", txn.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("%s: %w: %w", txn.Hash().Hex(), ErrTransactionReverted, ExplainRevertedTransaction(ctx, eth, txn, receipt))
+	}
+	return receipt, nil
+}
@@ -0,0 +1,421 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const revertValue = 13
+
+// a single-account node that mines a block every time it's asked for the block number. Pending transactions
+// are mined in nonce order, as long as they tip at least `minTip`. Transactions sending `revertValue` wei revert,
+// and calls always do.
+type fakeNode struct {
+	mu       sync.Mutex
+	block    uint64
+	baseFee  *big.Int
+	minTip   *big.Int
+	mined    uint64
+	pending  map[uint64]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+}
+
+func newFakeNode(t *testing.T, baseFee, minTip *big.Int) (*fakeNode, *ethclient.Client) {
+	node := &fakeNode{
+		baseFee:  baseFee,
+		minTip:   minTip,
+		pending:  map[uint64]*types.Transaction{},
+		receipts: map[common.Hash]*types.Receipt{},
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	return node, ethclient.NewClient(rpc.DialInProc(server))
+}
+
+func (n *fakeNode) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func (n *fakeNode) BlockNumber() hexutil.Uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.block++
+	for {
+		txn, ok := n.pending[n.mined]
+		if !ok || txn.GasTipCap().Cmp(n.minTip) < 0 {
+			break
+		}
+		delete(n.pending, n.mined)
+		n.mined++
+
+		status := types.ReceiptStatusSuccessful
+		if txn.Value().Int64() == revertValue {
+			status = types.ReceiptStatusFailed
+		}
+		n.receipts[txn.Hash()] = &types.Receipt{
+			Type:        txn.Type(),
+			Status:      status,
+			Logs:        []*types.Log{},
+			TxHash:      txn.Hash(),
+			BlockNumber: new(big.Int).SetUint64(n.block),
+		}
+	}
+	return hexutil.Uint64(n.block)
+}
+
+func (n *fakeNode) GetBlockByNumber(_ string, _ bool) *types.Header {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(n.block),
+		Difficulty: big.NewInt(0),
+		GasLimit:   30_000_000,
+		BaseFee:    n.baseFee,
+	}
+}
+
+func (n *fakeNode) GetTransactionCount(_ common.Address, block string) hexutil.Uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if block != "pending" {
+		return hexutil.Uint64(n.mined)
+	}
+	nonce := n.mined
+	for n.pending[nonce] != nil {
+		nonce++
+	}
+	return hexutil.Uint64(nonce)
+}
+
+func (n *fakeNode) GetTransactionByHash(hash common.Hash) *types.Transaction {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, txn := range n.pending {
+		if txn.Hash() == hash {
+			return txn
+		}
+	}
+	return nil
+}
+
+func (n *fakeNode) Call(_ map[string]any, _ string) (hexutil.Bytes, error) {
+	return nil, errors.New("execution reverted: EigenPod.verifyCheckpointProofs: must have active checkpoint")
+}
+
+// forgets a pending transaction, like a node evicting it from its mempool.
+func (n *fakeNode) drop(nonce uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.pending, nonce)
+}
+
+func (n *fakeNode) FeeHistory(blocks hexutil.Uint, _ string, percentiles []float64) map[string]any {
+	baseFees := []*hexutil.Big{}
+	rewards := [][]*hexutil.Big{}
+	for i := 0; i <= int(blocks); i++ {
+		baseFees = append(baseFees, (*hexutil.Big)(n.baseFee))
+		if i < int(blocks) && len(percentiles) > 0 {
+			rewards = append(rewards, []*hexutil.Big{(*hexutil.Big)(big.NewInt(int64(i) * 1e9))})
+		}
+	}
+	history := map[string]any{
+		"oldestBlock":   (*hexutil.Big)(big.NewInt(1)),
+		"baseFeePerGas": baseFees,
+		"gasUsedRatio":  make([]float64, blocks),
+	}
+	if len(rewards) > 0 {
+		history["reward"] = rewards
+	}
+	return history
+}
+
+func (n *fakeNode) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1e9))
+}
+
+func (n *fakeNode) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
+	var txn types.Transaction
+	if err := txn.UnmarshalBinary(data); err != nil {
+		return common.Hash{}, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	switch {
+	case txn.Nonce() < n.mined:
+		return common.Hash{}, errors.New("nonce too low")
+	case txn.Nonce() > n.mined+uint64(len(n.pending)):
+		return common.Hash{}, errors.New("nonce too high")
+	}
+	if previous, ok := n.pending[txn.Nonce()]; ok && txn.GasTipCap().Cmp(bumpBy(previous.GasTipCap(), minFeeBumpPercent)) < 0 {
+		return common.Hash{}, errors.New("replacement transaction underpriced")
+	}
+	n.pending[txn.Nonce()] = &txn
+	return txn.Hash(), nil
+}
+
+func (n *fakeNode) GetTransactionReceipt(hash common.Hash) *types.Receipt {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.receipts[hash]
+}
+
+func transferBuilder(eth *ethclient.Client, value func() int64) TxBuilder {
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		txn, err := opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{
+			ChainID:   big.NewInt(1337),
+			Nonce:     opts.Nonce.Uint64(),
+			GasTipCap: opts.GasTipCap,
+			GasFeeCap: opts.GasFeeCap,
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(value()),
+		}))
+		if err != nil {
+			return nil, err
+		}
+		return txn, eth.SendTransaction(opts.Context, txn)
+	}
+}
+
+func newTestTxManager(t *testing.T, eth *ethclient.Client, config TxConfig) *TxManager {
+	key, _ := crypto.GenerateKey()
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := NewTxManager(ContextWithTxConfig(context.Background(), config), eth, &Owner{FromAddress: opts.From, TransactionOptions: opts}, false)
+	manager.pollInterval = time.Millisecond
+	return manager
+}
+
+func TestTxManagerPipelinesAndReplans(t *testing.T) {
+	_, eth := newFakeNode(t, big.NewInt(10e9), big.NewInt(0))
+
+	attempts := map[int]int{}
+	builders := make([]TxBuilder, 5)
+	for i := range builders {
+		i := i
+		build := transferBuilder(eth, func() int64 { return 1 })
+		builders[i] = func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			attempts[i]++
+			if i == 2 && attempts[i] == 1 {
+				return nil, errors.New("rejected")
+			}
+			return build(opts)
+		}
+	}
+
+	manager := newTestTxManager(t, eth, TxConfig{MaxInFlight: 3})
+	txns, err := manager.Send(context.Background(), builders)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts[2] != 2 {
+		t.Errorf("expected the rejected transaction to be re-planned: attempts %v", attempts)
+	}
+
+	// five transactions were sent (the rejected one never got a nonce), without gaps or reuse.
+	nonces := map[uint64]bool{}
+	for _, txn := range txns {
+		if txn.Nonce() >= 5 || nonces[txn.Nonce()] {
+			t.Errorf("unexpected nonce %d", txn.Nonce())
+		}
+		nonces[txn.Nonce()] = true
+	}
+}
+
+func TestTxManagerFailsRevertedTransactions(t *testing.T) {
+	_, eth := newFakeNode(t, big.NewInt(10e9), big.NewInt(0))
+
+	attempts := map[int]int{}
+	builders := make([]TxBuilder, 3)
+	for i := range builders {
+		i := i
+		build := transferBuilder(eth, func() int64 {
+			if i == 1 {
+				return revertValue
+			}
+			return 1
+		})
+		builders[i] = func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			attempts[i]++
+			return build(opts)
+		}
+	}
+
+	_, err := newTestTxManager(t, eth, TxConfig{}).Send(context.Background(), builders)
+	if !errors.Is(err, ErrTransactionReverted) {
+		t.Fatalf("expected the revert to fail the send, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "must have active checkpoint") {
+		t.Errorf("expected the decoded revert reason, got %v", err)
+	}
+	if attempts[0] != 1 || attempts[1] != 1 || attempts[2] != 1 {
+		t.Errorf("expected nothing to be re-sent: attempts %v", attempts)
+	}
+}
+
+func TestTxManagerRebroadcastsDroppedTransactions(t *testing.T) {
+	// nothing is mined until the test allows it.
+	node, eth := newFakeNode(t, big.NewInt(10e9), big.NewInt(100e9))
+
+	manager := newTestTxManager(t, eth, TxConfig{})
+	sent := 0
+	manager.OnSent = func(_ int, txn *types.Transaction) error {
+		sent++
+		switch sent {
+		case 1:
+			// evicted before it's mined
+			node.drop(txn.Nonce())
+		case 2:
+			node.mu.Lock()
+			node.minTip = big.NewInt(0)
+			node.mu.Unlock()
+		}
+		return nil
+	}
+
+	txns, err := manager.Send(context.Background(), []TxBuilder{transferBuilder(eth, func() int64 { return 1 })})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 2 || txns[0].Nonce() != 0 {
+		t.Errorf("expected the dropped transaction to be rebroadcast with its nonce, sent %d", sent)
+	}
+}
+
+func TestTxManagerFailsStaleTransactions(t *testing.T) {
+	node, eth := newFakeNode(t, big.NewInt(10e9), big.NewInt(100e9))
+
+	manager := newTestTxManager(t, eth, TxConfig{})
+	manager.OnSent = func(_ int, txn *types.Transaction) error {
+		// another transaction, sent elsewhere with the same nonce, is mined instead.
+		node.mu.Lock()
+		defer node.mu.Unlock()
+		delete(node.pending, txn.Nonce())
+		node.mined++
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := manager.Send(ctx, []TxBuilder{transferBuilder(eth, func() int64 { return 1 })})
+	if !errors.Is(err, ErrTransactionDropped) {
+		t.Fatalf("expected the send to fail, got %v", err)
+	}
+}
+
+func TestTxManagerReplacesStuckTransactions(t *testing.T) {
+	// nothing tipping under 2 gwei is mined. The node suggests 1 gwei.
+	node, eth := newFakeNode(t, big.NewInt(10e9), big.NewInt(2e9))
+
+	manager := newTestTxManager(t, eth, TxConfig{
+		MaxInFlight: 1,
+		Fees: FeePolicy{
+			MaxFeePerGas:    big.NewInt(40e9),
+			BumpAfterBlocks: 2,
+			BumpPercent:     100,
+		},
+	})
+	sent := 0
+	manager.OnSent = func(_ int, _ *types.Transaction) error {
+		sent++
+		return nil
+	}
+
+	txns, err := manager.Send(context.Background(), []TxBuilder{transferBuilder(eth, func() int64 { return 1 })})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 2 {
+		t.Errorf("expected one replacement, got %d", sent-1)
+	}
+	if node.receipts[txns[0].Hash()] == nil {
+		t.Error("expected the mined replacement to be returned")
+	}
+	if txns[0].GasFeeCap().Cmp(big.NewInt(40e9)) != 0 {
+		t.Errorf("expected the replacement's fee cap to be held at the ceiling, got %s", txns[0].GasFeeCap())
+	}
+}
+
+func TestTxManagerPricesDryRuns(t *testing.T) {
+	_, eth := newFakeNode(t, big.NewInt(10e9), big.NewInt(0))
+	ctx := ContextWithTxConfig(context.Background(), TxConfig{Fees: FeePolicy{MaxFeePerGas: big.NewInt(25e9)}})
+
+	manager := newTestTxManager(t, eth, TxConfig{Fees: FeePolicy{MaxFeePerGas: big.NewInt(25e9)}})
+	manager.owner.IsDryRun = true
+	manager.owner.TransactionOptions.NoSend = true
+	manager.owner.TransactionOptions.Nonce = big.NewInt(0)
+	txns, err := manager.Send(ctx, []TxBuilder{transferBuilder(eth, func() int64 { return 1 })})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the node suggests a 1 gwei tip, on a 10 gwei base fee, held under the ceiling
+	if txns[0].GasTipCap().Cmp(big.NewInt(1e9)) != 0 || txns[0].GasFeeCap().Cmp(big.NewInt(21e9)) != 0 {
+		t.Errorf("expected the dry run to be priced by the fee policy, got tip %s, fee cap %s", txns[0].GasTipCap(), txns[0].GasFeeCap())
+	}
+
+	// without a sender, only the calldata is built
+	noSender := ""
+	owner, err := PrepareAccount(ctx, &noSender, big.NewInt(1337), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err = NewTxManager(ctx, eth, owner, false).Send(ctx, []TxBuilder{func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{Nonce: opts.Nonce.Uint64(), GasTipCap: opts.GasTipCap, GasFeeCap: opts.GasFeeCap, Gas: opts.GasLimit}))
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txns[0].GasFeeCap().Sign() != 0 {
+		t.Errorf("expected a transaction without a sender not to be priced, got fee cap %s", txns[0].GasFeeCap())
+	}
+}
+
+func TestSuggestFeesRespectsCeiling(t *testing.T) {
+	_, eth := newFakeNode(t, big.NewInt(10e9), big.NewInt(0))
+	ctx := context.Background()
+
+	fees, err := SuggestFees(ctx, eth, FeePolicy{TipPercentile: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rewards are 0..19 gwei, and fee caps are twice the base fee plus the tip
+	if fees.TipCap.Cmp(big.NewInt(10e9)) != 0 || fees.FeeCap.Cmp(big.NewInt(30e9)) != 0 {
+		t.Errorf("unexpected fees: %s", fees)
+	}
+
+	fees, err = SuggestFees(ctx, eth, FeePolicy{TipPercentile: 50, MaxFeePerGas: big.NewInt(15e9)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.FeeCap.Cmp(big.NewInt(15e9)) != 0 || fees.TipCap.Cmp(big.NewInt(10e9)) != 0 {
+		t.Errorf("unexpected fees: %s", fees)
+	}
+
+	if _, err := BumpFees(ctx, eth, FeePolicy{MaxFeePerGas: big.NewInt(15e9)}, fees); !errors.Is(err, ErrFeeCeiling) {
+		t.Errorf("expected no room for a replacement, got %v", err)
+	}
+	if _, err := SuggestFees(ctx, eth, FeePolicy{MaxFeePerGas: big.NewInt(5e9)}); !errors.Is(err, ErrFeeCeiling) {
+		t.Errorf("expected the base fee to exceed the ceiling, got %v", err)
+	}
+}
@@ -1,21 +1,22 @@
 package core
 
 import (
-	"bytes"
 	"context"
 	"crypto/ecdsa"
-	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"io"
 	"math"
 	"math/big"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
 
 	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -23,7 +24,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	gethCommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/fatih/color"
@@ -35,12 +35,6 @@ const (
 	ValidatorStatusWithdrawn = 2
 )
 
-func Panic(message string) {
-	color.Red(fmt.Sprintf("error: %s\n\n", message))
-
-	os.Exit(1)
-}
-
 func WeiToGwei(val *big.Int) *big.Float {
 	return new(big.Float).Quo(
 		new(big.Float).SetInt(val),
@@ -60,17 +54,6 @@ func IweiToEther(val *big.Int) *big.Float {
 	return new(big.Float).Quo(new(big.Float).SetInt(val), big.NewFloat(params.Ether))
 }
 
-func PanicOnError(message string, err error) {
-	if err != nil {
-		color.Red(fmt.Sprintf("error: %s\n\n", message))
-
-		info := color.New(color.FgRed, color.Italic)
-		info.Printf(fmt.Sprintf("caused by: %s\n", err))
-
-		os.Exit(1)
-	}
-}
-
 func chunk[T any](arr []T, chunkSize uint64) [][]T {
 	// Validate the chunkSize to ensure it's positive
 	if chunkSize <= 0 {
@@ -101,9 +84,12 @@ type ValidatorWithIndex = struct {
 func withDryRun(opts *bind.TransactOpts) *bind.TransactOpts {
 	// golang doesn't have a spread operator for structs smh
 	return &bind.TransactOpts{
-		From:   opts.From,
-		Nonce:  opts.Nonce,
-		Signer: opts.Signer,
+		From:  opts.From,
+		Nonce: opts.Nonce,
+		// only built to estimate gas, so there's no need to sign (e.g with a remote signer)
+		Signer: func(_ gethCommon.Address, txn *types.Transaction) (*types.Transaction, error) {
+			return txn, nil
+		},
 
 		Value:     opts.Value,
 		GasPrice:  opts.GasPrice,
@@ -123,8 +109,8 @@ type Owner = struct {
 	IsDryRun           bool
 }
 
-func StartCheckpoint(ctx context.Context, eigenpodAddress string, ownerPrivateKey string, chainId *big.Int, eth *ethclient.Client, forceCheckpoint bool, noSend bool) (*types.Transaction, error) {
-	ownerAccount, err := PrepareAccount(&ownerPrivateKey, chainId, noSend)
+func StartCheckpoint(ctx context.Context, eigenpodAddress string, ownerPrivateKey string, chainId *big.Int, eth ExecutionClient, forceCheckpoint bool, noSend bool) (*types.Transaction, error) {
+	ownerAccount, err := PrepareAccount(ctx, &ownerPrivateKey, chainId, noSend)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse private key: %w", err)
 	}
@@ -136,7 +122,9 @@ func StartCheckpoint(ctx context.Context, eigenpodAddress string, ownerPrivateKe
 
 	revertIfNoBalance := !forceCheckpoint
 
-	txn, err := eigenPod.StartCheckpoint(ownerAccount.TransactionOptions, revertIfNoBalance)
+	txn, err := SendTransaction(ctx, eth, ownerAccount, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return eigenPod.StartCheckpoint(opts, revertIfNoBalance)
+	}, false /* verbose */)
 	if err != nil {
 		if !forceCheckpoint {
 			return nil, fmt.Errorf("failed to start checkpoint (try running again with `--force`): %w", err)
@@ -149,11 +137,27 @@ func StartCheckpoint(ctx context.Context, eigenpodAddress string, ownerPrivateKe
 }
 
 func GetBeaconClient(beaconUri string, verbose bool) (BeaconClient, error) {
+	if strings.HasPrefix(beaconUri, ERA_SCHEME) {
+		dir, fallbackUri, err := parseEraUri(beaconUri)
+		if err != nil {
+			return nil, err
+		}
+
+		var fallback BeaconClient
+		if fallbackUri != "" {
+			fallback, _, err = NewBeaconClient(fallbackUri, verbose)
+			if err != nil {
+				return nil, fmt.Errorf("failed to reach fallback beacon node: %w", err)
+			}
+		}
+		return NewEraBeaconClient(dir, fallback, verbose)
+	}
+
 	beaconClient, _, err := NewBeaconClient(beaconUri, verbose)
 	return beaconClient, err
 }
 
-func GetCurrentCheckpoint(eigenpodAddress string, client *ethclient.Client) (uint64, error) {
+func GetCurrentCheckpoint(eigenpodAddress string, client ExecutionClient) (uint64, error) {
 	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(eigenpodAddress), client)
 	if err != nil {
 		return 0, fmt.Errorf("failed to locate eigenpod. is your address correct?: %w", err)
@@ -173,17 +177,15 @@ func GetCurrentCheckpoint(eigenpodAddress string, client *ethclient.Client) (uin
 func GetCheckpointTimestampAndBeaconState(
 	ctx context.Context,
 	eigenpodAddress string,
-	eth *ethclient.Client,
+	eth ExecutionClient,
 	beaconClient BeaconClient,
 ) (uint64, *spec.VersionedBeaconState, error) {
-	tracing := GetContextTracingCallbacks(ctx)
-
-	tracing.OnStartSection("GetCurrentCheckpoint", map[string]string{})
+	_, endSection := startSection(ctx, "GetCurrentCheckpoint", nil)
 	checkpointTimestamp, err := GetCurrentCheckpoint(eigenpodAddress, eth)
+	endSection(err)
 	if err != nil {
 		return 0, nil, fmt.Errorf("failed to fetch current checkpoint: %w", err)
 	}
-	tracing.OnEndSection()
 
 	// stateId to look up beacon state. "head" by default (if we do not have a checkpoint)
 	beaconStateId := "head"
@@ -191,8 +193,9 @@ func GetCheckpointTimestampAndBeaconState(
 	// If we have a checkpoint, get the state id for the checkpoint's block root
 	if checkpointTimestamp != 0 {
 		// Fetch the checkpoint's block root
-		tracing.OnStartSection("GetCurrentCheckpointBlockRoot", map[string]string{})
+		_, endSection := startSection(ctx, "GetCurrentCheckpointBlockRoot", nil)
 		blockRoot, err := GetCurrentCheckpointBlockRoot(eigenpodAddress, eth)
+		endSection(err)
 		if err != nil {
 			return 0, nil, fmt.Errorf("failed to fetch last checkpoint: %w", err)
 		}
@@ -204,27 +207,26 @@ func GetCheckpointTimestampAndBeaconState(
 		if AllZero(rootBytes[:]) {
 			return 0, nil, fmt.Errorf("failed to fetch last checkpoint - empty blockRoot")
 		}
-		tracing.OnEndSection()
 
 		headerBlock := "0x" + hex.EncodeToString((*blockRoot)[:])
-		tracing.OnStartSection("GetBeaconHeader", map[string]string{})
-		header, err := beaconClient.GetBeaconHeader(ctx, headerBlock)
+		headerCtx, endSection := startSection(ctx, "GetBeaconHeader", map[string]string{"block": headerBlock})
+		header, err := beaconClient.GetBeaconHeader(headerCtx, headerBlock)
+		endSection(err)
 		if err != nil {
 			return 0, nil, fmt.Errorf("failed to fetch beacon header (%s): %w", headerBlock, err)
 		}
-		tracing.OnEndSection()
 
 		beaconStateId = strconv.FormatUint(uint64(header.Header.Message.Slot), 10)
 	} else {
 		beaconStateId = "head"
 	}
 
-	tracing.OnStartSection("GetBeaconState", map[string]string{})
-	beaconState, err := beaconClient.GetBeaconState(ctx, beaconStateId)
+	stateCtx, endSection := startSection(ctx, "GetBeaconState", map[string]string{"stateId": beaconStateId})
+	beaconState, err := beaconClient.GetBeaconState(stateCtx, beaconStateId)
+	endSection(err)
 	if err != nil {
 		return 0, nil, fmt.Errorf("failed to fetch beacon state: %w", err)
 	}
-	tracing.OnEndSection()
 
 	return checkpointTimestamp, beaconState, nil
 }
@@ -262,67 +264,28 @@ func SortByStatus(validators map[string]Validator) ([]Validator, []Validator, []
 	return awaitingActivationQueueValidators, inactiveValidators, activeValidators, withdrawnValidators
 }
 
-// search through beacon state for validators whose withdrawal address is set to eigenpod.
-func FindAllValidatorsForEigenpod(eigenpodAddress string, beaconState *spec.VersionedBeaconState) ([]ValidatorWithIndex, error) {
-	allValidators, err := beaconState.Validators()
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch beacon state: %w", err)
-	}
-
-	eigenpodAddressBytes := common.FromHex(eigenpodAddress)
-
-	var outputValidators []ValidatorWithIndex = []ValidatorWithIndex{}
-	var i uint64 = 0
-	maxValidators := uint64(len(allValidators))
-	for i = 0; i < maxValidators; i++ {
-		validator := allValidators[i]
-		if validator == nil || validator.WithdrawalCredentials[0] != 1 { // withdrawalCredentials _need_ their first byte set to 1 to withdraw to execution layer.
-			continue
-		}
-		// we check that the last 20 bytes of expectedCredentials matches validatorCredentials.
-		if bytes.Equal(
-			eigenpodAddressBytes,
-			validator.WithdrawalCredentials[12:], // first 12 bytes are not the pubKeyHash, see (https://github.com/Layr-Labs/eigenlayer-contracts/blob/d148952a2942a97a218a2ab70f9b9f1792796081/src/contracts/pods/EigenPod.sol#L663)
-		) {
-			outputValidators = append(outputValidators, ValidatorWithIndex{
-				Validator: validator,
-				Index:     i,
-			})
+// search through beacon state for validators whose withdrawal address is set to eigenpod, using `index` (the
+// state's ValidatorIndex). If it's nil, the state is indexed for this lookup alone.
+func FindAllValidatorsForEigenpod(eigenpodAddress string, beaconState *spec.VersionedBeaconState, index *ValidatorIndex) ([]ValidatorWithIndex, error) {
+	if index == nil {
+		var err error
+		if index, err = NewValidatorIndex(beaconState); err != nil {
+			return nil, err
 		}
 	}
-	return outputValidators, nil
+	return index.ValidatorsWithdrawingTo(common.HexToAddress(eigenpodAddress)), nil
 }
 
-func GetOnchainValidatorInfo(client *ethclient.Client, eigenpodAddress string, allValidators []ValidatorWithIndex) ([]onchain.IEigenPodValidatorInfo, error) {
-	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(eigenpodAddress), client)
-	if err != nil {
-		return nil, fmt.Errorf("failed to locate Eigenpod. Is your address correct?: %w", err)
+// GetOnchainValidatorInfo reads the pod's info for each validator, in batches (see GetValidatorInfoByPubkeyHash).
+func GetOnchainValidatorInfo(ctx context.Context, client ExecutionClient, eigenpodAddress string, allValidators []ValidatorWithIndex) ([]onchain.IEigenPodValidatorInfo, error) {
+	pubkeyHashes := make([][32]byte, len(allValidators))
+	for i, validator := range allValidators {
+		pubkeyHashes[i] = PubkeyHash(validator.Validator.PublicKey)
 	}
-
-	var validatorInfo []onchain.IEigenPodValidatorInfo = []onchain.IEigenPodValidatorInfo{}
-
-	// TODO: batch/multicall
-	zeroes := [16]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
-	for i := 0; i < len(allValidators); i++ {
-		// ssz requires values to be 32-byte aligned, which requires 16 bytes of 0's to be added
-		// prior to hashing.
-		pubKeyHash := sha256.Sum256(
-			append(
-				(allValidators[i]).Validator.PublicKey[:],
-				zeroes[:]...,
-			),
-		)
-		info, err := eigenPod.ValidatorPubkeyHashToInfo(nil, pubKeyHash)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch validator eigeninfo: %w", err)
-		}
-		validatorInfo = append(validatorInfo, info)
-	}
-
-	return validatorInfo, nil
+	return GetValidatorInfoByPubkeyHash(ctx, client, eigenpodAddress, pubkeyHashes)
 }
 
-func GetCurrentCheckpointBlockRoot(eigenpodAddress string, eth *ethclient.Client) (*[32]byte, error) {
+func GetCurrentCheckpointBlockRoot(eigenpodAddress string, eth ExecutionClient) (*[32]byte, error) {
 	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
 	if err != nil {
 		return nil, fmt.Errorf("failed to locate Eigenpod. Is your address correct?: %w", err)
@@ -340,26 +303,49 @@ func IsAwaitingWithdrawalCredentialProof(validatorInfo onchain.IEigenPodValidato
 	return (validatorInfo.Status == ValidatorStatusInactive) && validator.ExitEpoch == FAR_FUTURE_EPOCH && validator.ActivationEpoch != FAR_FUTURE_EPOCH
 }
 
+// GetClients dials the execution and beacon nodes. The beacon client is an io.Closer, which releases what it
+// holds open (e.g era files, or the server intercepting its traffic); if the context has Resources, both clients
+// are added to them.
 func GetClients(ctx context.Context, node, beaconNodeUri string, enableLogs bool) (*ethclient.Client, BeaconClient, *big.Int, error) {
-	eth, err := ethclient.Dial(node)
+	eth, err := DialExecutionClient(ctx, node)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("failed to reach eth --node: %w", err)
 	}
 
 	chainId, err := eth.ChainID(ctx)
 	if err != nil {
+		eth.Close()
 		return nil, nil, nil, fmt.Errorf("failed to fetch chain id: %w", err)
 	}
 
-	if chainId == nil || (chainId.Int64() != 17000 && chainId.Int64() != 1) {
-		return nil, nil, nil, errors.New("this tool only supports the Holesky and Mainnet Ethereum Networks")
+	if _, err := chains.Get(chainId.Uint64()); err != nil {
+		eth.Close()
+		return nil, nil, nil, err
+	}
+
+	beaconNodeUri, stopInterceptor, err := interceptBeaconUri(ctx, beaconNodeUri)
+	if err != nil {
+		eth.Close()
+		return nil, nil, nil, err
 	}
 
 	beaconClient, err := GetBeaconClient(beaconNodeUri, enableLogs)
 	if err != nil {
+		_ = stopInterceptor()
+		eth.Close()
 		return nil, nil, nil, fmt.Errorf("failed to reach beacon client: %w", err)
 	}
 
+	closeBeacon := stopInterceptor
+	if closer, ok := beaconClient.(io.Closer); ok {
+		closeBeacon = func() error { return errors.Join(closer.Close(), stopInterceptor()) }
+	}
+	beaconClient = &closingBeaconClient{BeaconClient: beaconClient, close: closeBeacon}
+	if resources := GetContextResources(ctx); resources != nil {
+		resources.Add(beaconClient.(io.Closer).Close)
+		resources.Add(func() error { eth.Close(); return nil })
+	}
+
 	return eth, beaconClient, chainId, nil
 }
 
@@ -378,83 +364,74 @@ func CastBalanceProofs(proofs []*eigenpodproofs.BalanceProof) []onchain.BeaconCh
 	return out
 }
 
-func PanicIfNoConsent(prompt string) {
-	color.New(color.Bold).Printf("%s - Do you want to proceed? (y/n): ", prompt)
-	var reply string
-
-	fmt.Scanln(&reply)
-	if reply == "y" {
-		return
-	} else {
-		Panic("abort.")
-	}
-}
-
-func PrepareAccount(owner *string, chainID *big.Int, noSend bool) (*Owner, error) {
+// PrepareAccount sets up transaction options for `owner` (a `--sender`; see LoadSigner). With `noSend`,
+// transactions are only built (and simulated from `owner`, if it's set), so no secrets are needed.
+func PrepareAccount(ctx context.Context, owner *string, chainID *big.Int, noSend bool) (*Owner, error) {
 	if noSend {
 		isSimulatingGas := owner != nil && *owner != ""
-		var senderPk = func() string {
-			if owner == nil || *owner == "" {
-				return "372d94b8645091147a5dfc10a454d0d539773d2431293bf0a195b44fa5ddbb33" // this is a RANDOM private key. Do not use this for anything.
+		if isSimulatingGas {
+			fromAddress, err := SenderAddress(ctx, *owner)
+			if err != nil {
+				return nil, err
 			}
-			return *owner
-		}()
-
-		privateKey, err := crypto.HexToECDSA(senderPk)
-		if err != nil {
-			return nil, err
-		}
-
-		publicKey := privateKey.Public()
-		publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-		if !ok {
-			log.Fatal("error casting public key to ECDSA")
-		}
-		fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-		auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
-		if err != nil {
-			return nil, err
-		}
-
-		if !isSimulatingGas {
-			auth.GasPrice = nil             // big.NewInt(10)  // Gas price to use for the transaction execution (nil = gas price oracle)
-			auth.GasFeeCap = big.NewInt(10) // big.NewInt(10) // Gas fee cap to use for the 1559 transaction execution (nil = gas price oracle)
-			auth.GasTipCap = big.NewInt(2)  // big.NewInt(2) // Gas priority fee cap to use for the 1559 transaction execution (nil = gas price oracle)
-			auth.GasLimit = 21000
+			auth := &bind.TransactOpts{
+				From: fromAddress,
+				// never sent, so left unsigned
+				Signer: func(_ gethCommon.Address, txn *types.Transaction) (*types.Transaction, error) {
+					return txn, nil
+				},
+				Context: ctx,
+				NoSend:  true,
+			}
+			if strings.HasPrefix(*owner, SENDER_SAFE_SCHEME) {
+				// only the calldata goes into a Safe batch, and a Safe's transactions may depend on each other, so they're not simulated.
+				auth.Nonce = big.NewInt(0)
+				auth.GasFeeCap = big.NewInt(0)
+				auth.GasTipCap = big.NewInt(0)
+				auth.GasLimit = 1
+			}
+			return &Owner{
+				FromAddress:        fromAddress,
+				PublicKey:          nil,
+				TransactionOptions: auth,
+				IsDryRun:           true,
+			}, nil
 		}
-		auth.NoSend = true
 
+		// without a sender, only the calldata is used: there's no account to price, estimate or sign for.
 		return &Owner{
-			FromAddress:        fromAddress,
-			PublicKey:          nil,
-			TransactionOptions: auth,
+			TransactionOptions: forSimulation(&bind.TransactOpts{Context: ctx}),
 			IsDryRun:           true,
 		}, nil
 	}
 
-	if owner == nil {
+	if owner == nil || *owner == "" {
 		return nil, errors.New("no owner")
 	}
 
-	privateKey, err := crypto.HexToECDSA(*owner)
+	signer, err := LoadSigner(ctx, *owner)
 	if err != nil {
 		return nil, err
 	}
-
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		log.Fatal("error casting public key to ECDSA")
-	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, chainID)
-	if err != nil {
-		return nil, err
+	fromAddress := signer.Address()
+	auth := &bind.TransactOpts{
+		From: fromAddress,
+		Signer: func(address gethCommon.Address, txn *types.Transaction) (*types.Transaction, error) {
+			if address != fromAddress {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(ctx, txn, chainID)
+		},
+		Context: ctx,
 	}
 
+	var publicKey *ecdsa.PublicKey
+	if local, ok := signer.(*localSigner); ok {
+		publicKey = &local.key.PublicKey
+	}
 	return &Owner{
 		FromAddress:        fromAddress,
-		PublicKey:          publicKeyECDSA,
+		PublicKey:          publicKey,
 		TransactionOptions: auth,
 		IsDryRun:           noSend,
 	}, nil
@@ -492,28 +469,56 @@ func AllZero(s []byte) bool {
 	return true
 }
 
+type ProofFileKind string
+
+const (
+	ProofFileCheckpoint  ProofFileKind = "checkpoint"
+	ProofFileCredentials ProofFileKind = "credentials"
+)
+
+// DetectProofFileKind tells apart the files written by `checkpoint --out` and `credentials --out`.
+func DetectProofFileKind(path string) (ProofFileKind, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("failed to parse proof file: %w", err)
+	}
+
+	if _, ok := fields["validatorBalancesRootProof"]; ok {
+		return ProofFileCheckpoint, nil
+	}
+	if _, ok := fields["ValidatorProofs"]; ok {
+		return ProofFileCredentials, nil
+	}
+	return "", fmt.Errorf("%s is not a checkpoint or credential proof file", path)
+}
+
 func WriteOutputToFileOrStdout(output []byte, out *string) error {
 	if out != nil && *out != "" {
 		err := os.WriteFile(*out, output, os.ModePerm)
 		if err != nil {
 			return err
 		}
-		PanicOnError("failed to write to disk", err)
 		color.Green("Wrote output to %s\n", *out)
 	} else {
-		fmt.Println(string(output))
+		fmt.Fprintln(color.Output, string(output))
 	}
 
 	return nil
 }
 
 func SelectCheckpointableValidators(
-	client *ethclient.Client,
+	ctx context.Context,
+	client ExecutionClient,
 	eigenpodAddress string,
 	validators []ValidatorWithIndex,
 	lastCheckpoint uint64,
 ) ([]ValidatorWithIndex, error) {
-	validatorInfos, err := GetOnchainValidatorInfo(client, eigenpodAddress, validators)
+	validatorInfos, err := GetOnchainValidatorInfo(ctx, client, eigenpodAddress, validators)
 	if err != nil {
 		return nil, err
 	}
@@ -539,7 +544,7 @@ const FAR_FUTURE_EPOCH = math.MaxUint64
 // Validators whose deposits have been processed but are awaiting activation on the beacon chain
 // If the validator has 32 ETH effective balance, they should
 func SelectAwaitingActivationValidators(
-	client *ethclient.Client,
+	client ExecutionClient,
 	eigenpodAddress string,
 	validators []ValidatorWithIndex,
 ) ([]ValidatorWithIndex, error) {
@@ -555,11 +560,12 @@ func SelectAwaitingActivationValidators(
 }
 
 func SelectAwaitingCredentialValidators(
-	client *ethclient.Client,
+	ctx context.Context,
+	client ExecutionClient,
 	eigenpodAddress string,
 	validators []ValidatorWithIndex,
 ) ([]ValidatorWithIndex, error) {
-	validatorInfos, err := GetOnchainValidatorInfo(client, eigenpodAddress, validators)
+	validatorInfos, err := GetOnchainValidatorInfo(ctx, client, eigenpodAddress, validators)
 	if err != nil {
 		return nil, err
 	}
@@ -579,11 +585,12 @@ func SelectAwaitingCredentialValidators(
 }
 
 func SelectActiveValidators(
-	client *ethclient.Client,
+	ctx context.Context,
+	client ExecutionClient,
 	eigenpodAddress string,
 	validators []ValidatorWithIndex,
 ) ([]ValidatorWithIndex, error) {
-	validatorInfos, err := GetOnchainValidatorInfo(client, eigenpodAddress, validators)
+	validatorInfos, err := GetOnchainValidatorInfo(ctx, client, eigenpodAddress, validators)
 	if err != nil {
 		return nil, err
 	}
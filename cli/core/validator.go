@@ -3,24 +3,45 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/big"
 	"os"
 	"strconv"
 
 	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/utils"
 	v1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/fatih/color"
 )
 
+// SerializableCredentialProof is what `credentials --out` writes.
 type SerializableCredentialProof struct {
 	ValidatorProofs       *eigenpodproofs.VerifyValidatorFieldsCallParams
 	OracleBeaconTimestamp uint64
+	// The pod the validators' credentials point to.
+	EigenpodAddress string
+}
+
+// Check makes sure the proof is for `eigenpodAddress`, and is against a beacon state from after the pod's
+// current checkpoint started (EigenPod.verifyWithdrawalCredentials() rejects anything older).
+func (p *SerializableCredentialProof) Check(eigenpodAddress string, currentCheckpointTimestamp uint64) error {
+	if p.EigenpodAddress == "" {
+		return errors.New("the proof file doesn't say which pod it's for (it was written by an older version) -- regenerate it with `credentials --out`")
+	}
+	if !common.IsHexAddress(p.EigenpodAddress) || common.HexToAddress(p.EigenpodAddress) != common.HexToAddress(eigenpodAddress) {
+		return fmt.Errorf("the proof is for pod %s, not %s", p.EigenpodAddress, eigenpodAddress)
+	}
+	if p.OracleBeaconTimestamp <= currentCheckpointTimestamp {
+		return fmt.Errorf("the proof is against timestamp %d, from before the pod's current checkpoint started (%d) -- regenerate it with `credentials --out`", p.OracleBeaconTimestamp, currentCheckpointTimestamp)
+	}
+	return nil
 }
 
 func LoadValidatorProofFromFile(path string) (*SerializableCredentialProof, error) {
@@ -35,15 +56,18 @@ func LoadValidatorProofFromFile(path string) (*SerializableCredentialProof, erro
 		return nil, err
 	}
 
+	if res.ValidatorProofs == nil {
+		return nil, fmt.Errorf("%s is not a credential proof file", path)
+	}
+
 	return &res, nil
 }
 
-func SubmitValidatorProof(ctx context.Context, owner, eigenpodAddress string, chainId *big.Int, eth *ethclient.Client, batchSize uint64, proofs *eigenpodproofs.VerifyValidatorFieldsCallParams, oracleBeaconTimesetamp uint64, noPrompt bool, noSend bool, verbose bool) ([]*types.Transaction, [][]*big.Int, error) {
-	ownerAccount, err := PrepareAccount(&owner, chainId, noSend)
+func SubmitValidatorProof(ctx context.Context, owner, eigenpodAddress string, chainId *big.Int, eth ExecutionClient, batchSize uint64, proofs *eigenpodproofs.VerifyValidatorFieldsCallParams, oracleBeaconTimesetamp uint64, noPrompt bool, noSend bool, verbose bool) ([]*types.Transaction, [][]*big.Int, error) {
+	ownerAccount, err := PrepareAccount(ctx, &owner, chainId, noSend)
 	if err != nil {
 		return nil, [][]*big.Int{}, err
 	}
-	PanicOnError("failed to parse private key", err)
 
 	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
 	if err != nil {
@@ -51,55 +75,81 @@ func SubmitValidatorProof(ctx context.Context, owner, eigenpodAddress string, ch
 	}
 
 	indices := Uint64ArrayToBigIntArray(proofs.ValidatorIndices)
-	validatorIndicesChunks := chunk(indices, batchSize)
-	validatorProofsChunks := chunk(proofs.ValidatorFieldsProofs, batchSize)
-	validatorFieldsChunks := chunk(proofs.ValidatorFields, batchSize)
+	submitBatch := func(account *Owner, batch batchRange, verbose bool) (*types.Transaction, error) {
+		var validatorFieldsProofs [][]byte = [][]byte{}
+		for _, proof := range proofs.ValidatorFieldsProofs[batch.start:batch.end] {
+			validatorFieldsProofs = append(validatorFieldsProofs, proof.ToByteSlice())
+		}
+		var validatorFields [][][32]byte = CastValidatorFields(proofs.ValidatorFields[batch.start:batch.end])
+		return SubmitValidatorProofChunk(ctx, account, eigenPod, chainId, eth, indices[batch.start:batch.end], validatorFields, proofs.StateRootProof, validatorFieldsProofs, oracleBeaconTimesetamp, verbose)
+	}
+
+	// a batch size of 0 sizes batches by gas
+	batches := planBatches(ctx, eth, len(indices), batchSize, utils.DEFAULT_BATCH_CREDENTIALS, owner != "", func(start, end int) (uint64, error) {
+		estimateAccount := *ownerAccount
+		estimateAccount.TransactionOptions = withDryRun(ownerAccount.TransactionOptions)
+		txn, err := submitBatch(&estimateAccount, batchRange{start: start, end: end}, false)
+		if err != nil {
+			return 0, err
+		}
+		return txn.Gas(), nil
+	}, verbose)
+
+	if owner != "" {
+		batches, err = preflight(ctx, batches, func(start, end int) error {
+			return simulateTx(ctx, eth, ownerAccount.TransactionOptions, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+				simulateAccount := *ownerAccount
+				simulateAccount.TransactionOptions = opts
+				return submitBatch(&simulateAccount, batchRange{start: start, end: end}, false)
+			})
+		}, func(i int) string {
+			return fmt.Sprintf("validator %d", proofs.ValidatorIndices[i])
+		}, verbose)
+		if err != nil {
+			return nil, [][]*big.Int{}, err
+		}
+	}
+
+	validatorIndicesChunks := make([][]*big.Int, len(batches))
+	for i, batch := range batches {
+		validatorIndicesChunks[i] = indices[batch.start:batch.end]
+	}
 	if !noPrompt && !noSend {
-		PanicIfNoConsent(SubmitCredentialsProofConsent(len(validatorFieldsChunks)))
+		if err := askConsent(ctx, SubmitCredentialsProofConsent(len(batches))); err != nil {
+			return nil, [][]*big.Int{}, err
+		}
 	}
 
-	transactions := []*types.Transaction{}
-	numChunks := len(validatorIndicesChunks)
+	numChunks := len(batches)
 
 	if verbose {
-		color.Green("calling EigenPod.VerifyWithdrawalCredentials() (using %d txn(s), max(%d) proofs per txn [%s])", numChunks, batchSize, func() string {
+		logInfo(ctx, "calling EigenPod.VerifyWithdrawalCredentials() (using %d txn(s) [%s])", numChunks, func() string {
 			if ownerAccount.TransactionOptions.NoSend {
 				return "simulated"
 			} else {
 				return "live"
 			}
 		}())
-		color.Green("Submitting proofs with %d transactions", numChunks)
+		logInfo(ctx, "Submitting proofs with %d transactions", numChunks)
 	}
 
-	for i := 0; i < numChunks; i++ {
-		curValidatorIndices := validatorIndicesChunks[i]
-		curValidatorProofs := validatorProofsChunks[i]
-
-		var validatorFieldsProofs [][]byte = [][]byte{}
-		for i := 0; i < len(curValidatorProofs); i++ {
-			pr := curValidatorProofs[i].ToByteSlice()
-			validatorFieldsProofs = append(validatorFieldsProofs, pr)
+	builders := make([]TxBuilder, numChunks)
+	for i, batch := range batches {
+		batch := batch
+		builders[i] = func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			chunkAccount := *ownerAccount
+			chunkAccount.TransactionOptions = opts
+			return submitBatch(&chunkAccount, batch, verbose)
 		}
-		var curValidatorFields [][][32]byte = CastValidatorFields(validatorFieldsChunks[i])
-
-		if verbose {
-			fmt.Printf("Submitted chunk %d/%d -- waiting for transaction...: ", i+1, numChunks)
-		}
-		txn, err := SubmitValidatorProofChunk(ctx, ownerAccount, eigenPod, chainId, eth, curValidatorIndices, curValidatorFields, proofs.StateRootProof, validatorFieldsProofs, oracleBeaconTimesetamp, verbose)
-		if err != nil {
-			return transactions, validatorIndicesChunks, err
-		}
-
-		transactions = append(transactions, txn)
 	}
 
+	transactions, err := NewTxManager(ctx, eth, ownerAccount, verbose).Send(ctx, builders)
 	return transactions, validatorIndicesChunks, err
 }
 
-func SubmitValidatorProofChunk(ctx context.Context, ownerAccount *Owner, eigenPod *onchain.EigenPod, chainId *big.Int, eth *ethclient.Client, indices []*big.Int, validatorFields [][][32]byte, stateRootProofs *eigenpodproofs.StateRootProof, validatorFieldsProofs [][]byte, oracleBeaconTimesetamp uint64, verbose bool) (*types.Transaction, error) {
+func SubmitValidatorProofChunk(ctx context.Context, ownerAccount *Owner, eigenPod *onchain.EigenPod, chainId *big.Int, eth ExecutionClient, indices []*big.Int, validatorFields [][][32]byte, stateRootProofs *eigenpodproofs.StateRootProof, validatorFieldsProofs [][]byte, oracleBeaconTimesetamp uint64, verbose bool) (*types.Transaction, error) {
 	if verbose {
-		color.Green("submitting onchain...")
+		logInfo(ctx, "submitting onchain...")
 	}
 	txn, err := eigenPod.VerifyWithdrawalCredentials(
 		ownerAccount.TransactionOptions,
@@ -120,43 +170,128 @@ func SubmitValidatorProofChunk(ctx context.Context, ownerAccount *Owner, eigenPo
  * Generates a .ProveValidatorContainers() proof for all eligible validators on the pod. If `validatorIndex` is set, it will only generate  a proof
  * against that validator, regardless of the validator's state.
  */
-func GenerateValidatorProof(ctx context.Context, eigenpodAddress string, eth *ethclient.Client, chainId *big.Int, beaconClient BeaconClient, validatorIndex *big.Int, verbose bool) (*eigenpodproofs.VerifyValidatorFieldsCallParams, uint64, error) {
+func GenerateValidatorProof(ctx context.Context, eigenpodAddress string, eth ExecutionClient, chainId *big.Int, beaconClient BeaconClient, validatorIndex *big.Int, verbose bool) (*eigenpodproofs.VerifyValidatorFieldsCallParams, uint64, error) {
+	header, beaconState, blockTimestamp, err := credentialProofState(ctx, eigenpodAddress, eth, beaconClient)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	proofExecutor, err := eigenpodproofs.NewEigenPodProofs(chainId.Uint64(), 300 /* oracleStateCacheExpirySeconds - 5min */)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to initialize provider: %w", err)
+	}
+
+	proofs, err := GenerateValidatorProofAtState(ctx, proofExecutor, eigenpodAddress, beaconState, nil, eth, chainId, header, blockTimestamp, validatorIndex, verbose)
+	return proofs, blockTimestamp, err
+}
+
+// the beacon state credential proofs are made against: that of the latest block's parent, whose root the pod can
+// look up. Returns the latest block's timestamp, which the proofs are submitted with.
+func credentialProofState(ctx context.Context, eigenpodAddress string, eth ExecutionClient, beaconClient BeaconClient) (*v1.BeaconBlockHeader, *spec.VersionedBeaconState, uint64, error) {
 	latestBlock, err := eth.BlockByNumber(ctx, nil)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to load latest block: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to load latest block: %w", err)
 	}
 
 	eigenPod, err := onchain.NewEigenPod(common.HexToAddress(eigenpodAddress), eth)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to reach eigenpod: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to reach eigenpod: %w", err)
 	}
 
 	expectedBlockRoot, err := eigenPod.GetParentBlockRoot(nil, latestBlock.Time())
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to load parent block root: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to load parent block root: %w", err)
 	}
 
 	header, err := beaconClient.GetBeaconHeader(ctx, "0x"+common.Bytes2Hex(expectedBlockRoot[:]))
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch beacon header: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to fetch beacon header: %w", err)
 	}
 
 	beaconState, err := beaconClient.GetBeaconState(ctx, strconv.FormatUint(uint64(header.Header.Message.Slot), 10))
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch beacon state: %w", err)
+		return nil, nil, 0, fmt.Errorf("failed to fetch beacon state: %w", err)
 	}
+	return header, beaconState, latestBlock.Time(), nil
+}
 
-	proofExecutor, err := eigenpodproofs.NewEigenPodProofs(chainId.Uint64(), 300 /* oracleStateCacheExpirySeconds - 5min */)
+// PlanCredentials plans verifying the withdrawal credentials of the pod's validators at `indices` from `sender`,
+// or of every validator awaiting a credential proof if `indices` is empty. `proofs` may be nil, in which case a
+// new prover is used.
+func PlanCredentials(ctx context.Context, eigenpodAddress, sender string, eth ExecutionClient, chainId *big.Int, beaconClient BeaconClient, proofs *eigenpodproofs.EigenPodProofs, indices []uint64, batchSize uint64, verbose bool) (*Plan, error) {
+	ownerAccount, err := PrepareAccount(ctx, &sender, chainId, true /* noSend */)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to initialize provider: %w", err)
+		return nil, fmt.Errorf("failed to parse sender: %w", err)
+	}
+
+	header, beaconState, blockTimestamp, err := credentialProofState(ctx, eigenpodAddress, eth, beaconClient)
+	if err != nil {
+		return nil, err
+	}
+
+	allValidators, err := FindAllValidatorsForEigenpod(eigenpodAddress, beaconState, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find validators: %w", err)
+	}
+	var validators []ValidatorWithIndex
+	if len(indices) == 0 {
+		validators, err = SelectAwaitingCredentialValidators(ctx, eth, eigenpodAddress, allValidators)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find validators awaiting credential proofs: %w", err)
+		}
+	} else {
+		byIndex := make(map[uint64]ValidatorWithIndex, len(allValidators))
+		for _, v := range allValidators {
+			byIndex[v.Index] = v
+		}
+		for _, index := range indices {
+			v, ok := byIndex[index]
+			if !ok {
+				return nil, fmt.Errorf("validator at index %d does not exist or does not have withdrawal credentials set to pod %s", index, eigenpodAddress)
+			}
+			validators = append(validators, v)
+		}
+	}
+
+	pubkeys := make([][]byte, len(validators))
+	validatorIndices := make([]uint64, len(validators))
+	for i, v := range validators {
+		pubkeys[i] = v.Validator.PublicKey[:]
+		validatorIndices[i] = v.Index
+	}
+
+	plan, err := NewPlan(ctx, "credentials", eth, chainId, eigenpodAddress, ownerAccount.FromAddress, pubkeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pod state: %w", err)
+	}
+	if len(validators) == 0 {
+		plan.Notes = append(plan.Notes, "no validators are awaiting a credential proof.")
+		return plan, nil
 	}
 
-	proofs, err := GenerateValidatorProofAtState(proofExecutor, eigenpodAddress, beaconState, eth, chainId, header, latestBlock.Time(), validatorIndex, verbose)
-	return proofs, latestBlock.Time(), err
+	if proofs == nil {
+		proofs, err = eigenpodproofs.NewEigenPodProofs(chainId.Uint64(), 300 /* oracleStateCacheExpirySeconds - 5min */)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize prover: %w", err)
+		}
+	}
+	proof, err := proofs.ProveValidatorContainers(header.Header.Message, beaconState, validatorIndices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prove validators: %w", err)
+	}
+
+	txns, _, err := SubmitValidatorProof(ctx, sender, eigenpodAddress, chainId, eth, batchSize, proof, blockTimestamp, true /* noPrompt */, true /* noSend */, verbose)
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate credential proofs: %w", err)
+	}
+	plan.AddTransactions(PlanStepVerifyCredentials, "EigenPod.verifyWithdrawalCredentials()", txns...)
+	return plan, nil
 }
 
-func GenerateValidatorProofAtState(proofs *eigenpodproofs.EigenPodProofs, eigenpodAddress string, beaconState *spec.VersionedBeaconState, eth *ethclient.Client, chainId *big.Int, header *v1.BeaconBlockHeader, blockTimestamp uint64, forSpecificValidatorIndex *big.Int, verbose bool) (*eigenpodproofs.VerifyValidatorFieldsCallParams, error) {
-	allValidators, err := FindAllValidatorsForEigenpod(eigenpodAddress, beaconState)
+// GenerateValidatorProofAtState proves the credentials of the pod's validators awaiting them (or just
+// `forSpecificValidatorIndex`) against `beaconState`. `index` is the state's ValidatorIndex, or nil.
+func GenerateValidatorProofAtState(ctx context.Context, proofs *eigenpodproofs.EigenPodProofs, eigenpodAddress string, beaconState *spec.VersionedBeaconState, index *ValidatorIndex, eth ExecutionClient, chainId *big.Int, header *v1.BeaconBlockHeader, blockTimestamp uint64, forSpecificValidatorIndex *big.Int, verbose bool) (*eigenpodproofs.VerifyValidatorFieldsCallParams, error) {
+	allValidators, err := FindAllValidatorsForEigenpod(eigenpodAddress, beaconState, index)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find validators: %w", err)
 	}
@@ -176,7 +311,7 @@ func GenerateValidatorProofAtState(proofs *eigenpodproofs.EigenPodProofs, eigenp
 		}
 	} else {
 		// default behavior -- load any validators that are inactive / need a credential proof
-		awaitingCredentialValidators, err = SelectAwaitingCredentialValidators(eth, eigenpodAddress, allValidators)
+		awaitingCredentialValidators, err = SelectAwaitingCredentialValidators(ctx, eth, eigenpodAddress, allValidators)
 	}
 
 	if err != nil {
@@ -185,12 +320,12 @@ func GenerateValidatorProofAtState(proofs *eigenpodproofs.EigenPodProofs, eigenp
 
 	if len(awaitingCredentialValidators) == 0 {
 		if verbose {
-			color.Red("You have no inactive validators to verify. Everything up-to-date.")
+			logWith(ctx, slog.LevelWarn, color.Red, "You have no inactive validators to verify. Everything up-to-date.")
 		}
 		return nil, nil
 	} else {
 		if verbose {
-			color.Blue("Verifying %d inactive validators", len(awaitingCredentialValidators))
+			logWith(ctx, slog.LevelInfo, color.Blue, "Verifying %d inactive validators", len(awaitingCredentialValidators))
 		}
 	}
 
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ValidatorIndex indexes a beacon state's validator registry by execution withdrawal address and by pubkey hash,
+// so looking up a pod's validators doesn't take a pass over the whole registry.
+type ValidatorIndex struct {
+	validators          []*phase0.Validator
+	byWithdrawalAddress map[common.Address][]uint64
+
+	pubkeyHashesOnce sync.Once
+	byPubkeyHash     map[[32]byte]uint64
+}
+
+// NewValidatorIndex indexes the state's validators by withdrawal address. The pubkey hash index is built the first
+// time it's used. Build one per state, and pass it to everything that looks up validators in that state (e.g
+// every pod of a fleet group), so it's dropped along with the state.
+func NewValidatorIndex(state *spec.VersionedBeaconState) (*ValidatorIndex, error) {
+	validators, err := state.Validators()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch beacon state: %w", err)
+	}
+
+	index := &ValidatorIndex{
+		validators:          validators,
+		byWithdrawalAddress: map[common.Address][]uint64{},
+	}
+	for i, validator := range validators {
+		if address, ok := executionWithdrawalAddressOf(validator); ok {
+			index.byWithdrawalAddress[address] = append(index.byWithdrawalAddress[address], uint64(i))
+		}
+	}
+	return index, nil
+}
+
+// the address an 0x01 (or 0x02, compounding) validator withdraws to.
+func executionWithdrawalAddressOf(validator *phase0.Validator) (common.Address, bool) {
+	if validator == nil {
+		return common.Address{}, false
+	}
+	if prefix := validator.WithdrawalCredentials[0]; prefix != 1 && prefix != 2 {
+		return common.Address{}, false
+	}
+	// the first 12 bytes are the prefix and padding, see (https://github.com/Layr-Labs/eigenlayer-contracts/blob/d148952a2942a97a218a2ab70f9b9f1792796081/src/contracts/pods/EigenPod.sol#L663)
+	return common.BytesToAddress(validator.WithdrawalCredentials[12:]), true
+}
+
+// PubkeyHash is the hash EigenPods key validators by: sha256(pubkey || bytes16(0)), since ssz needs the
+// 48-byte pubkey padded to 64 bytes.
+func PubkeyHash(pubkey phase0.BLSPubKey) [32]byte {
+	var padded [64]byte
+	copy(padded[:], pubkey[:])
+	return sha256.Sum256(padded[:])
+}
+
+// Len is the number of validators in the registry.
+func (idx *ValidatorIndex) Len() int {
+	return len(idx.validators)
+}
+
+func (idx *ValidatorIndex) Validator(index uint64) ValidatorWithIndex {
+	return ValidatorWithIndex{Validator: idx.validators[index], Index: index}
+}
+
+// ValidatorsWithdrawingTo returns the validators (in index order) with execution withdrawal credentials for
+// `address`, e.g a pod's validators.
+func (idx *ValidatorIndex) ValidatorsWithdrawingTo(address common.Address) []ValidatorWithIndex {
+	indices := idx.byWithdrawalAddress[address]
+	out := make([]ValidatorWithIndex, len(indices))
+	for i, index := range indices {
+		out[i] = idx.Validator(index)
+	}
+	return out
+}
+
+// ValidatorByPubkeyHash looks up a validator by PubkeyHash.
+func (idx *ValidatorIndex) ValidatorByPubkeyHash(pubkeyHash [32]byte) (ValidatorWithIndex, bool) {
+	idx.pubkeyHashesOnce.Do(func() {
+		idx.byPubkeyHash = make(map[[32]byte]uint64, len(idx.validators))
+		for i, validator := range idx.validators {
+			if validator != nil {
+				idx.byPubkeyHash[PubkeyHash(validator.PublicKey)] = uint64(i)
+			}
+		}
+	})
+
+	index, ok := idx.byPubkeyHash[pubkeyHash]
+	if !ok {
+		return ValidatorWithIndex{}, false
+	}
+	return idx.Validator(index), true
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func testValidator(prefix byte, address common.Address, pubkeyByte byte) *phase0.Validator {
+	credentials := make([]byte, 32)
+	credentials[0] = prefix
+	copy(credentials[12:], address[:])
+	validator := &phase0.Validator{WithdrawalCredentials: credentials}
+	validator.PublicKey[0] = pubkeyByte
+	return validator
+}
+
+func TestValidatorIndex(t *testing.T) {
+	pod := common.HexToAddress("0x000000000000000000000000000000000000b0d5")
+	other := common.HexToAddress("0x0000000000000000000000000000000000000123")
+	state := &spec.VersionedBeaconState{
+		Version: spec.DataVersionDeneb,
+		Deneb: &deneb.BeaconState{Validators: []*phase0.Validator{
+			testValidator(1, pod, 0),
+			testValidator(0, pod, 1), // BLS credentials
+			testValidator(1, other, 2),
+			testValidator(2, pod, 3), // compounding
+		}},
+	}
+
+	index, err := NewValidatorIndex(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validators := index.ValidatorsWithdrawingTo(pod)
+	if len(validators) != 2 || validators[0].Index != 0 || validators[1].Index != 3 {
+		t.Errorf("got pod validators %+v, want 0 and 3", validators)
+	}
+	for _, index := range []*ValidatorIndex{index, nil} {
+		if found, _ := FindAllValidatorsForEigenpod(pod.Hex(), state, index); len(found) != 2 {
+			t.Errorf("FindAllValidatorsForEigenpod found %d validators, want 2", len(found))
+		}
+	}
+
+	pubkey := state.Deneb.Validators[2].PublicKey
+	// as the contracts hash it: the pubkey, padded with 16 zero bytes.
+	expected := sha256.Sum256(append(pubkey[:], make([]byte, 16)...))
+	if PubkeyHash(pubkey) != expected {
+		t.Errorf("PubkeyHash is %x, want %x", PubkeyHash(pubkey), expected)
+	}
+	if v, ok := index.ValidatorByPubkeyHash(expected); !ok || v.Index != 2 {
+		t.Errorf("got %+v (found: %v), want validator 2", v, ok)
+	}
+	if _, ok := index.ValidatorByPubkeyHash([32]byte{}); ok {
+		t.Error("found a validator for an unknown pubkey hash")
+	}
+}
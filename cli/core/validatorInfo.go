@@ -0,0 +1,203 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// validator infos are read this many to a JSON-RPC batch request.
+const validatorInfoBatchSize = 250
+
+const VALIDATOR_INFO_CACHE_KEY TEigenKey = "com.eigen.validatorInfoCache"
+
+type validatorInfoKey struct {
+	pod        common.Address
+	pubkeyHash [32]byte
+}
+
+// ValidatorInfoCache holds validator infos already read from pods, so a command (or one call of an SDK client)
+// reads each validator's info once. Only transactions it sends change them, and a TxManager sending with the
+// cache in its context forgets everything once one is mined. A cache is for one command on one chain: don't share
+// it across chains, or beyond the command it was made for.
+type ValidatorInfoCache struct {
+	mu    sync.Mutex
+	infos map[validatorInfoKey]onchain.IEigenPodValidatorInfo
+}
+
+func NewValidatorInfoCache() *ValidatorInfoCache {
+	return &ValidatorInfoCache{infos: map[validatorInfoKey]onchain.IEigenPodValidatorInfo{}}
+}
+
+func ContextWithValidatorInfoCache(ctx context.Context, cache *ValidatorInfoCache) context.Context {
+	return context.WithValue(ctx, VALIDATOR_INFO_CACHE_KEY, cache)
+}
+
+// GetContextValidatorInfoCache returns the context's cache, or nil (which caches nothing) if it has none.
+func GetContextValidatorInfoCache(ctx context.Context) *ValidatorInfoCache {
+	cache, _ := ctx.Value(VALIDATOR_INFO_CACHE_KEY).(*ValidatorInfoCache)
+	return cache
+}
+
+func (c *ValidatorInfoCache) get(key validatorInfoKey) (onchain.IEigenPodValidatorInfo, bool) {
+	if c == nil {
+		return onchain.IEigenPodValidatorInfo{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, ok := c.infos[key]
+	return info, ok
+}
+
+func (c *ValidatorInfoCache) put(key validatorInfoKey, info onchain.IEigenPodValidatorInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.infos[key] = info
+}
+
+// Forget drops the cached validator infos, once a transaction that may have changed them is mined.
+func (c *ValidatorInfoCache) Forget() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.infos = map[validatorInfoKey]onchain.IEigenPodValidatorInfo{}
+}
+
+// GetValidatorInfoByPubkeyHash reads EigenPod.validatorPubkeyHashToInfo() for each pubkey hash, with JSON-RPC batch
+// requests. Infos already in the context's ValidatorInfoCache are reused, and those read are added to it.
+func GetValidatorInfoByPubkeyHash(ctx context.Context, eth ExecutionClient, eigenpodAddress string, pubkeyHashes [][32]byte) ([]onchain.IEigenPodValidatorInfo, error) {
+	pod := common.HexToAddress(eigenpodAddress)
+	cache := GetContextValidatorInfoCache(ctx)
+	infos := make([]onchain.IEigenPodValidatorInfo, len(pubkeyHashes))
+
+	missing := []int{}
+	for i, pubkeyHash := range pubkeyHashes {
+		info, ok := cache.get(validatorInfoKey{pod, pubkeyHash})
+		if ok {
+			infos[i] = info
+		} else {
+			missing = append(missing, i)
+		}
+	}
+
+	for _, batch := range chunk(missing, validatorInfoBatchSize) {
+		hashes := make([][32]byte, len(batch))
+		for j, i := range batch {
+			hashes[j] = pubkeyHashes[i]
+		}
+		read, err := readValidatorInfos(ctx, eth, pod, hashes)
+		if err != nil {
+			return nil, err
+		}
+
+		for j, i := range batch {
+			infos[i] = read[j]
+			cache.put(validatorInfoKey{pod, pubkeyHashes[i]}, read[j])
+		}
+	}
+	return infos, nil
+}
+
+// implemented by clients (e.g *ethclient.Client) that can make JSON-RPC batch requests.
+type rpcClientProvider interface {
+	Client() *rpc.Client
+}
+
+// whether `err`, from a batch request, means the node doesn't take batch requests (rather than that the request
+// failed), so it's worth making the calls one at a time instead.
+func isBatchUnsupported(err error) bool {
+	// the node answered the batch with something other than an array of responses, e.g a single error
+	var unmarshalErr *json.UnmarshalTypeError
+	if errors.As(err, &unmarshalErr) {
+		return true
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.ErrorCode() == -32600 /* invalid request */ || rpcErr.ErrorCode() == -32601 /* method not found */
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusRequestEntityTooLarge, http.StatusNotImplemented:
+			return true
+		}
+	}
+	return false
+}
+
+// reads the infos in one batch request, or one call at a time if the client or node doesn't support batches.
+func readValidatorInfos(ctx context.Context, eth ExecutionClient, pod common.Address, pubkeyHashes [][32]byte) ([]onchain.IEigenPodValidatorInfo, error) {
+	eigenPodAbi, err := onchain.EigenPodMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]rpc.BatchElem, len(pubkeyHashes))
+	results := make([]hexutil.Bytes, len(pubkeyHashes))
+	for i, pubkeyHash := range pubkeyHashes {
+		data, err := eigenPodAbi.Pack("validatorPubkeyHashToInfo", pubkeyHash)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = rpc.BatchElem{
+			Method: "eth_call",
+			Args:   []any{map[string]any{"to": pod, "data": hexutil.Bytes(data)}, "latest"},
+			Result: &results[i],
+		}
+	}
+
+	batcher, ok := eth.(rpcClientProvider)
+	if !ok {
+		return readValidatorInfosOneByOne(ctx, pod, eth, pubkeyHashes)
+	}
+	if err := batcher.Client().BatchCallContext(ctx, calls); err != nil {
+		if ctx.Err() != nil || !isBatchUnsupported(err) {
+			return nil, fmt.Errorf("failed to fetch validator eigeninfo: %w", err)
+		}
+		return readValidatorInfosOneByOne(ctx, pod, eth, pubkeyHashes)
+	}
+
+	infos := make([]onchain.IEigenPodValidatorInfo, len(pubkeyHashes))
+	for i, call := range calls {
+		if call.Error != nil {
+			return nil, fmt.Errorf("failed to fetch validator eigeninfo: %w", call.Error)
+		}
+		out, err := eigenPodAbi.Unpack("validatorPubkeyHashToInfo", results[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode validator eigeninfo: %w", err)
+		}
+		infos[i] = *abi.ConvertType(out[0], new(onchain.IEigenPodValidatorInfo)).(*onchain.IEigenPodValidatorInfo)
+	}
+	return infos, nil
+}
+
+func readValidatorInfosOneByOne(ctx context.Context, pod common.Address, eth ExecutionClient, pubkeyHashes [][32]byte) ([]onchain.IEigenPodValidatorInfo, error) {
+	eigenPod, err := onchain.NewEigenPod(pod, eth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate Eigenpod. Is your address correct?: %w", err)
+	}
+
+	infos := make([]onchain.IEigenPodValidatorInfo, len(pubkeyHashes))
+	for i, pubkeyHash := range pubkeyHashes {
+		infos[i], err = eigenPod.ValidatorPubkeyHashToInfo(&bind.CallOpts{Context: ctx}, pubkeyHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch validator eigeninfo: %w", err)
+		}
+	}
+	return infos, nil
+}
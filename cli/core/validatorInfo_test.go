@@ -0,0 +1,202 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core/onchain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// answers validatorPubkeyHashToInfo(hash) with a validator whose index is the hash's last byte.
+type fakePodNode struct {
+	calls atomic.Int64
+}
+
+type fakeCallArgs struct {
+	To    common.Address `json:"to"`
+	Data  hexutil.Bytes  `json:"data"`
+	Input hexutil.Bytes  `json:"input"` // what bind sends the calldata as
+}
+
+func (n *fakePodNode) Call(args fakeCallArgs, _ string) (hexutil.Bytes, error) {
+	n.calls.Add(1)
+	eigenPodAbi, err := onchain.EigenPodMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	data := args.Data
+	if len(args.Input) > 0 {
+		data = args.Input
+	}
+	method := eigenPodAbi.Methods["validatorPubkeyHashToInfo"]
+	in, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	hash := in[0].([32]byte)
+	return method.Outputs.Pack(onchain.IEigenPodValidatorInfo{ValidatorIndex: uint64(hash[31]), Status: ValidatorStatusActive})
+}
+
+// serves `node` over HTTP, counting the requests (a batch being one). If `batches` is false, batch requests are
+// answered with a single error, as by nodes that don't take them.
+func serveFakePodNode(t *testing.T, node *fakePodNode, batches bool) (*ethclient.Client, *atomic.Int64) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+
+	requests := &atomic.Int64{}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			server.ServeHTTP(w, r)
+			return
+		}
+		requests.Add(1)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !batches && bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"batch requests are not supported"}}`))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		server.ServeHTTP(w, r)
+	}))
+	t.Cleanup(httpServer.Close)
+
+	client, err := rpc.Dial(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return ethclient.NewClient(client), requests
+}
+
+func TestGetValidatorInfoByPubkeyHash(t *testing.T) {
+	node := &fakePodNode{}
+	eth, requests := serveFakePodNode(t, node, true)
+	cache := NewValidatorInfoCache()
+	ctx := ContextWithValidatorInfoCache(context.Background(), cache)
+
+	pod := "0x000000000000000000000000000000000000b0d5"
+	hashes := make([][32]byte, 2*validatorInfoBatchSize+7)
+	for i := range hashes {
+		hashes[i][30] = byte(i >> 8)
+		hashes[i][31] = byte(i)
+	}
+
+	infos, err := GetValidatorInfoByPubkeyHash(ctx, eth, pod, hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, info := range infos {
+		if info.ValidatorIndex != uint64(byte(i)) || info.Status != ValidatorStatusActive {
+			t.Fatalf("info %d is %+v", i, info)
+		}
+	}
+	if calls := node.calls.Load(); calls != int64(len(hashes)) {
+		t.Fatalf("made %d calls, want %d", calls, len(hashes))
+	}
+	// one round trip per batch
+	batches := (len(hashes) + validatorInfoBatchSize - 1) / validatorInfoBatchSize
+	if n := requests.Load(); n != int64(batches) {
+		t.Fatalf("made %d requests, want %d", n, batches)
+	}
+
+	// already read, so served from the cache
+	if _, err := GetValidatorInfoByPubkeyHash(ctx, eth, pod, hashes[:10]); err != nil {
+		t.Fatal(err)
+	}
+	if calls := node.calls.Load(); calls != int64(len(hashes)) {
+		t.Errorf("re-read cached infos (%d calls)", calls-int64(len(hashes)))
+	}
+
+	// until a transaction is mined
+	cache.Forget()
+	if _, err := GetValidatorInfoByPubkeyHash(ctx, eth, pod, hashes[:10]); err != nil {
+		t.Fatal(err)
+	}
+	if calls := node.calls.Load(); calls != int64(len(hashes))+10 {
+		t.Errorf("made %d calls after forgetting, want 10", calls-int64(len(hashes)))
+	}
+
+	// without a cache (e.g another command), everything is read
+	if _, err := GetValidatorInfoByPubkeyHash(context.Background(), eth, pod, hashes[:10]); err != nil {
+		t.Fatal(err)
+	}
+	if calls := node.calls.Load(); calls != int64(len(hashes))+20 {
+		t.Errorf("made %d calls without a cache, want 10", calls-int64(len(hashes))-10)
+	}
+}
+
+// an ExecutionClient that can't make batch requests, like a custom RPC stack might be.
+type unbatchedClient struct {
+	ExecutionClient
+}
+
+func TestGetValidatorInfoWithoutBatching(t *testing.T) {
+	node := &fakePodNode{}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	eth := unbatchedClient{ethclient.NewClient(rpc.DialInProc(server))}
+
+	hashes := [][32]byte{{31: 7}, {31: 9}}
+	infos, err := GetValidatorInfoByPubkeyHash(context.Background(), eth, "0x000000000000000000000000000000000000b0d6", hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if infos[0].ValidatorIndex != 7 || infos[1].ValidatorIndex != 9 {
+		t.Errorf("got %+v", infos)
+	}
+}
+
+func TestGetValidatorInfoFallsBackWithoutBatchRequests(t *testing.T) {
+	node := &fakePodNode{}
+	eth, requests := serveFakePodNode(t, node, false)
+
+	hashes := [][32]byte{{31: 7}, {31: 9}, {31: 11}}
+	infos, err := GetValidatorInfoByPubkeyHash(context.Background(), eth, "0x000000000000000000000000000000000000b0d7", hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if infos[0].ValidatorIndex != 7 || infos[1].ValidatorIndex != 9 || infos[2].ValidatorIndex != 11 {
+		t.Errorf("got %+v", infos)
+	}
+	// the rejected batch, then one call at a time
+	if n := requests.Load(); n != int64(1+len(hashes)) {
+		t.Errorf("made %d requests, want %d", n, 1+len(hashes))
+	}
+}
+
+func TestGetValidatorInfoReturnsContextErrors(t *testing.T) {
+	node := &fakePodNode{}
+	eth, requests := serveFakePodNode(t, node, true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := GetValidatorInfoByPubkeyHash(ctx, eth, "0x000000000000000000000000000000000000b0d8", [][32]byte{{31: 1}, {31: 2}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the context's error, got %v", err)
+	}
+	// and not retried one call at a time
+	if n := requests.Load(); n != 0 {
+		t.Errorf("made %d requests after the context was canceled", n)
+	}
+}
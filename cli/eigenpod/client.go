@@ -0,0 +1,261 @@
+// Package eigenpod is a Go client for EigenPod operations: reading a pod's status, planning checkpoints and
+// credential proofs, and submitting those plans. It's what the CLI does, for programs that would otherwise
+// depend on cli/core.
+//
+//	client, err := eigenpod.Dial(ctx, execNode, beaconNode, eigenpod.WithSender("env:POD_OWNER_KEY"))
+//	defer client.Close()
+//	plan, err := client.PlanCheckpoint(ctx, pod)
+//	txns, err := client.Submit(ctx, plan)
+//
+// Nothing prompts or exits the process, and nothing is printed or written to disk unless asked for (see
+// WithVerboseLogging, WithLogger and WithJournalDir). Plans are the same files as `--plan` writes and `apply` reads.
+package eigenpod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+
+	eigenpodproofs "github.com/Layr-Labs/eigenpod-proofs-generation"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type (
+	Status = core.EigenpodStatus
+	Plan   = core.Plan
+)
+
+// ErrNoSender is returned by methods that need a sender, when the client has none (see WithSender).
+var ErrNoSender = errors.New("eigenpod: no sender (see WithSender)")
+
+// ErrNoBeaconClient is returned by methods that need beacon state, when the client has no beacon client.
+var ErrNoBeaconClient = errors.New("eigenpod: no beacon client (see WithBeaconClient)")
+
+// Client runs EigenPod operations against an execution node and a beacon node. It's safe for concurrent use.
+type Client struct {
+	eth     core.ExecutionClient
+	beacon  core.BeaconClient
+	chainId *big.Int
+	proofs  *eigenpodproofs.EigenPodProofs
+
+	sender       string
+	signer       core.Signer
+	signerConfig core.SignerConfig
+	txConfig     core.TxConfig
+	tracing      *core.TracerCallbacks
+	onSent       func(description string, txn *types.Transaction)
+	batchSize    uint64
+	verbose      bool
+	logger       *slog.Logger
+	journalDir   string
+
+	// what Dial opened
+	resources *core.Resources
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithExecutionClient sets the execution node. Required by New.
+func WithExecutionClient(eth core.ExecutionClient) Option {
+	return func(c *Client) { c.eth = eth }
+}
+
+// WithBeaconClient sets the beacon node, which is needed for anything that reads beacon state.
+func WithBeaconClient(beacon core.BeaconClient) Option {
+	return func(c *Client) { c.beacon = beacon }
+}
+
+// WithSender sets the account plans are made for and submitted from, in any form `--sender` accepts: a hex
+// private key, `env:VAR`, `file:path`, `keystore:path`, a remote signer URL, or `safe:address` (plans only).
+func WithSender(sender string) Option {
+	return func(c *Client) { c.sender = sender }
+}
+
+// WithSigner sets the account plans are made for and submitted from to `signer`'s, and signs with it. It
+// replaces WithSender.
+func WithSigner(signer core.Signer) Option {
+	return func(c *Client) {
+		c.signer = signer
+		c.sender = core.SignerSender(signer)
+	}
+}
+
+// WithSignerConfig sets how a keystore sender is unlocked.
+func WithSignerConfig(config core.SignerConfig) Option {
+	return func(c *Client) { c.signerConfig = config }
+}
+
+// WithTxConfig sets how transactions are priced and pipelined when a plan is submitted.
+func WithTxConfig(config core.TxConfig) Option {
+	return func(c *Client) { c.txConfig = config }
+}
+
+// WithProver sets the prover, e.g to share its cache of beacon state trees between clients. By default, each
+// client has its own, caching trees for 5 minutes.
+func WithProver(proofs *eigenpodproofs.EigenPodProofs) Option {
+	return func(c *Client) { c.proofs = proofs }
+}
+
+// WithBatchSize sets how many proofs go in a transaction. By default (0), batches are sized by gas.
+func WithBatchSize(batchSize uint64) Option {
+	return func(c *Client) { c.batchSize = batchSize }
+}
+
+// WithVerboseLogging turns on the progress logging the CLI prints with `--verbose`, printing it to stdout.
+func WithVerboseLogging() Option {
+	return func(c *Client) { c.verbose = true }
+}
+
+// WithLogger turns on the progress logging the CLI prints with `--verbose`, sending it to `logger` instead.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.verbose = true
+		c.logger = logger
+	}
+}
+
+// WithJournalDir keeps a journal of each checkpoint Submit sends proofs for in `dir`, like the CLI's
+// `checkpoint-<pod>-<timestamp>.journal.json`. Proofs the journal has as landed aren't sent again. Off by default.
+func WithJournalDir(dir string) Option {
+	return func(c *Client) { c.journalDir = dir }
+}
+
+// WithOnSent sets a callback for each transaction Submit sends, before it's mined.
+func WithOnSent(onSent func(description string, txn *types.Transaction)) Option {
+	return func(c *Client) { c.onSent = onSent }
+}
+
+// WithTracing sets callbacks for the sections (e.g fetching state, generating proofs) operations go through.
+// tracing.Callbacks records them as OpenTelemetry spans.
+func WithTracing(tracing *core.TracerCallbacks) Option {
+	return func(c *Client) { c.tracing = tracing }
+}
+
+// New creates a client. It needs an execution client (see WithExecutionClient), which it reads the chain from.
+func New(ctx context.Context, opts ...Option) (*Client, error) {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.eth == nil {
+		return nil, errors.New("eigenpod: no execution client (see WithExecutionClient)")
+	}
+
+	chainId, err := c.eth.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("eigenpod: failed to fetch chain id: %w", err)
+	}
+	c.chainId = chainId
+
+	if c.proofs == nil {
+		c.proofs, err = eigenpodproofs.NewEigenPodProofs(chainId.Uint64(), 300 /* oracleStateCacheExpirySeconds - 5min */)
+		if err != nil {
+			return nil, fmt.Errorf("eigenpod: failed to initialize prover: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Dial connects to an execution node and a beacon node by URL, and creates a client for them. Clients set by
+// `opts` take precedence. Close the client to release the connections.
+func Dial(ctx context.Context, execNode, beaconNode string, opts ...Option) (*Client, error) {
+	resources := &core.Resources{}
+	eth, beacon, _, err := core.GetClients(core.ContextWithResources(ctx, resources), execNode, beaconNode, false /* enableLogs */)
+	if err != nil {
+		return nil, fmt.Errorf("eigenpod: %w", err)
+	}
+	c, err := New(ctx, append([]Option{WithExecutionClient(eth), WithBeaconClient(beacon)}, opts...)...)
+	if err != nil {
+		_ = resources.Close()
+		return nil, err
+	}
+	c.resources = resources
+	return c, nil
+}
+
+// Close releases the connections Dial opened. Clients passed in with options are left open.
+func (c *Client) Close() error {
+	if c.resources == nil {
+		return nil
+	}
+	return c.resources.Close()
+}
+
+// ChainId is the chain the execution node is on.
+func (c *Client) ChainId() *big.Int {
+	return new(big.Int).Set(c.chainId)
+}
+
+// the context core reads the client's configuration from.
+func (c *Client) context(ctx context.Context) context.Context {
+	ctx = core.ContextWithSignerConfig(ctx, c.signerConfig)
+	ctx = core.ContextWithTxConfig(ctx, c.txConfig)
+	ctx = core.ContextWithJournalDir(ctx, c.journalDir)
+	if c.signer != nil {
+		ctx = core.ContextWithSigner(ctx, c.signer)
+	}
+	if c.logger != nil {
+		ctx = core.ContextWithLogger(ctx, c.logger)
+	}
+	if c.tracing != nil {
+		ctx = core.ContextWithTracing(ctx, c.tracing)
+	}
+	// validator infos are cached for one call, so a later call sees the pod as it is then.
+	if core.GetContextValidatorInfoCache(ctx) == nil {
+		ctx = core.ContextWithValidatorInfoCache(ctx, core.NewValidatorInfoCache())
+	}
+	return ctx
+}
+
+// Status reads the pod's status: its validators, checkpoint, and (estimated) shares.
+func (c *Client) Status(ctx context.Context, pod common.Address) (Status, error) {
+	if c.beacon == nil {
+		return Status{}, ErrNoBeaconClient
+	}
+	return core.GetStatus(c.context(ctx), pod.Hex(), c.eth, c.beacon)
+}
+
+// PlanCheckpoint plans a checkpoint of the pod: starting one (unless one is active) and proving every
+// validator's balance. Proofs for a checkpoint that hasn't started are generated when the plan is submitted.
+func (c *Client) PlanCheckpoint(ctx context.Context, pod common.Address) (*Plan, error) {
+	if c.sender == "" {
+		return nil, ErrNoSender
+	}
+	if c.beacon == nil {
+		return nil, ErrNoBeaconClient
+	}
+	return core.PlanCheckpoint(c.context(ctx), pod.Hex(), c.sender, c.eth, c.chainId, c.beacon, c.proofs, c.batchSize, false /* forceCheckpoint */, c.verbose)
+}
+
+// ProveCredentials plans verifying the withdrawal credentials of the pod's validators at `indices`, or of every
+// validator awaiting a credential proof if there are none.
+func (c *Client) ProveCredentials(ctx context.Context, pod common.Address, indices ...uint64) (*Plan, error) {
+	if c.sender == "" {
+		return nil, ErrNoSender
+	}
+	if c.beacon == nil {
+		return nil, ErrNoBeaconClient
+	}
+	return core.PlanCredentials(c.context(ctx), pod.Hex(), c.sender, c.eth, c.chainId, c.beacon, c.proofs, indices, c.batchSize, c.verbose)
+}
+
+// Submit sends a plan's transactions, in order, and waits for them to be mined. It refuses to if the plan was
+// made for another chain or sender, or the pod has changed since. The transactions sent before an error are
+// returned with it. Checkpoint proofs are journaled if the client has a journal directory (see WithJournalDir).
+func (c *Client) Submit(ctx context.Context, plan *Plan) ([]*types.Transaction, error) {
+	if c.sender == "" {
+		return nil, ErrNoSender
+	}
+	for _, step := range plan.Steps {
+		// deferred steps are generated from beacon state
+		if step.Deferred && c.beacon == nil {
+			return nil, ErrNoBeaconClient
+		}
+	}
+	return core.ApplyPlan(c.context(ctx), plan, c.sender, c.eth, c.chainId, c.beacon, c.proofs, c.batchSize, true /* noPrompt */, c.verbose, c.onSent)
+}
@@ -0,0 +1,105 @@
+package eigenpod
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type chainIdNode struct{}
+
+func (chainIdNode) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(17000))
+}
+
+func dialChainIdNode(t *testing.T) *ethclient.Client {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", chainIdNode{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+	return ethclient.NewClient(rpc.DialInProc(server))
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := New(ctx); err == nil {
+		t.Fatal("expected an error without an execution client")
+	}
+
+	eth := dialChainIdNode(t)
+
+	client, err := New(ctx, WithExecutionClient(eth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.ChainId().Uint64() != 17000 {
+		t.Errorf("unexpected chain id %s", client.ChainId())
+	}
+
+	pod := common.HexToAddress("0x000000000000000000000000000000000000b0d5")
+	if _, err := client.Status(ctx, pod); !errors.Is(err, ErrNoBeaconClient) {
+		t.Errorf("expected ErrNoBeaconClient, got %v", err)
+	}
+	if _, err := client.PlanCheckpoint(ctx, pod); !errors.Is(err, ErrNoSender) {
+		t.Errorf("expected ErrNoSender, got %v", err)
+	}
+	if _, err := client.Submit(ctx, &Plan{}); !errors.Is(err, ErrNoSender) {
+		t.Errorf("expected ErrNoSender, got %v", err)
+	}
+}
+
+// signs for a fixed address, without a key.
+type fakeSigner struct{}
+
+func (fakeSigner) Address() common.Address {
+	return common.HexToAddress("0x000000000000000000000000000000000000a11c")
+}
+
+func (fakeSigner) SignTx(_ context.Context, txn *types.Transaction, _ *big.Int) (*types.Transaction, error) {
+	return txn, nil
+}
+
+func TestClientOptions(t *testing.T) {
+	ctx := context.Background()
+	pod := "0x000000000000000000000000000000000000b0d5"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	client, err := New(ctx, WithExecutionClient(dialChainIdNode(t)), WithSigner(fakeSigner{}), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	callCtx := client.context(ctx)
+
+	signer, err := core.LoadSigner(callCtx, client.sender)
+	if err != nil || signer.Address() != (fakeSigner{}).Address() {
+		t.Errorf("expected the client's signer, got %v (%v)", signer, err)
+	}
+	if core.GetContextLogger(callCtx) != logger || !client.verbose {
+		t.Error("expected progress to be logged to the client's logger")
+	}
+	// nothing is written to the working directory unless asked for
+	if path := core.GetContextJournalPath(callCtx, pod, 100); path != "" {
+		t.Errorf("expected no journal, got %s", path)
+	}
+
+	client, err = New(ctx, WithExecutionClient(dialChainIdNode(t)), WithJournalDir("journals"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path := core.GetContextJournalPath(client.context(ctx), pod, 100); path != filepath.Join("journals", core.DefaultCheckpointJournalPath(pod, 100)) {
+		t.Errorf("expected a journal in the directory, got %s", path)
+	}
+}
@@ -1,6 +1,14 @@
 package main
 
-import cli "github.com/urfave/cli/v2"
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/commands"
+	cli "github.com/urfave/cli/v2"
+)
 
 // Required for commands that need an EigenPod's address
 var PodAddressFlag = &cli.StringFlag{
@@ -17,7 +25,7 @@ var BeaconNodeFlag = &cli.StringFlag{
 	Name:        "beaconNode",
 	Aliases:     []string{"b"},
 	Value:       "",
-	Usage:       "[required] `URL` to a functioning beacon node RPC (https://)",
+	Usage:       "[required] `URL` to a functioning beacon node RPC (https://), or a directory of era files / beacon states (era:///path/to/dir, optionally with ?fallback=https://...)",
 	Required:    true,
 	Destination: &beacon,
 }
@@ -39,7 +47,7 @@ var SenderPkFlag = &cli.StringFlag{
 	Name:        "sender",
 	Aliases:     []string{"s"},
 	Value:       "",
-	Usage:       "`Private key` of the account that will send any transactions. If set, this will automatically submit the proofs to their corresponding onchain functions after generation. If using checkpoint mode, it will also begin a checkpoint if one hasn't been started already.",
+	Usage:       "The account that will send any transactions: a hex `private key`, env:<VAR> or file:<path> holding one, keystore:<path> to an encrypted JSON keystore, or the http(s):// URL of a remote signer (e.g Web3Signer or Clef, with #<address> to pick an account). If set, this will automatically submit the proofs to their corresponding onchain functions after generation. If using checkpoint mode, it will also begin a checkpoint if one hasn't been started already.",
 	Destination: &sender,
 }
 
@@ -60,12 +68,44 @@ var PrintJSONFlag = &cli.BoolFlag{
 	Destination: &useJSON,
 }
 
+// Optional use for commands that can save a proof instead of submitting it
+var OutputFlag = &cli.StringFlag{
+	Name:        "out",
+	Aliases:     []string{"O"},
+	Value:       "",
+	Usage:       "Write the generated proof to `file` instead of submitting it. Submit it later with `submit <file>`.",
+	Destination: &output,
+}
+
+// Optional use for multi-step commands, to review what they'll do before `apply`ing it
+var PlanFlag = &cli.StringFlag{
+	Name:        "plan",
+	Value:       "",
+	Usage:       "Write every transaction this would send (with calldata, estimated gas, and the pod state it assumes) to `file`, instead of sending them. Execute it with `apply <file>`.",
+	Destination: &planFile,
+}
+
+var SafeFlag = &cli.StringFlag{
+	Name:        "safe",
+	Value:       "",
+	Usage:       "Print the transactions as a Safe Transaction Builder batch for the Safe at `address` (which owns the pod), instead of sending them.",
+	Destination: &safeAddress,
+}
+
+var FleetConfigFlag = &cli.StringFlag{
+	Name:        "config",
+	Aliases:     []string{"c"},
+	Usage:       "[required] JSON `file` listing the fleet's pods, and their senders and policies",
+	Required:    true,
+	Destination: &fleetConfig,
+}
+
 // shared flag --batch
 func BatchBySize(destination *uint64, defaultValue uint64) *cli.Uint64Flag {
 	return &cli.Uint64Flag{
 		Name:        "batch",
 		Value:       defaultValue,
-		Usage:       "Submit proofs in groups of size `batchSize`, to avoid gas limit.",
+		Usage:       "Submit proofs in groups of size `batchSize`, to avoid gas limit. By default (0), batches are sized by estimating their gas against --gasTarget.",
 		Required:    false,
 		Destination: destination,
 	}
@@ -82,3 +122,173 @@ func Require(flag *cli.StringFlag) *cli.StringFlag {
 		Required:    true,
 	}
 }
+
+// Gives every flag of the app, and of its commands, an environment variable (see commands.EnvVar). Returns the
+// names of every flag, and of the app's own (global) flags.
+func bindEnvVars(app *cli.App) (all []string, global map[string]bool) {
+	names := map[string]bool{}
+	bind := func(flags []cli.Flag) {
+		for _, flag := range flags {
+			name := flag.Names()[0]
+			names[name] = true
+			envVars := []string{commands.EnvVar(name)}
+			switch f := flag.(type) {
+			case *cli.StringFlag:
+				f.EnvVars = envVars
+			case *cli.BoolFlag:
+				f.EnvVars = envVars
+			case *cli.Uint64Flag:
+				f.EnvVars = envVars
+			case *cli.Float64Flag:
+				f.EnvVars = envVars
+			}
+		}
+	}
+	var bindCommands func(cmds []*cli.Command)
+	bindCommands = func(cmds []*cli.Command) {
+		for _, cmd := range cmds {
+			bind(cmd.Flags)
+			bindCommands(cmd.Subcommands)
+		}
+	}
+
+	bind(app.Flags)
+	global = map[string]bool{}
+	for _, flag := range app.Flags {
+		global[flag.Names()[0]] = true
+	}
+	bindCommands(app.Commands)
+
+	for name := range names {
+		all = append(all, name)
+	}
+	return all, global
+}
+
+// Fills in the flags the command line and environment leave unset from the profile. Global flags are parsed before
+// the profile is loaded, so are set directly; the rest once their command has parsed its own, in its Before.
+func applyProfile(c *cli.Context, profile *commands.Profile, global map[string]bool) error {
+	for name := range profile.Values {
+		if global[name] && !c.IsSet(name) {
+			if err := setFromProfile(c, profile, name); err != nil {
+				return err
+			}
+		}
+	}
+	applyProfileToCommands(c.App.Commands, profile)
+	return nil
+}
+
+func setFromProfile(c *cli.Context, profile *commands.Profile, name string) error {
+	if err := c.Set(name, profile.Values[name]); err != nil {
+		return fmt.Errorf("%s: invalid %s: %w", profile.Sources[name], name, err)
+	}
+	return nil
+}
+
+// Sets the flags of each command that the profile holds, before it runs. A command's required flags are checked
+// before its Before, so those the profile holds are no longer required of the command line.
+func applyProfileToCommands(cmds []*cli.Command, profile *commands.Profile) {
+	for _, cmd := range cmds {
+		names := []string{}
+		for i, flag := range cmd.Flags {
+			name := flag.Names()[0]
+			if _, ok := profile.Values[name]; ok {
+				names = append(names, name)
+				cmd.Flags[i] = optional(flag)
+			}
+		}
+		if len(names) > 0 {
+			before := cmd.Before
+			cmd.Before = func(c *cli.Context) error {
+				for _, name := range names {
+					if !c.IsSet(name) {
+						if err := setFromProfile(c, profile, name); err != nil {
+							return err
+						}
+					}
+				}
+				if before != nil {
+					return before(c)
+				}
+				return nil
+			}
+		}
+		applyProfileToCommands(cmd.Subcommands, profile)
+	}
+}
+
+// The opposite of Require: a copy of a flag that isn't required
+func optional(flag cli.Flag) cli.Flag {
+	switch f := flag.(type) {
+	case *cli.StringFlag:
+		if f.Required {
+			copied := *f
+			copied.Required = false
+			return &copied
+		}
+	case *cli.Uint64Flag:
+		if f.Required {
+			copied := *f
+			copied.Required = false
+			return &copied
+		}
+	}
+	return flag
+}
+
+// Whether a global flag was passed on the command line, rather than set by its environment variable (which the
+// flag's context doesn't tell apart).
+func onCommandLine(c *cli.Context, name string) bool {
+	if _, ok := os.LookupEnv(commands.EnvVar(name)); !ok {
+		return slices.Contains(c.LocalFlagNames(), name)
+	}
+	flag := c.App.Flags[slices.IndexFunc(c.App.Flags, func(f cli.Flag) bool { return f.Names()[0] == name })]
+	// the app's own flags come before the command, and its arguments
+	for _, arg := range os.Args[1 : len(os.Args)-c.Args().Len()] {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		arg, _, _ = strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if slices.Contains(flag.Names(), arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrapActions brackets every command's action with commands.BeginCommand and commands.EndCommand, which print
+// its result with `--output json`.
+func wrapActions(cmds []*cli.Command, prefix string) {
+	for _, cmd := range cmds {
+		name := prefix + cmd.Name
+		if action := cmd.Action; action != nil {
+			cmd.Action = func(c *cli.Context) error {
+				commands.BeginCommand(name)
+				return commands.EndCommand(action(c))
+			}
+		}
+		if len(cmd.Subcommands) > 0 {
+			subcommands, before := cmd.Subcommands, cmd.Before
+			cmd.Before = func(c *cli.Context) error {
+				nameCommand(subcommands, name+" ", c.Args())
+				if before != nil {
+					return before(c)
+				}
+				return nil
+			}
+		}
+		wrapActions(cmd.Subcommands, name+" ")
+	}
+}
+
+// nameCommand names the command among `cmds` that `args` run, if any. A command's required flags are checked
+// before anything of its own runs, so this is called by its parent (or the app) before it's dispatched.
+func nameCommand(cmds []*cli.Command, prefix string, args cli.Args) {
+	for _, cmd := range cmds {
+		if cmd.HasName(args.First()) {
+			commands.NameCommand(prefix + cmd.Name)
+			return
+		}
+	}
+}
@@ -1,11 +1,19 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"math/big"
 	"os"
+	"slices"
 
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/commands"
-	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/utils"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/tracing"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/rpcreplay"
+	"github.com/ethereum/go-ethereum/params"
 	cli "github.com/urfave/cli/v2"
 )
 
@@ -15,6 +23,14 @@ var useJSON = false
 var specificValidator uint64 = math.MaxUint64
 var estimateGas = false
 var slashedValidatorIndex uint64
+var fixturesDir, scenariosFile string
+var mockPort, mockChainId uint64
+var output string
+var resume = false
+var journalFile string
+var planFile string
+var safeAddress string
+var fleetConfig string
 
 const DefaultHealthcheckTolerance = float64(5.0)
 
@@ -25,6 +41,31 @@ func main() {
 	var verbose = false
 	var noPrompt = false
 	var tolerance = DefaultHealthcheckTolerance
+	var recordFile, replayFile string
+	var chainsFile string
+	var configFile, profileName string
+	var settings []string
+	var globalSettings map[string]bool
+	var maxInFlight, gasTarget uint64
+	var maxFeeGwei, tipPercentile float64
+	var bumpAfterBlocks, feeBumpPercent uint64
+	var noPreflight, dropFailing bool
+	var passwordFile string
+	var outputFormat string
+	var traceTarget string
+
+	fleetArgs := func() commands.TFleetCommandArgs {
+		return commands.TFleetCommandArgs{
+			ConfigFile:   fleetConfig,
+			Node:         node,
+			BeaconNode:   beacon,
+			Sender:       sender,
+			UseJSON:      useJSON,
+			DisableColor: disableColor,
+			NoPrompt:     noPrompt,
+			Verbose:      verbose,
+		}
+	}
 
 	app := &cli.App{
 		Name:                   "Eigenlayer Proofs CLi",
@@ -32,6 +73,86 @@ func main() {
 		Usage:                  "Generates proofs to (1) checkpoint your validators, or (2) verify the withdrawal credentials of an inactive validator. By default, the unsigned transactions will be printed to stdout as JSON. If you want to sign and broadcast these automatically, pass `--sender <pk>`.",
 		EnableBashCompletion:   true,
 		UseShortOptionHandling: true,
+		Before: func(c *cli.Context) error {
+			nameCommand(c.App.Commands, "", c.Args())
+
+			// the profile goes first, so it can set any of the settings below
+			profile, err := commands.LoadProfile(configFile, profileName, c.IsSet("configFile"))
+			if err != nil {
+				return err
+			}
+			for name := range profile.Values {
+				if !slices.Contains(settings, name) {
+					return fmt.Errorf("%s (%s): unknown setting %s", profile.Path, profile.Sources[name], name)
+				}
+			}
+			commands.SetResolvedConfig(profile, commands.ResolveSettings(profile, settings, func(name string) (string, bool) {
+				if !globalSettings[name] || !onCommandLine(c, name) {
+					return "", false
+				}
+				return fmt.Sprint(c.Value(name)), true
+			}))
+			if err := applyProfile(c, profile, globalSettings); err != nil {
+				return err
+			}
+			if err := commands.SetOutputFormat(outputFormat); err != nil {
+				return err
+			}
+			if outputFormat == commands.OutputJSON {
+				c.App.Writer = os.Stderr
+			}
+			if traceTarget != "" {
+				// stdout is for results
+				provider, err := tracing.NewTracerProvider(c.Context, traceTarget, os.Stderr)
+				if err != nil {
+					return err
+				}
+				commands.SetTracing(tracing.Callbacks(provider.Tracer(tracing.ServiceName)), provider.Shutdown)
+			}
+
+			if recordFile != "" && replayFile != "" {
+				return errors.New("--record and --replay cannot be used together")
+			}
+			if recordFile != "" {
+				recorder, err := rpcreplay.NewRecorder(recordFile)
+				if err != nil {
+					return err
+				}
+				commands.SetRPCInterceptor(recorder)
+			}
+			if replayFile != "" {
+				replayer, err := rpcreplay.LoadReplayer(replayFile)
+				if err != nil {
+					return err
+				}
+				commands.SetRPCInterceptor(replayer)
+			}
+			if chainsFile != "" {
+				if _, err := chains.LoadFile(chainsFile); err != nil {
+					return err
+				}
+			}
+			if tipPercentile < 0 || tipPercentile > 100 {
+				return errors.New("--tipPercentile must be between 0 and 100")
+			}
+			fees := core.FeePolicy{
+				TipPercentile:   tipPercentile,
+				BumpAfterBlocks: bumpAfterBlocks,
+				BumpPercent:     feeBumpPercent,
+			}
+			if maxFeeGwei > 0 {
+				fees.MaxFeePerGas, _ = new(big.Float).Mul(big.NewFloat(maxFeeGwei), big.NewFloat(params.GWei)).Int(nil)
+			}
+			commands.SetSignerConfig(core.SignerConfig{PasswordFile: passwordFile})
+			commands.SetTxConfig(core.TxConfig{
+				MaxInFlight:       int(maxInFlight),
+				Fees:              fees,
+				GasTarget:         gasTarget,
+				SkipPreflight:     noPreflight,
+				DropFailingProofs: dropFailing,
+			})
+			return nil
+		},
 		Commands: []*cli.Command{
 			{
 				Name:      "find-stale-pods",
@@ -66,8 +187,10 @@ func main() {
 					PodAddressFlag,
 					ExecNodeFlag,
 					BeaconNodeFlag,
-					BatchBySize(&batchSize, utils.DEFAULT_BATCH_CHECKPOINT),
-					Require(SenderPkFlag),
+					BatchBySize(&batchSize, 0),
+					SenderPkFlag,
+					SafeFlag,
+					PlanFlag,
 					&cli.Uint64Flag{
 						Name:        "validatorIndex",
 						Usage:       "The index of a validator slashed that belongs to the pod.",
@@ -85,6 +208,8 @@ func main() {
 						Verbose:               verbose,
 						CheckpointBatchSize:   batchSize,
 						NoPrompt:              noPrompt,
+						Plan:                  planFile,
+						Safe:                  safeAddress,
 					})
 				},
 			},
@@ -96,7 +221,8 @@ func main() {
 				Flags: []cli.Flag{
 					PodAddressFlag,
 					ExecNodeFlag,
-					Require(SenderPkFlag),
+					SenderPkFlag,
+					SafeFlag,
 				},
 				Action: func(cctx *cli.Context) error {
 					return commands.AssignSubmitterCommand(commands.TAssignSubmitterArgs{
@@ -106,6 +232,7 @@ func main() {
 						EigenpodAddress: eigenpodAddress,
 						NoPrompt:        noPrompt,
 						Verbose:         verbose,
+						Safe:            safeAddress,
 					})
 				},
 			},
@@ -139,7 +266,10 @@ func main() {
 					ExecNodeFlag,
 					SenderPkFlag,
 					EstimateGasFlag,
-					BatchBySize(&batchSize, utils.DEFAULT_BATCH_CHECKPOINT),
+					BatchBySize(&batchSize, 0),
+					OutputFlag,
+					PlanFlag,
+					SafeFlag,
 					&cli.BoolFlag{
 						Name:        "force",
 						Aliases:     []string{"f"},
@@ -147,6 +277,16 @@ func main() {
 						Usage:       "If true, starts a checkpoint even if the pod has no native ETH to award shares",
 						Destination: &forceCheckpoint,
 					},
+					&cli.BoolFlag{
+						Name:        "resume",
+						Usage:       "Continue an interrupted submission, skipping the proofs its journal (or the pod's ValidatorCheckpointed events) show as landed",
+						Destination: &resume,
+					},
+					&cli.StringFlag{
+						Name:        "journal",
+						Usage:       "The `file` to record submission progress in. Defaults to checkpoint-<podAddress>-<checkpointTimestamp>.journal.json",
+						Destination: &journalFile,
+					},
 				},
 				Action: func(_ *cli.Context) error {
 					return commands.CheckpointCommand(commands.TCheckpointCommandArgs{
@@ -160,6 +300,11 @@ func main() {
 						EigenpodAddress:     eigenpodAddress,
 						Verbose:             verbose,
 						Sender:              sender,
+						Out:                 output,
+						Resume:              resume,
+						JournalFile:         journalFile,
+						Plan:                planFile,
+						Safe:                safeAddress,
 					})
 				},
 			},
@@ -173,7 +318,9 @@ func main() {
 					ExecNodeFlag,
 					SenderPkFlag,
 					EstimateGasFlag,
-					BatchBySize(&batchSize, utils.DEFAULT_BATCH_CREDENTIALS),
+					BatchBySize(&batchSize, 0),
+					OutputFlag,
+					SafeFlag,
 					&cli.Uint64Flag{
 						Name:        "validatorIndex",
 						Usage:       "The `index` of a specific validator to prove (e.g a slashed validator for `verifyStaleBalance()`).",
@@ -193,6 +340,157 @@ func main() {
 						BatchSize:           batchSize,
 						NoPrompt:            noPrompt,
 						Verbose:             verbose,
+						Out:                 output,
+						Safe:                safeAddress,
+					})
+				},
+			},
+			{
+				Name:      "submit",
+				Args:      true,
+				Usage:     "Submits a proof saved earlier with `checkpoint --out` or `credentials --out`.",
+				UsageText: "./cli submit [FLAGS] <proof.json>",
+				Flags: []cli.Flag{
+					PodAddressFlag,
+					ExecNodeFlag,
+					SenderPkFlag,
+					EstimateGasFlag,
+					BatchBySize(&batchSize, 0),
+				},
+				Action: func(cctx *cli.Context) error {
+					return commands.SubmitCommand(commands.TSubmitCommandArgs{
+						ProofFile:           cctx.Args().First(),
+						EigenpodAddress:     eigenpodAddress,
+						Node:                node,
+						Sender:              sender,
+						DisableColor:        disableColor,
+						NoPrompt:            noPrompt,
+						SimulateTransaction: sender == "" || estimateGas,
+						BatchSize:           batchSize,
+						Verbose:             verbose,
+					})
+				},
+			},
+			{
+				Name:      "apply",
+				Args:      true,
+				Usage:     "Executes a plan written by `checkpoint --plan` or `correct-stale-pod --plan`, if the pod is still in the state it was planned against.",
+				UsageText: "./cli apply [FLAGS] <plan.json>",
+				Flags: []cli.Flag{
+					ExecNodeFlag,
+					BeaconNodeFlag,
+					Require(SenderPkFlag),
+					BatchBySize(&batchSize, 0),
+				},
+				Action: func(cctx *cli.Context) error {
+					return commands.ApplyCommand(commands.TApplyCommandArgs{
+						PlanFile:     cctx.Args().First(),
+						Node:         node,
+						BeaconNode:   beacon,
+						Sender:       sender,
+						BatchSize:    batchSize,
+						DisableColor: disableColor,
+						NoPrompt:     noPrompt,
+						Verbose:      verbose,
+					})
+				},
+			},
+			{
+				Name:  "config",
+				Usage: "Shows the settings in effect (from flags, EIGENPOD_* environment variables and --configFile), with secrets redacted.",
+				Flags: []cli.Flag{
+					PrintJSONFlag,
+				},
+				Action: func(_ *cli.Context) error {
+					return commands.ConfigCommand(commands.TConfigCommandArgs{
+						UseJSON:      useJSON,
+						DisableColor: disableColor,
+					})
+				},
+			},
+			{
+				Name:  "fleet",
+				Usage: "Operates every pod in a fleet config at once, fetching each beacon state needed only once.",
+				Subcommands: []*cli.Command{
+					{
+						Name:  "status",
+						Usage: "Checks the status of every pod.",
+						Flags: []cli.Flag{
+							FleetConfigFlag,
+							BeaconNodeFlag,
+							ExecNodeFlag,
+							PrintJSONFlag,
+						},
+						Action: func(_ *cli.Context) error {
+							return commands.FleetStatusCommand(fleetArgs())
+						},
+					},
+					{
+						Name:  "checkpoint",
+						Usage: "Completes every pod's active checkpoint, starting checkpoints first on pods whose policy has `startCheckpoint`.",
+						Flags: []cli.Flag{
+							FleetConfigFlag,
+							BeaconNodeFlag,
+							ExecNodeFlag,
+							SenderPkFlag,
+							PrintJSONFlag,
+						},
+						Action: func(_ *cli.Context) error {
+							return commands.FleetCheckpointCommand(fleetArgs())
+						},
+					},
+					{
+						Name:  "credentials",
+						Usage: "Verifies the withdrawal credentials of every pod's inactive validators.",
+						Flags: []cli.Flag{
+							FleetConfigFlag,
+							BeaconNodeFlag,
+							ExecNodeFlag,
+							SenderPkFlag,
+							PrintJSONFlag,
+						},
+						Action: func(_ *cli.Context) error {
+							return commands.FleetCredentialsCommand(fleetArgs())
+						},
+					},
+				},
+			},
+			{
+				Name:      "mock-beacon-node",
+				Usage:     "Serves beacon states and headers from fixture files over the beacon API, for testing without a network.",
+				UsageText: "./cli mock-beacon-node --fixtures <dir> [--scenarios <file>]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "fixtures",
+						Usage:       "[required] `directory` of beacon states (*.ssz, *.ssz_snappy) and block headers (*.json)",
+						Required:    true,
+						Destination: &fixturesDir,
+					},
+					&cli.StringFlag{
+						Name:        "scenarios",
+						Usage:       "JSON `file` of scripted failures (missing states, slow responses, reorged headers)",
+						Destination: &scenariosFile,
+					},
+					&cli.Uint64Flag{
+						Name:        "port",
+						Value:       5052,
+						Usage:       "The `port` to listen on",
+						Destination: &mockPort,
+					},
+					&cli.Uint64Flag{
+						Name:        "chainId",
+						Value:       17000,
+						Usage:       "The chain `id` reported by the mock node",
+						Destination: &mockChainId,
+					},
+				},
+				Action: func(_ *cli.Context) error {
+					return commands.MockBeaconNodeCommand(commands.TMockBeaconNodeArgs{
+						FixturesDir:   fixturesDir,
+						ScenariosFile: scenariosFile,
+						Port:          mockPort,
+						ChainID:       mockChainId,
+						Verbose:       verbose,
 					})
 				},
 			},
@@ -217,10 +515,99 @@ func main() {
 				Usage:       "Enable verbose output.",
 				Destination: &verbose,
 			},
+			&cli.StringFlag{
+				Name:        "record",
+				Usage:       "Record all execution and beacon node traffic to `file`, for use with --replay.",
+				Destination: &recordFile,
+			},
+			&cli.StringFlag{
+				Name:        "replay",
+				Usage:       "Serve execution and beacon node responses from a `file` written by --record, instead of contacting the nodes.",
+				Destination: &replayFile,
+			},
+			&cli.StringFlag{
+				Name:        "configFile",
+				Value:       commands.DefaultConfigPath(),
+				Usage:       "Read settings (any flag, by name) from the profiles in this YAML `file`. Flags and EIGENPOD_* environment variables take precedence.",
+				Destination: &configFile,
+			},
+			&cli.StringFlag{
+				Name:        "profile",
+				Usage:       "The `name` of the profile to use from --configFile, instead of the file's default.",
+				Destination: &profileName,
+			},
+			&cli.StringFlag{
+				Name:        "chains",
+				Usage:       "Load chains (e.g devnets) besides mainnet, holesky, hoodi and sepolia from a JSON `file`.",
+				Destination: &chainsFile,
+			},
+			&cli.Uint64Flag{
+				Name:        "inFlight",
+				Value:       core.DEFAULT_MAX_IN_FLIGHT,
+				Usage:       "The maximum `number` of proof transactions to have pending at once.",
+				Destination: &maxInFlight,
+			},
+			&cli.Uint64Flag{
+				Name:        "gasTarget",
+				Value:       core.DEFAULT_GAS_TARGET,
+				Usage:       "Unless --batch is set, proofs are batched into the fewest transactions that each use under `gas` (and the block gas limit).",
+				Destination: &gasTarget,
+			},
+			&cli.Float64Flag{
+				Name:        "maxFeePerGas",
+				Usage:       "Never send (or replace) a transaction with a max fee above `gwei` per gas. Commands fail rather than exceed it.",
+				Destination: &maxFeeGwei,
+			},
+			&cli.Float64Flag{
+				Name:        "tipPercentile",
+				Usage:       "Tip the `percentile` of recent priority fees (from eth_feeHistory), instead of the node's suggestion.",
+				Destination: &tipPercentile,
+			},
+			&cli.Uint64Flag{
+				Name:        "bumpAfterBlocks",
+				Usage:       "Replace a transaction that has been pending for `n` blocks with one paying higher fees (0 disables).",
+				Destination: &bumpAfterBlocks,
+			},
+			&cli.Uint64Flag{
+				Name:        "feeBump",
+				Value:       core.DEFAULT_FEE_BUMP_PERCENT,
+				Usage:       "How much more (in `percent`, at least 10) a replacement transaction pays.",
+				Destination: &feeBumpPercent,
+			},
+			&cli.StringFlag{
+				Name:        "passwordFile",
+				Usage:       "Read the passphrase of a keystore:<path> --sender from `file`, instead of prompting for it.",
+				Destination: &passwordFile,
+			},
+			&cli.StringFlag{
+				Name:        "output",
+				Value:       commands.OutputText,
+				Usage:       "`text`, or `json` to print only a versioned JSON result (or error) to stdout, with logs on stderr.",
+				Destination: &outputFormat,
+			},
+			&cli.StringFlag{
+				Name:        "trace",
+				Usage:       "Trace each command (fetching states, proving, each batch submitted, ...) with OpenTelemetry: `stdout` (printed to stderr), `otlp` (configured by OTEL_EXPORTER_OTLP_* environment variables), or an OTLP/HTTP collector URL.",
+				Destination: &traceTarget,
+			},
+			&cli.BoolFlag{
+				Name:        "noPreflight",
+				Usage:       "Don't simulate each proof transaction (with eth_call, from --sender) before sending it.",
+				Destination: &noPreflight,
+			},
+			&cli.BoolFlag{
+				Name:        "dropFailing",
+				Usage:       "Leave out proofs that would revert in pre-flight simulation and submit the rest, instead of failing.",
+				Destination: &dropFailing,
+			},
 		},
 	}
 
+	settings, globalSettings = bindEnvVars(app)
+	wrapActions(app.Commands, "")
 	if err := app.Run(os.Args); err != nil {
-		panic(err)
+		// errors from before a command ran (e.g a missing required flag), and those a command returned in text
+		// mode, are printed (and exit) here
+		commands.Panic(err.Error())
 	}
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// runs the CLI with `args` in a copy of the test binary, with no EIGENPOD_* environment variables or config file.
+func runCLI(t *testing.T, args ...string) (stdout, stderr []byte, exitCode int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRunCLI$")
+	cmd.Env = []string{"EIGENPOD_TEST_ARGS=" + strings.Join(args, "\n"), "HOME=" + t.TempDir(), "XDG_CONFIG_HOME=" + t.TempDir()}
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return out.Bytes(), errOut.Bytes(), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes(), errOut.Bytes(), 0
+}
+
+// the CLI, when run by runCLI.
+func TestRunCLI(t *testing.T) {
+	args, ok := os.LookupEnv("EIGENPOD_TEST_ARGS")
+	if !ok {
+		t.Skip("run by runCLI")
+	}
+	os.Args = append([]string{"eigenproofs"}, strings.Split(args, "\n")...)
+	main()
+	os.Exit(0)
+}
+
+func TestOutputErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		stderr string
+	}{
+		{
+			name:   "usage_error",
+			args:   []string{"--output", "json", "checkpoint", "--podAddress", "0x000000000000000000000000000000000000b0d5"},
+			stderr: "Required flags",
+		},
+		{
+			name:   "command_error",
+			args:   []string{"--output", "json", "status", "--podAddress", "0x000000000000000000000000000000000000b0d5", "--execNode", "http://127.0.0.1:1", "--beaconNode", "http://127.0.0.1:1"},
+			stderr: "error: failed to load ethereum clients",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stdout, stderr, exitCode := runCLI(t, test.args...)
+			assert.Equal(t, 1, exitCode, string(stderr))
+			assert.True(t, strings.Contains(string(stderr), test.stderr), string(stderr))
+
+			path := filepath.Join("testdata", test.name+".golden")
+			if *update {
+				if err := os.MkdirAll("testdata", 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, stdout, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, string(want), string(stdout))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Export targets for NewTracerProvider, besides a collector URL.
+const (
+	// Spans are written to a writer as JSON, one after another.
+	TraceStdout = "stdout"
+	// Spans are sent over OTLP/HTTP, configured by the standard OTEL_EXPORTER_OTLP_* environment variables
+	// (by default, to a collector on localhost:4318).
+	TraceOTLP = "otlp"
+)
+
+const ServiceName = "eigenpod-proofs-cli"
+
+// NewTracerProvider returns a TracerProvider that exports spans to `target`: TraceStdout (written to `w`),
+// TraceOTLP, or the http(s) URL of an OTLP/HTTP collector (e.g http://localhost:4318). Spans are exported in
+// batches; call Shutdown to flush them before exiting.
+func NewTracerProvider(ctx context.Context, target string, w io.Writer) (*sdktrace.TracerProvider, error) {
+	exporter, err := newExporter(ctx, target, w)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe trace resource: %w", err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	), nil
+}
+
+func newExporter(ctx context.Context, target string, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch {
+	case target == TraceStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(w))
+	case target == TraceOTLP:
+		return otlptracehttp.New(ctx)
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid collector URL %s: %w", target, err)
+		}
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+		if u.Path != "" && u.Path != "/" {
+			options = append(options, otlptracehttp.WithURLPath(u.Path))
+		}
+		if u.Scheme == "http" {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown trace target %s (expected %s, %s or a collector URL)", target, TraceStdout, TraceOTLP)
+	}
+}
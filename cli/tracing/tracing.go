@@ -0,0 +1,57 @@
+// Package tracing records the sections core reports through core.TracerCallbacks (fetching beacon states,
+// proving, submitting each checkpoint batch, ...) as OpenTelemetry spans, with their metadata as attributes:
+//
+//	provider, err := tracing.NewTracerProvider(ctx, tracing.TraceOTLP, os.Stderr)
+//	defer provider.Shutdown(ctx)
+//	ctx = core.ContextWithTracing(ctx, tracing.Callbacks(provider.Tracer("my-service")))
+//
+// Sections nest in whichever span is active in the context they're started from, so they can be made part of an
+// existing trace.
+package tracing
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Callbacks returns core.TracerCallbacks that start a span from `tracer` for each section, and end it with an
+// error status if the section failed.
+func Callbacks(tracer trace.Tracer) *core.TracerCallbacks {
+	return &core.TracerCallbacks{
+		// only for code still calling these directly
+		OnStartSection: func(name string, metadata map[string]string) {},
+		OnEndSection:   func() {},
+
+		OnStartSpan: func(ctx context.Context, name string, metadata map[string]string) context.Context {
+			ctx, _ = tracer.Start(ctx, name, trace.WithAttributes(attributes(metadata)...))
+			return ctx
+		},
+		OnEndSpan: func(ctx context.Context, err error) {
+			span := trace.SpanFromContext(ctx)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		},
+	}
+}
+
+func attributes(metadata map[string]string) []attribute.KeyValue {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]attribute.KeyValue, len(keys))
+	for i, key := range keys {
+		attrs[i] = attribute.String(key, metadata[key])
+	}
+	return attrs
+}
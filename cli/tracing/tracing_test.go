@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/cli/core"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestCallbacks(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx := core.ContextWithTracing(context.Background(), Callbacks(provider.Tracer("test")))
+	callbacks := core.GetContextTracingCallbacks(ctx)
+
+	submitCtx := callbacks.OnStartSpan(ctx, "pepe::proof::checkpoint::submit", map[string]string{"batches": "2"})
+	batchCtx := callbacks.OnStartSpan(submitCtx, "pepe::proof::checkpoint::batch::submit", map[string]string{"chunk": "1"})
+	callbacks.OnEndSpan(batchCtx, errors.New("execution reverted"))
+	callbacks.OnEndSpan(submitCtx, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	batch, submit := spans[0], spans[1]
+
+	if batch.Parent.SpanID() != submit.SpanContext.SpanID() {
+		t.Error("expected the batch span to be a child of the submit span")
+	}
+	if batch.Status.Code != codes.Error || batch.Status.Description != "execution reverted" {
+		t.Errorf("unexpected batch status %+v", batch.Status)
+	}
+	if len(batch.Events) != 1 || batch.Events[0].Name != "exception" {
+		t.Errorf("expected the error to be recorded, got %+v", batch.Events)
+	}
+	if submit.Status.Code != codes.Unset {
+		t.Errorf("unexpected submit status %+v", submit.Status)
+	}
+	if len(submit.Attributes) != 1 || submit.Attributes[0] != attribute.String("batches", "2") {
+		t.Errorf("unexpected submit attributes %v", submit.Attributes)
+	}
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	ctx := context.Background()
+	var out bytes.Buffer
+	provider, err := NewTracerProvider(ctx, TraceStdout, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := provider.Tracer("test").Start(ctx, "GetBeaconState")
+	span.End()
+	if err := provider.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"Name":"GetBeaconState"`) || !strings.Contains(out.String(), ServiceName) {
+		t.Errorf("expected the span to be written, got %s", out.String())
+	}
+
+	if _, err := NewTracerProvider(ctx, "jaeger", &out); err == nil {
+		t.Error("expected an error for an unknown target")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"math/bits"
 	"os"
 
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
 	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -98,8 +99,31 @@ func CeilLog2(x int) uint64 {
 	return uint64(math.Ceil(math.Log2(float64(x))))
 }
 
+// GetSlotTimestamp is the timestamp of `blockHeader`'s slot, on the chain `beaconState` is from (looked up by its
+// genesis time). It's 0 if the chain isn't registered.
+//
+// Deprecated: use GetSlotTimestampWithError, which says when the chain isn't registered, or
+// GetSlotTimestampOnChain.
 func GetSlotTimestamp(beaconState *deneb.BeaconState, blockHeader *phase0.BeaconBlockHeader) uint64 {
-	return beaconState.GenesisTime + uint64(blockHeader.Slot)*12
+	timestamp, err := GetSlotTimestampWithError(beaconState, blockHeader)
+	if err != nil {
+		return 0
+	}
+	return timestamp
+}
+
+// GetSlotTimestampWithError is GetSlotTimestamp, but returns an error if `beaconState`'s chain isn't registered.
+func GetSlotTimestampWithError(beaconState *deneb.BeaconState, blockHeader *phase0.BeaconBlockHeader) (uint64, error) {
+	chain, ok := chains.ByGenesisTime(beaconState.GenesisTime)
+	if !ok {
+		return 0, fmt.Errorf("%w: no chain has genesis time %d (register it with a chains file)", chains.ErrUnsupported, beaconState.GenesisTime)
+	}
+	return GetSlotTimestampOnChain(chain, blockHeader), nil
+}
+
+// GetSlotTimestampOnChain is the timestamp of `blockHeader`'s slot on `chain`.
+func GetSlotTimestampOnChain(chain *chains.Chain, blockHeader *phase0.BeaconBlockHeader) uint64 {
+	return chain.SlotTimestamp(uint64(blockHeader.Slot))
 }
 
 func ConvertValidatorToValidatorFields(v *phase0.Validator) []Bytes32 {
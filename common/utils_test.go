@@ -0,0 +1,31 @@
+package common
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+func TestGetSlotTimestamp(t *testing.T) {
+	header := &phase0.BeaconBlockHeader{Slot: 100}
+
+	holesky := &deneb.BeaconState{GenesisTime: chains.Holesky.GenesisTime}
+	if got, err := GetSlotTimestampWithError(holesky, header); err != nil || got != chains.Holesky.GenesisTime+100*12 {
+		t.Errorf("got %d (%v)", got, err)
+	}
+	if got := GetSlotTimestamp(holesky, header); got != chains.Holesky.GenesisTime+100*12 {
+		t.Errorf("got %d", got)
+	}
+
+	// a chain that isn't registered isn't assumed to have 12 second slots
+	unknown := &deneb.BeaconState{GenesisTime: 1234}
+	if _, err := GetSlotTimestampWithError(unknown, header); !errors.Is(err, chains.ErrUnsupported) {
+		t.Errorf("expected an unsupported chain error, got %v", err)
+	}
+	if got := GetSlotTimestamp(unknown, header); got != 0 {
+		t.Errorf("got %d for an unregistered chain, want 0", got)
+	}
+}
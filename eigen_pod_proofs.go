@@ -10,6 +10,7 @@ import (
 	expirable "github.com/hashicorp/golang-lru/v2/expirable"
 
 	beacon "github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
 	"github.com/Layr-Labs/eigenpod-proofs-generation/common"
 )
 
@@ -30,8 +31,8 @@ type EigenPodProofs struct {
 // chainID is the chain ID of the chain that the EigenPodProofs instance will be used for.
 // oracleStateCacheExpirySeconds is the expiry time for the oracle state cache in seconds. After this time caches of beacon state roots, validator trees and validator balances trees will be evicted.
 func NewEigenPodProofs(chainID uint64, oracleStateCacheExpirySeconds int) (*EigenPodProofs, error) {
-	if chainID != 1 && chainID != 17000 {
-		return nil, errors.New("chainID not supported")
+	if _, err := chains.Get(chainID); err != nil {
+		return nil, err
 	}
 
 	oracleStateRootCache := expirable.NewLRU[uint64, phase0.Root](MAX_ORACLE_STATE_CACHE_SIZE, nil, time.Duration(oracleStateCacheExpirySeconds)*time.Second)
@@ -49,6 +50,15 @@ func NewEigenPodProofs(chainID uint64, oracleStateCacheExpirySeconds int) (*Eige
 	}, nil
 }
 
+// GetSlotTimestamp is the timestamp of `blockHeader`'s slot on the chain the EigenPodProofs instance was created for.
+func (epp *EigenPodProofs) GetSlotTimestamp(blockHeader *phase0.BeaconBlockHeader) (uint64, error) {
+	chain, err := chains.Get(epp.chainID)
+	if err != nil {
+		return 0, err
+	}
+	return GetSlotTimestampOnChain(chain, blockHeader), nil
+}
+
 func (epp *EigenPodProofs) PrecomputeCache(state *spec.VersionedBeaconState) error {
 	slot, err := state.Slot()
 	if err != nil {
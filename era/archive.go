@@ -0,0 +1,333 @@
+package era
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/golang/snappy"
+)
+
+var ErrStateNotArchived = errors.New("beacon state not available in archive")
+
+// ErrStateRootMismatch is returned when a replayed state doesn't hash to the state root of the last block replayed.
+var ErrStateRootMismatch = errors.New("replayed state does not match the block's state root")
+
+// StateTransition rebuilds the states an archive doesn't store, by replaying blocks on top of the nearest stored
+// state. The transition package has Deneb's.
+type StateTransition interface {
+	// Replay starts a replay from `state`, which it may modify.
+	Replay(state *spec.VersionedBeaconState) (Replayer, error)
+}
+
+// Replayer advances a single state. It lives for one replay, so it can cache what it derives from the state.
+type Replayer interface {
+	// ProcessSlots advances the state through empty slots until it reaches `slot`. It's a no-op if the state is
+	// already at `slot`.
+	ProcessSlots(ctx context.Context, slot phase0.Slot) error
+	// ProcessBlock applies `block`, whose slot must equal the state's slot.
+	ProcessBlock(ctx context.Context, block *spec.VersionedSignedBeaconBlock) error
+	// State is the state replayed so far.
+	State() *spec.VersionedBeaconState
+}
+
+// Archive is a directory of era files (`*.era`) and loose beacon states (`*.ssz`, `*.ssz_snappy`).
+type Archive struct {
+	// Optional. If set, states that were not stored directly are rebuilt by replaying blocks.
+	Transition StateTransition
+
+	eras        []*Reader // sorted by ascending state slot
+	looseStates map[phase0.Slot]string
+}
+
+func OpenArchive(dir string) (*Archive, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive directory: %w", err)
+	}
+
+	archive := &Archive{
+		eras:        []*Reader{},
+		looseStates: map[phase0.Slot]string{},
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		switch {
+		case strings.HasSuffix(entry.Name(), ".era"):
+			reader, err := Open(path)
+			if err != nil {
+				archive.Close()
+				return nil, err
+			}
+			archive.eras = append(archive.eras, reader)
+		case strings.HasSuffix(entry.Name(), ".ssz"), strings.HasSuffix(entry.Name(), ".ssz_snappy"):
+			slot, err := readStateFileSlot(path)
+			if err != nil {
+				archive.Close()
+				return nil, fmt.Errorf("failed to read state file %s: %w", path, err)
+			}
+			archive.looseStates[slot] = path
+		}
+	}
+
+	sort.Slice(archive.eras, func(i, j int) bool {
+		return archive.eras[i].StateSlot() < archive.eras[j].StateSlot()
+	})
+
+	return archive, nil
+}
+
+func (a *Archive) Close() error {
+	var errs []error
+	for _, reader := range a.eras {
+		errs = append(errs, reader.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// StateSlots lists every slot for which the archive holds a complete beacon state, in ascending order.
+func (a *Archive) StateSlots() []phase0.Slot {
+	slots := []phase0.Slot{}
+	for _, reader := range a.eras {
+		slots = append(slots, reader.StateSlot())
+	}
+	for slot := range a.looseStates {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i] < slots[j]
+	})
+	return slots
+}
+
+// LatestStateSlot returns the most recent slot with a stored state.
+func (a *Archive) LatestStateSlot() (phase0.Slot, bool) {
+	slots := a.StateSlots()
+	if len(slots) == 0 {
+		return 0, false
+	}
+	return slots[len(slots)-1], true
+}
+
+// Block returns the block at `slot`, or ErrEmptySlot if the slot had no block.
+func (a *Archive) Block(slot phase0.Slot) (*spec.VersionedSignedBeaconBlock, error) {
+	for _, reader := range a.eras {
+		if reader.HasBlockSlot(slot) {
+			return reader.Block(slot)
+		}
+	}
+	return nil, fmt.Errorf("no era file covers the block at slot %d", slot)
+}
+
+// BlockByRoot searches the archive (newest eras first) for a block with the given root.
+func (a *Archive) BlockByRoot(root phase0.Root) (*spec.VersionedSignedBeaconBlock, error) {
+	for i := len(a.eras) - 1; i >= 0; i-- {
+		block, found, err := a.eras[i].BlockByRoot(root)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("no archived block with root %#x", root)
+}
+
+func (a *Archive) storedState(slot phase0.Slot) (*spec.VersionedBeaconState, bool, error) {
+	if path, ok := a.looseStates[slot]; ok {
+		state, err := ReadStateFile(path)
+		return state, true, err
+	}
+
+	for _, reader := range a.eras {
+		if reader.StateSlot() == slot {
+			state, err := reader.State()
+			return state, true, err
+		}
+	}
+	return nil, false, nil
+}
+
+// State returns the beacon state at `slot`, after the block at that slot (if any). If the archive does not hold
+// that state directly, it is rebuilt by replaying archived blocks on top of the nearest earlier state using
+// `a.Transition`, and checked against the state root of the last block replayed.
+//
+// An era file's state comes before the block at its slot, which begins the next era file, so that block (if the
+// archive has it) is replayed too.
+func (a *Archive) State(ctx context.Context, slot phase0.Slot) (*spec.VersionedBeaconState, error) {
+	state, found, err := a.storedState(slot)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		applied, err := blockApplied(state)
+		if err != nil || applied {
+			return state, err
+		}
+		if _, err := a.Block(slot); err != nil {
+			// the slot is empty, or its block isn't archived: the stored state is the best there is
+			return state, nil
+		}
+		if a.Transition == nil {
+			return nil, fmt.Errorf("%w: slot %d (the stored state precedes the block at that slot, and no state transition is configured to apply it)", ErrStateNotArchived, slot)
+		}
+		return a.replay(ctx, state, slot)
+	}
+
+	// find the nearest stored state before `slot`
+	var baseSlot phase0.Slot
+	hasBase := false
+	for _, storedSlot := range a.StateSlots() {
+		if storedSlot < slot {
+			baseSlot = storedSlot
+			hasBase = true
+		}
+	}
+	if !hasBase {
+		return nil, fmt.Errorf("%w: no stored state at or before slot %d", ErrStateNotArchived, slot)
+	}
+
+	if a.Transition == nil {
+		return nil, fmt.Errorf("%w: slot %d (nearest stored state is at slot %d, and no state transition is configured to replay blocks)", ErrStateNotArchived, slot, baseSlot)
+	}
+
+	state, _, err = a.storedState(baseSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.replay(ctx, state, slot)
+}
+
+// replays the archived blocks after `state` (including the one at its slot, if it hasn't been applied) up to
+// and including `to`.
+func (a *Archive) replay(ctx context.Context, state *spec.VersionedBeaconState, to phase0.Slot) (*spec.VersionedBeaconState, error) {
+	from, err := state.Slot()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := blockApplied(state)
+	if err != nil {
+		return nil, err
+	}
+	if applied {
+		from++
+	}
+
+	replayer, err := a.Transition.Replay(state)
+	if err != nil {
+		return nil, err
+	}
+
+	var last *spec.VersionedSignedBeaconBlock
+	for slot := from; slot <= to; slot++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		block, blockErr := a.Block(slot)
+		if errors.Is(blockErr, ErrEmptySlot) {
+			continue
+		} else if blockErr != nil {
+			return nil, fmt.Errorf("failed to replay to slot %d: %w", to, blockErr)
+		}
+
+		if err := replayer.ProcessSlots(ctx, slot); err != nil {
+			return nil, fmt.Errorf("failed to process slots up to %d: %w", slot, err)
+		}
+		if err := replayer.ProcessBlock(ctx, block); err != nil {
+			return nil, fmt.Errorf("failed to process block at slot %d: %w", slot, err)
+		}
+		last = block
+	}
+
+	if last != nil {
+		if err := checkStateRoot(replayer.State(), last); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := replayer.ProcessSlots(ctx, to); err != nil {
+		return nil, fmt.Errorf("failed to process slots up to %d: %w", to, err)
+	}
+	return replayer.State(), nil
+}
+
+// checks that `state` is the state `block` commits to.
+func checkStateRoot(state *spec.VersionedBeaconState, block *spec.VersionedSignedBeaconBlock) error {
+	if state.Version != spec.DataVersionDeneb {
+		return fmt.Errorf("unsupported beacon state version %s", state.Version)
+	}
+	root, err := state.Deneb.HashTreeRoot()
+	if err != nil {
+		return err
+	}
+	want, err := block.StateRoot()
+	if err != nil {
+		return err
+	}
+	if phase0.Root(root) != want {
+		slot, _ := block.Slot()
+		return fmt.Errorf("%w: slot %d: got %#x, block has %#x", ErrStateRootMismatch, slot, root, want)
+	}
+	return nil
+}
+
+// whether the block at the state's slot (if there was one) has been applied to it.
+func blockApplied(state *spec.VersionedBeaconState) (bool, error) {
+	var header *phase0.BeaconBlockHeader
+	switch state.Version {
+	case spec.DataVersionPhase0:
+		header = state.Phase0.LatestBlockHeader
+	case spec.DataVersionAltair:
+		header = state.Altair.LatestBlockHeader
+	case spec.DataVersionBellatrix:
+		header = state.Bellatrix.LatestBlockHeader
+	case spec.DataVersionCapella:
+		header = state.Capella.LatestBlockHeader
+	case spec.DataVersionDeneb:
+		header = state.Deneb.LatestBlockHeader
+	default:
+		return false, fmt.Errorf("unsupported beacon state version %s", state.Version)
+	}
+	slot, err := state.Slot()
+	if err != nil {
+		return false, err
+	}
+	return header != nil && header.Slot == slot, nil
+}
+
+// every fork's BeaconState begins with genesis_time (8 bytes), genesis_validators_root (32 bytes) and slot (8 bytes).
+const stateSlotOffset = 40
+
+func readStateFileSlot(path string) (phase0.Slot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".ssz_snappy") {
+		r = snappy.NewReader(file)
+	}
+
+	prefix := make([]byte, stateSlotOffset+8)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return 0, err
+	}
+	return phase0.Slot(binary.LittleEndian.Uint64(prefix[stateSlotOffset:])), nil
+}
@@ -0,0 +1,198 @@
+package era
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+// e2store entry types used by era files.
+// (https://github.com/status-im/nimbus-eth2/blob/stable/docs/e2store.md)
+var (
+	TypeEmpty                       = [2]byte{0x00, 0x00}
+	TypeCompressedSignedBeaconBlock = [2]byte{0x01, 0x00}
+	TypeCompressedBeaconState       = [2]byte{0x02, 0x00}
+	TypeVersion                     = [2]byte{0x65, 0x32}
+	TypeSlotIndex                   = [2]byte{0x69, 0x32}
+)
+
+const (
+	// every e2store entry starts with a header of: type (2 bytes) | length (4 bytes, LE) | reserved (2 bytes)
+	headerSize = 8
+
+	// number of slots covered by the blocks of a single era file
+	SLOTS_PER_HISTORICAL_ROOT = uint64(8192)
+)
+
+var ErrInvalidEntry = errors.New("invalid e2store entry")
+
+type Header struct {
+	Type   [2]byte
+	Length uint32
+}
+
+// Entry is a single e2store record. Offset is the position of the record header in the file.
+type Entry struct {
+	Header
+	Offset int64
+	Data   []byte
+}
+
+func readHeader(r io.ReaderAt, offset int64) (*Header, error) {
+	var buf [headerSize]byte
+	if _, err := r.ReadAt(buf[:], offset); err != nil {
+		return nil, fmt.Errorf("failed to read entry header at %d: %w", offset, err)
+	}
+
+	if buf[6] != 0 || buf[7] != 0 {
+		return nil, fmt.Errorf("%w: reserved bytes set at %d", ErrInvalidEntry, offset)
+	}
+
+	return &Header{
+		Type:   [2]byte{buf[0], buf[1]},
+		Length: binary.LittleEndian.Uint32(buf[2:6]),
+	}, nil
+}
+
+// ReadEntry reads the e2store record whose header begins at `offset`.
+func ReadEntry(r io.ReaderAt, offset int64) (*Entry, error) {
+	header, err := readHeader(r, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if size, ok := readerSize(r); ok {
+		// don't allocate for a length the reader can't hold
+		if int64(header.Length) > size-offset-headerSize {
+			return nil, fmt.Errorf("%w: entry at %d is %d bytes, past the end of the file", ErrInvalidEntry, offset, header.Length)
+		}
+		data = make([]byte, header.Length)
+		if _, err := r.ReadAt(data, offset+headerSize); err != nil {
+			return nil, fmt.Errorf("failed to read entry at %d: %w", offset, err)
+		}
+	} else {
+		// ReadAll only grows the buffer as data actually arrives
+		data, err = io.ReadAll(io.NewSectionReader(r, offset+headerSize, int64(header.Length)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entry at %d: %w", offset, err)
+		}
+		if len(data) != int(header.Length) {
+			return nil, fmt.Errorf("%w: entry at %d is %d bytes, past the end of the file", ErrInvalidEntry, offset, header.Length)
+		}
+	}
+
+	return &Entry{
+		Header: *header,
+		Offset: offset,
+		Data:   data,
+	}, nil
+}
+
+// the size of `r`, if it knows it (e.g a file, or a bytes.Reader).
+func readerSize(r io.ReaderAt) (int64, bool) {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		return r.Size(), true
+	case interface{ Stat() (fs.FileInfo, error) }:
+		info, err := r.Stat()
+		if err != nil {
+			return 0, false
+		}
+		return info.Size(), true
+	}
+	return 0, false
+}
+
+// WriteEntry appends a single e2store record to `w`, returning the number of bytes written.
+func WriteEntry(w io.Writer, entryType [2]byte, data []byte) (int64, error) {
+	var header [headerSize]byte
+	copy(header[0:2], entryType[:])
+	binary.LittleEndian.PutUint32(header[2:6], uint32(len(data)))
+
+	n, err := w.Write(header[:])
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(data)
+	return int64(n + m), err
+}
+
+// SlotIndex maps slots to the offsets of their entries in an era file.
+// Offsets are relative to the beginning of the slot index record, and an offset of 0 marks an empty slot.
+type SlotIndex struct {
+	StartSlot uint64
+	Offsets   []int64
+
+	// position of the slot index record within the file
+	RecordOffset int64
+}
+
+func slotIndexSize(count uint64) int64 {
+	// header | starting-slot | offsets... | count
+	return int64(headerSize + 8 + 8*count + 8)
+}
+
+// ReadSlotIndex reads the slot index record that ends at `end` (exclusive).
+func ReadSlotIndex(r io.ReaderAt, end int64) (*SlotIndex, error) {
+	var countBuf [8]byte
+	if end < 8 {
+		return nil, fmt.Errorf("%w: no room for a slot index", ErrInvalidEntry)
+	}
+	if _, err := r.ReadAt(countBuf[:], end-8); err != nil {
+		return nil, fmt.Errorf("failed to read slot index count: %w", err)
+	}
+
+	count := binary.LittleEndian.Uint64(countBuf[:])
+	// bound the count before slotIndexSize multiplies it
+	if count == 0 || count > uint64(end)/8 || slotIndexSize(count) > end {
+		return nil, fmt.Errorf("%w: slot index count %d out of range", ErrInvalidEntry, count)
+	}
+
+	entry, err := ReadEntry(r, end-slotIndexSize(count))
+	if err != nil {
+		return nil, err
+	}
+	if entry.Type != TypeSlotIndex {
+		return nil, fmt.Errorf("%w: expected slot index, got type %x", ErrInvalidEntry, entry.Type)
+	}
+	if uint64(len(entry.Data)) != 8+8*count+8 {
+		return nil, fmt.Errorf("%w: slot index of %d bytes for %d slots", ErrInvalidEntry, len(entry.Data), count)
+	}
+
+	index := &SlotIndex{
+		StartSlot:    binary.LittleEndian.Uint64(entry.Data[0:8]),
+		Offsets:      make([]int64, count),
+		RecordOffset: entry.Offset,
+	}
+	for i := uint64(0); i < count; i++ {
+		index.Offsets[i] = int64(binary.LittleEndian.Uint64(entry.Data[8+8*i : 16+8*i]))
+	}
+	return index, nil
+}
+
+// EncodeSlotIndex serializes a slot index record body, with offsets relative to the record's header.
+func EncodeSlotIndex(startSlot uint64, offsets []int64) []byte {
+	data := make([]byte, 8+8*len(offsets)+8)
+	binary.LittleEndian.PutUint64(data[0:8], startSlot)
+	for i, offset := range offsets {
+		binary.LittleEndian.PutUint64(data[8+8*i:16+8*i], uint64(offset))
+	}
+	binary.LittleEndian.PutUint64(data[len(data)-8:], uint64(len(offsets)))
+	return data
+}
+
+// Lookup returns the absolute file offset of the entry for `slot`, or false if the slot is empty or not covered.
+func (s *SlotIndex) Lookup(slot uint64) (int64, bool) {
+	if slot < s.StartSlot || slot >= s.StartSlot+uint64(len(s.Offsets)) {
+		return 0, false
+	}
+
+	offset := s.Offsets[slot-s.StartSlot]
+	if offset == 0 {
+		return 0, false
+	}
+	return s.RecordOffset + offset, true
+}
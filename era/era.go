@@ -0,0 +1,243 @@
+package era
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+var ErrEmptySlot = errors.New("no block at slot")
+
+// Reader provides random access to the blocks and state stored in a single era file.
+//
+// An era file for era N holds the blocks for slots [(N-1)*8192, N*8192) followed by the
+// beacon state at slot N*8192, and ends with slot indices for both.
+// (https://github.com/status-im/nimbus-eth2/blob/stable/docs/e2store.md#era-files)
+type Reader struct {
+	Path string
+
+	file       *os.File
+	stateIndex *SlotIndex
+	blockIndex *SlotIndex // nil for the genesis era, which has no blocks
+
+	// lazily built when blocks are looked up by root
+	blockRoots map[phase0.Root]phase0.Slot
+}
+
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := newReader(path, file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read era file %s: %w", path, err)
+	}
+	return reader, nil
+}
+
+func newReader(path string, file *os.File) (*Reader, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := readHeader(file, 0)
+	if err != nil {
+		return nil, err
+	}
+	if version.Type != TypeVersion {
+		return nil, fmt.Errorf("%w: missing version entry", ErrInvalidEntry)
+	}
+
+	stateIndex, err := ReadSlotIndex(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state index: %w", err)
+	}
+
+	reader := &Reader{
+		Path:       path,
+		file:       file,
+		stateIndex: stateIndex,
+	}
+
+	// the genesis era contains only a state, so the block index is optional.
+	if stateIndex.StartSlot > 0 {
+		blockIndex, err := ReadSlotIndex(file, stateIndex.RecordOffset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read block index: %w", err)
+		}
+		reader.blockIndex = blockIndex
+	}
+
+	return reader, nil
+}
+
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
+
+// StateSlot is the slot of the beacon state stored in this era file.
+func (r *Reader) StateSlot() phase0.Slot {
+	return phase0.Slot(r.stateIndex.StartSlot)
+}
+
+// BlockSlots returns the half-open range of slots whose blocks are stored in this era file.
+func (r *Reader) BlockSlots() (start phase0.Slot, end phase0.Slot) {
+	if r.blockIndex == nil {
+		return 0, 0
+	}
+	return phase0.Slot(r.blockIndex.StartSlot), phase0.Slot(r.blockIndex.StartSlot + uint64(len(r.blockIndex.Offsets)))
+}
+
+// HasBlockSlot returns whether `slot` falls within the block range of this file (the slot may still be empty).
+func (r *Reader) HasBlockSlot(slot phase0.Slot) bool {
+	start, end := r.BlockSlots()
+	return slot >= start && slot < end
+}
+
+func (r *Reader) State() (*spec.VersionedBeaconState, error) {
+	offset, ok := r.stateIndex.Lookup(r.stateIndex.StartSlot)
+	if !ok {
+		return nil, fmt.Errorf("%w: era file has no state", ErrInvalidEntry)
+	}
+
+	entry, err := ReadEntry(r.file, offset)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Type != TypeCompressedBeaconState {
+		return nil, fmt.Errorf("%w: expected beacon state, got type %x", ErrInvalidEntry, entry.Type)
+	}
+
+	return DecodeSnappySSZState(entry.Data)
+}
+
+// Block returns the signed block at `slot`, or ErrEmptySlot if no block was proposed at that slot.
+func (r *Reader) Block(slot phase0.Slot) (*spec.VersionedSignedBeaconBlock, error) {
+	if r.blockIndex == nil || !r.HasBlockSlot(slot) {
+		return nil, fmt.Errorf("slot %d is not covered by %s", slot, filepath.Base(r.Path))
+	}
+
+	offset, ok := r.blockIndex.Lookup(uint64(slot))
+	if !ok {
+		return nil, ErrEmptySlot
+	}
+
+	entry, err := ReadEntry(r.file, offset)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Type != TypeCompressedSignedBeaconBlock {
+		return nil, fmt.Errorf("%w: expected beacon block, got type %x", ErrInvalidEntry, entry.Type)
+	}
+
+	sszBytes, err := DecompressFramed(entry.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress block at slot %d: %w", slot, err)
+	}
+
+	return beacon.UnmarshalSSZVersionedSignedBeaconBlock(sszBytes)
+}
+
+// BlockByRoot returns the block in this era file whose root is `root`.
+//
+// The first lookup hashes every block in the file, so this is slow -- prefer looking blocks up by slot.
+func (r *Reader) BlockByRoot(root phase0.Root) (*spec.VersionedSignedBeaconBlock, bool, error) {
+	if r.blockRoots == nil {
+		blockRoots := map[phase0.Root]phase0.Slot{}
+		start, end := r.BlockSlots()
+		for slot := start; slot < end; slot++ {
+			block, err := r.Block(slot)
+			if errors.Is(err, ErrEmptySlot) {
+				continue
+			} else if err != nil {
+				return nil, false, err
+			}
+
+			blockRoot, err := block.Root()
+			if err != nil {
+				return nil, false, err
+			}
+			blockRoots[blockRoot] = slot
+		}
+		r.blockRoots = blockRoots
+	}
+
+	slot, ok := r.blockRoots[root]
+	if !ok {
+		return nil, false, nil
+	}
+
+	block, err := r.Block(slot)
+	if err != nil {
+		return nil, false, err
+	}
+	return block, true, nil
+}
+
+// WriteEraFile writes blocks and a state in the era file format. `blocks` must hold exactly one
+// (possibly nil) entry per slot starting at `startSlot`, and `state` is the state at `startSlot+len(blocks)`.
+func WriteEraFile(w io.Writer, startSlot phase0.Slot, blocks [][]byte, state []byte) error {
+	var position int64
+
+	n, err := WriteEntry(w, TypeVersion, nil)
+	if err != nil {
+		return err
+	}
+	position += n
+
+	blockOffsets := make([]int64, len(blocks))
+	for i, block := range blocks {
+		if block == nil {
+			continue
+		}
+		compressed, err := CompressFramed(block)
+		if err != nil {
+			return err
+		}
+		blockOffsets[i] = position
+		n, err := WriteEntry(w, TypeCompressedSignedBeaconBlock, compressed)
+		if err != nil {
+			return err
+		}
+		position += n
+	}
+
+	compressedState, err := CompressFramed(state)
+	if err != nil {
+		return err
+	}
+	stateOffset := position
+	n, err = WriteEntry(w, TypeCompressedBeaconState, compressedState)
+	if err != nil {
+		return err
+	}
+	position += n
+
+	if len(blocks) > 0 {
+		// offsets are relative to the start of the slot index record
+		for i := range blockOffsets {
+			if blockOffsets[i] != 0 {
+				blockOffsets[i] -= position
+			}
+		}
+		n, err = WriteEntry(w, TypeSlotIndex, EncodeSlotIndex(uint64(startSlot), blockOffsets))
+		if err != nil {
+			return err
+		}
+		position += n
+	}
+
+	stateSlot := uint64(startSlot) + uint64(len(blocks))
+	_, err = WriteEntry(w, TypeSlotIndex, EncodeSlotIndex(stateSlot, []int64{stateOffset - position}))
+	return err
+}
@@ -0,0 +1,295 @@
+package era_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacontest"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/era"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestState(t *testing.T, slot phase0.Slot) []byte {
+	t.Helper()
+
+	fixture, err := beacontest.NewStateBuilder(slot).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := fixture.State.Deneb.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+// writes an era file for era 1 (blocks for slots [0, 8192), state at 8192), with blocks at `blockSlots`.
+func writeTestEraFile(t *testing.T, dir string, blockSlots ...phase0.Slot) {
+	t.Helper()
+
+	blocks := make([][]byte, era.SLOTS_PER_HISTORICAL_ROOT)
+	for _, slot := range blockSlots {
+		fixture, err := beacontest.NewStateBuilder(slot).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, err := fixture.Block.MarshalSSZ()
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks[slot] = block
+	}
+
+	state := newTestState(t, phase0.Slot(era.SLOTS_PER_HISTORICAL_ROOT))
+
+	file, err := os.Create(filepath.Join(dir, "holesky-00001-00000000.era"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if err := era.WriteEraFile(file, 0, blocks, state); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEraFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	writeTestEraFile(t, dir, 5, 100)
+
+	reader, err := era.Open(filepath.Join(dir, "holesky-00001-00000000.era"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	assert.Equal(t, phase0.Slot(8192), reader.StateSlot())
+
+	state, err := reader.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot, err := state.Slot()
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Slot(8192), slot)
+
+	block, err := reader.Block(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockSlot, err := block.Slot()
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Slot(100), blockSlot)
+
+	_, err = reader.Block(6)
+	assert.True(t, errors.Is(err, era.ErrEmptySlot))
+
+	root, err := block.Root()
+	assert.Nil(t, err)
+	found, ok, err := reader.BlockByRoot(root)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, block, found)
+}
+
+// a "transition" that applies a block by swapping in the beacontest state that block commits to.
+type recordingTransition struct {
+	blocks []phase0.Slot
+}
+
+func (r *recordingTransition) Replay(state *spec.VersionedBeaconState) (era.Replayer, error) {
+	return &recordingReplayer{transition: r, state: state}, nil
+}
+
+type recordingReplayer struct {
+	transition *recordingTransition
+	state      *spec.VersionedBeaconState
+	// if set, blocks are recorded but not applied
+	ignoreBlocks bool
+}
+
+func (r *recordingReplayer) ProcessSlots(_ context.Context, slot phase0.Slot) error {
+	r.state.Deneb.Slot = slot
+	return nil
+}
+
+func (r *recordingReplayer) ProcessBlock(_ context.Context, block *spec.VersionedSignedBeaconBlock) error {
+	slot, err := block.Slot()
+	if err != nil {
+		return err
+	}
+	r.transition.blocks = append(r.transition.blocks, slot)
+	if r.ignoreBlocks {
+		return nil
+	}
+	fixture, err := beacontest.NewStateBuilder(slot).Build()
+	if err != nil {
+		return err
+	}
+	r.state = fixture.State
+	return nil
+}
+
+func (r *recordingReplayer) State() *spec.VersionedBeaconState {
+	return r.state
+}
+
+// a transition whose replays don't apply blocks.
+type ignoringTransition struct {
+	recordingTransition
+}
+
+func (i *ignoringTransition) Replay(state *spec.VersionedBeaconState) (era.Replayer, error) {
+	return &recordingReplayer{transition: &i.recordingTransition, state: state, ignoreBlocks: true}, nil
+}
+
+func TestArchiveReplaysFromNearestState(t *testing.T) {
+	dir := t.TempDir()
+	writeTestEraFile(t, dir, 5, 100)
+
+	// a loose snappy state before the blocks that need replaying
+	compressed, err := era.CompressFramed(newTestState(t, 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "state_50.ssz_snappy"), compressed, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := era.OpenArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	assert.Equal(t, []phase0.Slot{50, 8192}, archive.StateSlots())
+
+	// without a transition, only stored states are available
+	_, err = archive.State(context.Background(), 120)
+	assert.True(t, errors.Is(err, era.ErrStateNotArchived))
+
+	transition := &recordingTransition{}
+	archive.Transition = transition
+
+	state, err := archive.State(context.Background(), 120)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot, err := state.Slot()
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Slot(120), slot)
+	assert.Equal(t, []phase0.Slot{100}, transition.blocks)
+}
+
+func TestArchiveChecksReplayedStateRoot(t *testing.T) {
+	dir := t.TempDir()
+	writeTestEraFile(t, dir, 5, 100)
+	compressed, err := era.CompressFramed(newTestState(t, 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "state_50.ssz_snappy"), compressed, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := era.OpenArchive(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	transition := &ignoringTransition{}
+	archive.Transition = transition
+
+	_, err = archive.State(context.Background(), 120)
+	assert.True(t, errors.Is(err, era.ErrStateRootMismatch), err)
+	assert.Equal(t, []phase0.Slot{100}, transition.blocks)
+
+	// no blocks were replayed, so there's nothing to check
+	state, err := archive.State(context.Background(), 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot, err := state.Slot()
+	assert.Nil(t, err)
+	assert.Equal(t, phase0.Slot(60), slot)
+}
+
+// hides the size of a reader, so reads can't be bounded by it.
+type sizelessReader struct {
+	r *bytes.Reader
+}
+
+func (s sizelessReader) ReadAt(p []byte, off int64) (int, error) {
+	return s.r.ReadAt(p, off)
+}
+
+func TestReadSlotIndexRejectsCorruptIndexes(t *testing.T) {
+	// a version entry, then an index of 4 slots
+	valid := func() []byte {
+		var buf bytes.Buffer
+		if _, err := era.WriteEntry(&buf, era.TypeVersion, nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := era.WriteEntry(&buf, era.TypeSlotIndex, era.EncodeSlotIndex(100, []int64{-8, 0, 0, -8})); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	setUint64 := func(data []byte, at int, value uint64) []byte {
+		binary.LittleEndian.PutUint64(data[at:], value)
+		return data
+	}
+	setLength := func(data []byte, length uint32) []byte {
+		binary.LittleEndian.PutUint32(data[8+2:], length)
+		return data
+	}
+
+	index, err := era.ReadSlotIndex(bytes.NewReader(valid()), int64(len(valid())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(100), index.StartSlot)
+	assert.Equal(t, []int64{-8, 0, 0, -8}, index.Offsets)
+
+	tests := map[string][]byte{
+		"truncated":                   valid()[:len(valid())-12],
+		"truncated to the count":      valid()[len(valid())-8:],
+		"a count past the file":       setUint64(valid(), len(valid())-8, 5),
+		"a count that overflows":      setUint64(valid(), len(valid())-8, 1<<61),
+		"a count of 0":                setUint64(valid(), len(valid())-8, 0),
+		"a header shorter than count": setLength(valid(), 8),
+		"a header longer than count":  setLength(valid(), 8+8*4+8+8),
+		"a header past the file":      setLength(valid(), 1<<31),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, r := range []io.ReaderAt{bytes.NewReader(data), sizelessReader{bytes.NewReader(data)}} {
+				_, err := era.ReadSlotIndex(r, int64(len(data)))
+				assert.True(t, errors.Is(err, era.ErrInvalidEntry), err)
+			}
+		})
+	}
+}
+
+func TestReadEntryRejectsLengthsPastTheEnd(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := era.WriteEntry(&buf, era.TypeCompressedBeaconState, []byte("state")); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	binary.LittleEndian.PutUint32(data[2:], 0xffffffff)
+
+	for _, r := range []io.ReaderAt{bytes.NewReader(data), sizelessReader{bytes.NewReader(data)}} {
+		_, err := era.ReadEntry(r, 0)
+		assert.True(t, errors.Is(err, era.ErrInvalidEntry), err)
+	}
+}
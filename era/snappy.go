@@ -0,0 +1,56 @@
+package era
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/golang/snappy"
+)
+
+// DecompressFramed decodes data compressed with the snappy framing format, as used by
+// era files and `.ssz_snappy` dumps from consensus clients.
+func DecompressFramed(data []byte) ([]byte, error) {
+	return io.ReadAll(snappy.NewReader(bytes.NewReader(data)))
+}
+
+// CompressFramed encodes data using the snappy framing format.
+func CompressFramed(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := snappy.NewBufferedWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeSnappySSZState decodes a snappy-framed, SSZ-encoded beacon state.
+func DecodeSnappySSZState(data []byte) (*spec.VersionedBeaconState, error) {
+	sszBytes, err := DecompressFramed(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress beacon state: %w", err)
+	}
+
+	return beacon.UnmarshalSSZVersionedBeaconState(sszBytes)
+}
+
+// ReadStateFile loads a beacon state from disk. Files ending in `.ssz_snappy` are treated as
+// snappy-framed; anything else is read as plain SSZ.
+func ReadStateFile(path string) (*spec.VersionedBeaconState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(path, ".ssz_snappy") {
+		return DecodeSnappySSZState(data)
+	}
+	return beacon.UnmarshalSSZVersionedBeaconState(data)
+}
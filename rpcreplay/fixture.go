@@ -0,0 +1,191 @@
+// Package rpcreplay records the JSON-RPC and beacon API traffic of a real run to a fixture file,
+// and serves it back deterministically so the same run can be repeated without live nodes.
+package rpcreplay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+const (
+	KindJSONRPC = "jsonrpc"
+	KindBeacon  = "beacon"
+)
+
+var ErrNoRecording = errors.New("no recorded response for request")
+
+// Exchange is one recorded request/response pair. Fixture files hold one exchange per line.
+type Exchange struct {
+	Kind string `json:"kind"`
+
+	// beacon requests: the method and API path (including the query), without the node's address.
+	Method string `json:"method,omitempty"`
+	Path   string `json:"path,omitempty"`
+	// JSON-RPC requests: the request body, with ids renumbered from 0.
+	Request json.RawMessage `json:"request,omitempty"`
+
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	// JSON responses are kept readable, anything else (e.g SSZ states) is stored as base64.
+	Body   json.RawMessage `json:"body,omitempty"`
+	Binary []byte          `json:"binary,omitempty"`
+}
+
+// response headers needed to decode a replayed response.
+var recordedHeaders = []string{"Content-Type", "Eth-Consensus-Version"}
+
+func (e *Exchange) key() string {
+	if e.Kind == KindJSONRPC {
+		return e.Kind + " " + string(e.Request)
+	}
+	return e.Kind + " " + e.Method + " " + e.Path
+}
+
+func (e *Exchange) setBody(body []byte) {
+	if len(body) > 0 && json.Valid(body) {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, body); err == nil {
+			e.Body = compact.Bytes()
+			return
+		}
+	}
+	e.Binary = body
+}
+
+func (e *Exchange) body() []byte {
+	if e.Body != nil {
+		return e.Body
+	}
+	return e.Binary
+}
+
+// ReadFixture reads every exchange from a fixture file.
+func ReadFixture(path string) ([]Exchange, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	exchanges := []Exchange{}
+	scanner := bufio.NewScanner(file)
+	// beacon states are recorded on a single (very long) line
+	scanner.Buffer(make([]byte, 64*1024), 1<<31-1)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var exchange Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	return exchanges, scanner.Err()
+}
+
+// Interceptor sits between the CLI and the nodes it talks to. Both Recorder and Replayer implement it.
+type Interceptor interface {
+	// ExecutionTransport is used as the http transport of the execution client.
+	ExecutionTransport() http.RoundTripper
+	// BeaconHandler serves the beacon API on behalf of the node at `upstream`.
+	BeaconHandler(upstream string) (http.Handler, error)
+}
+
+// Serve starts serving `handler` on a local port, and returns its url. go-eth2-client builds its own
+// http client, so beacon traffic is intercepted by pointing it at this server instead.
+func Serve(handler http.Handler) (string, func() error, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+
+	server := &http.Server{Handler: handler}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	return "http://127.0.0.1:" + strconv.Itoa(port), server.Close, nil
+}
+
+// rewrites the `id` of every message in a JSON-RPC request or response (single or batch).
+func rewriteIDs(body []byte, rewrite func(index int, id json.RawMessage) (json.RawMessage, error)) ([]byte, error) {
+	trimmed := bytes.TrimSpace(body)
+	isBatch := len(trimmed) > 0 && trimmed[0] == '['
+
+	var messages []map[string]json.RawMessage
+	if isBatch {
+		if err := json.Unmarshal(trimmed, &messages); err != nil {
+			return nil, err
+		}
+	} else {
+		var message map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &message); err != nil {
+			return nil, err
+		}
+		messages = []map[string]json.RawMessage{message}
+	}
+
+	for i, message := range messages {
+		id, ok := message["id"]
+		if !ok {
+			continue
+		}
+		newId, err := rewrite(i, id)
+		if err != nil {
+			return nil, err
+		}
+		message["id"] = newId
+	}
+
+	if isBatch {
+		return json.Marshal(messages)
+	}
+	return json.Marshal(messages[0])
+}
+
+// normalizeRequest renumbers request ids from 0, so identical calls made in different runs match.
+// It returns the normalized body and the original ids, by their new number.
+func normalizeRequest(body []byte) ([]byte, []json.RawMessage, error) {
+	ids := []json.RawMessage{}
+	normalized, err := rewriteIDs(body, func(_ int, id json.RawMessage) (json.RawMessage, error) {
+		ids = append(ids, id)
+		return json.RawMessage(strconv.Itoa(len(ids) - 1)), nil
+	})
+	return normalized, ids, err
+}
+
+// normalizeResponse replaces each response id with its position in `ids`.
+func normalizeResponse(body []byte, ids []json.RawMessage) ([]byte, error) {
+	return rewriteIDs(body, func(_ int, id json.RawMessage) (json.RawMessage, error) {
+		for i := range ids {
+			if bytes.Equal(ids[i], id) {
+				return json.RawMessage(strconv.Itoa(i)), nil
+			}
+		}
+		// e.g a parse error, which responds with a null id
+		return id, nil
+	})
+}
+
+// restoreResponse is the inverse of normalizeResponse.
+func restoreResponse(body []byte, ids []json.RawMessage) ([]byte, error) {
+	return rewriteIDs(body, func(_ int, id json.RawMessage) (json.RawMessage, error) {
+		index, err := strconv.Atoi(string(id))
+		if err != nil || index < 0 || index >= len(ids) {
+			return id, nil
+		}
+		return ids[index], nil
+	})
+}
@@ -0,0 +1,40 @@
+package rpcreplay
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayRestoresBatchIDs(t *testing.T) {
+	recorded := `[{"id":7,"jsonrpc":"2.0","method":"eth_blockNumber"},{"id":8,"jsonrpc":"2.0","method":"eth_chainId"}]`
+	normalized, ids, err := normalizeRequest([]byte(recorded))
+	assert.Nil(t, err)
+
+	// responses to a batch may come back in any order
+	response, err := normalizeResponse([]byte(`[{"id":8,"result":"0x4268"},{"id":7,"result":"0x10"}]`), ids)
+	assert.Nil(t, err)
+
+	replayer := NewReplayer([]Exchange{{Kind: KindJSONRPC, Request: normalized, Status: 200, Body: response}})
+
+	// same calls, different ids
+	request := `[{"jsonrpc":"2.0","id":100,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":101,"method":"eth_chainId"}]`
+	req, err := http.NewRequest(http.MethodPost, "http://node", bytes.NewBufferString(request))
+	assert.Nil(t, err)
+
+	resp, err := replayer.ExecutionTransport().RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"id":101,"result":"0x4268"},{"id":100,"result":"0x10"}]`, string(body))
+
+	req, err = http.NewRequest(http.MethodPost, "http://node", bytes.NewBufferString(`{"jsonrpc":"2.0","id":1,"method":"eth_gasPrice"}`))
+	assert.Nil(t, err)
+	_, err = replayer.ExecutionTransport().RoundTrip(req)
+	assert.ErrorIs(t, err, ErrNoRecording)
+}
@@ -0,0 +1,166 @@
+package rpcreplay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Recorder appends every exchange with the execution and beacon nodes to a fixture file as it happens,
+// so a run that ends in a crash still leaves a usable recording.
+type Recorder struct {
+	// Used to reach the real nodes. Defaults to http.DefaultTransport.
+	Next http.RoundTripper
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecorder creates (or truncates) the fixture file at `path`.
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fixture file: %w", err)
+	}
+	return &Recorder{file: file}, nil
+}
+
+func (r *Recorder) Close() error {
+	return r.file.Close()
+}
+
+func (r *Recorder) next() http.RoundTripper {
+	if r.Next != nil {
+		return r.Next
+	}
+	return http.DefaultTransport
+}
+
+func (r *Recorder) record(exchange *Exchange) error {
+	line, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.file.Write(append(line, '\n'))
+	return err
+}
+
+func recordResponse(exchange *Exchange, resp *http.Response, body []byte) {
+	exchange.Status = resp.StatusCode
+	exchange.Header = map[string]string{}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			exchange.Header[name] = value
+		}
+	}
+	exchange.setBody(body)
+}
+
+func (r *Recorder) ExecutionTransport() http.RoundTripper {
+	return recordingTransport{recorder: r}
+}
+
+type recordingTransport struct {
+	recorder *Recorder
+}
+
+func (t recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	resp, err := t.recorder.next().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	normalizedRequest, ids, err := normalizeRequest(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record JSON-RPC request: %w", err)
+	}
+	exchange := &Exchange{Kind: KindJSONRPC, Request: normalizedRequest}
+	recordResponse(exchange, resp, responseBody)
+	if exchange.Body != nil {
+		if normalized, err := normalizeResponse(exchange.Body, ids); err == nil {
+			exchange.Body = normalized
+		}
+	}
+
+	if err := t.recorder.record(exchange); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return resp, nil
+}
+
+// BeaconHandler proxies beacon API requests to `upstream`, recording each exchange. The upstream address
+// (which often contains an API key) is not written to the fixture.
+func (r *Recorder) BeaconHandler(upstream string) (http.Handler, error) {
+	base, err := url.Parse(upstream)
+	if err != nil {
+		return nil, fmt.Errorf("invalid beacon node url: %w", err)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		target := *base
+		target.Path = strings.TrimSuffix(base.Path, "/") + req.URL.Path
+		target.RawQuery = req.URL.RawQuery
+
+		outgoing, err := http.NewRequestWithContext(req.Context(), req.Method, target.String(), req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		outgoing.Header = req.Header.Clone()
+
+		resp, err := r.next().RoundTrip(outgoing)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		exchange := &Exchange{Kind: KindBeacon, Method: req.Method, Path: req.URL.RequestURI()}
+		recordResponse(exchange, resp, body)
+		if err := r.record(exchange); err != nil {
+			http.Error(w, fmt.Sprintf("failed to write fixture: %s", err), http.StatusInternalServerError)
+			return
+		}
+
+		writeExchange(w, exchange.Header, resp.StatusCode, body)
+	}), nil
+}
+
+func writeExchange(w http.ResponseWriter, header map[string]string, status int, body []byte) {
+	for name, value := range header {
+		w.Header().Set(name, value)
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,115 @@
+package rpcreplay
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Replayer serves recorded exchanges. Identical requests are answered in the order they were recorded,
+// and once those run out the last response is repeated.
+type Replayer struct {
+	mu        sync.Mutex
+	exchanges map[string][]*Exchange
+	served    map[string]int
+}
+
+func NewReplayer(exchanges []Exchange) *Replayer {
+	replayer := &Replayer{
+		exchanges: map[string][]*Exchange{},
+		served:    map[string]int{},
+	}
+	for i := range exchanges {
+		key := exchanges[i].key()
+		replayer.exchanges[key] = append(replayer.exchanges[key], &exchanges[i])
+	}
+	return replayer
+}
+
+func LoadReplayer(path string) (*Replayer, error) {
+	exchanges, err := ReadFixture(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	return NewReplayer(exchanges), nil
+}
+
+func (r *Replayer) next(key string) (*Exchange, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recorded := r.exchanges[key]
+	if len(recorded) == 0 {
+		return nil, false
+	}
+	index := min(r.served[key], len(recorded)-1)
+	r.served[key]++
+	return recorded[index], true
+}
+
+func (r *Replayer) ExecutionTransport() http.RoundTripper {
+	return replayTransport{replayer: r}
+}
+
+type replayTransport struct {
+	replayer *Replayer
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	normalized, ids, err := normalizeRequest(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON-RPC request: %w", err)
+	}
+	exchange, ok := t.replayer.next((&Exchange{Kind: KindJSONRPC, Request: normalized}).key())
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoRecording, normalized)
+	}
+
+	body := exchange.body()
+	if exchange.Body != nil {
+		body, err = restoreResponse(exchange.Body, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &http.Response{
+		Status:        http.StatusText(exchange.Status),
+		StatusCode:    exchange.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+	for name, value := range exchange.Header {
+		resp.Header.Set(name, value)
+	}
+	return resp, nil
+}
+
+// BeaconHandler serves recorded beacon API responses. `upstream` is ignored.
+func (r *Replayer) BeaconHandler(_ string) (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		exchange, ok := r.next((&Exchange{Kind: KindBeacon, Method: req.Method, Path: req.URL.RequestURI()}).key())
+		if !ok {
+			http.Error(w, fmt.Sprintf("%s: %s %s", ErrNoRecording, req.Method, req.URL.RequestURI()), http.StatusNotImplemented)
+			return
+		}
+		writeExchange(w, exchange.Header, exchange.Status, exchange.body())
+	}), nil
+}
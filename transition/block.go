@@ -0,0 +1,442 @@
+package transition
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	ssz "github.com/ferranbt/fastssz"
+)
+
+func (r *replayer) processBlockHeader(block *deneb.BeaconBlock) error {
+	if block.Slot != r.state.Slot || block.Slot <= r.state.LatestBlockHeader.Slot {
+		return fmt.Errorf("%w: block is for slot %d, state is at slot %d", ErrInvalidBlock, block.Slot, r.state.Slot)
+	}
+	if int(block.ProposerIndex) >= len(r.state.Validators) || r.state.Validators[block.ProposerIndex].Slashed {
+		return fmt.Errorf("%w: slot %d: proposer %d can't propose", ErrInvalidBlock, block.Slot, block.ProposerIndex)
+	}
+	parentRoot, err := r.state.LatestBlockHeader.HashTreeRoot()
+	if err != nil {
+		return err
+	}
+	if block.ParentRoot != parentRoot {
+		return fmt.Errorf("%w: slot %d: parent root %#x, state's latest block is %#x", ErrInvalidBlock, block.Slot, block.ParentRoot, parentRoot)
+	}
+	bodyRoot, err := block.Body.HashTreeRoot()
+	if err != nil {
+		return err
+	}
+
+	r.proposerIndex = block.ProposerIndex
+	r.state.LatestBlockHeader = &phase0.BeaconBlockHeader{
+		Slot:          block.Slot,
+		ProposerIndex: block.ProposerIndex,
+		ParentRoot:    block.ParentRoot,
+		BodyRoot:      bodyRoot,
+	}
+	return nil
+}
+
+// expectedWithdrawals is get_expected_withdrawals.
+func (r *replayer) expectedWithdrawals() []*capella.Withdrawal {
+	epoch := r.currentEpoch()
+	withdrawalIndex := r.state.NextWithdrawalIndex
+	validatorIndex := r.state.NextWithdrawalValidatorIndex
+	withdrawals := []*capella.Withdrawal{}
+	bound := min(len(r.state.Validators), MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP)
+	for i := 0; i < bound; i++ {
+		validator := r.state.Validators[validatorIndex]
+		balance := r.state.Balances[validatorIndex]
+		hasETH1Credential := validator.WithdrawalCredentials[0] == ETH1_ADDRESS_WITHDRAWAL_PREFIX
+
+		var amount phase0.Gwei
+		switch {
+		case hasETH1Credential && validator.WithdrawableEpoch <= epoch && balance > 0:
+			amount = balance
+		case hasETH1Credential && validator.EffectiveBalance == MAX_EFFECTIVE_BALANCE && balance > MAX_EFFECTIVE_BALANCE:
+			amount = balance - MAX_EFFECTIVE_BALANCE
+		}
+		if amount > 0 {
+			withdrawal := &capella.Withdrawal{Index: withdrawalIndex, ValidatorIndex: validatorIndex, Amount: amount}
+			copy(withdrawal.Address[:], validator.WithdrawalCredentials[12:])
+			withdrawals = append(withdrawals, withdrawal)
+			withdrawalIndex++
+		}
+		if len(withdrawals) == MAX_WITHDRAWALS_PER_PAYLOAD {
+			break
+		}
+		validatorIndex = phase0.ValidatorIndex((uint64(validatorIndex) + 1) % uint64(len(r.state.Validators)))
+	}
+	return withdrawals
+}
+
+func (r *replayer) processWithdrawals(payload *deneb.ExecutionPayload) error {
+	expected := r.expectedWithdrawals()
+	if len(payload.Withdrawals) != len(expected) {
+		return fmt.Errorf("%w: slot %d: %d withdrawals, expected %d", ErrInvalidBlock, r.state.Slot, len(payload.Withdrawals), len(expected))
+	}
+	for i, withdrawal := range payload.Withdrawals {
+		if *withdrawal != *expected[i] {
+			return fmt.Errorf("%w: slot %d: withdrawal %d isn't the expected one", ErrInvalidBlock, r.state.Slot, withdrawal.Index)
+		}
+		r.decreaseBalance(withdrawal.ValidatorIndex, withdrawal.Amount)
+	}
+
+	if len(expected) != 0 {
+		r.state.NextWithdrawalIndex = expected[len(expected)-1].Index + 1
+	}
+	validators := uint64(len(r.state.Validators))
+	if len(expected) == MAX_WITHDRAWALS_PER_PAYLOAD {
+		r.state.NextWithdrawalValidatorIndex = phase0.ValidatorIndex((uint64(expected[len(expected)-1].ValidatorIndex) + 1) % validators)
+	} else {
+		r.state.NextWithdrawalValidatorIndex = phase0.ValidatorIndex((uint64(r.state.NextWithdrawalValidatorIndex) + MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP) % validators)
+	}
+	return nil
+}
+
+// processExecutionPayload checks the payload against the state, but can't check the payload itself.
+func (r *replayer) processExecutionPayload(body *deneb.BeaconBlockBody) error {
+	payload := body.ExecutionPayload
+	if payload.ParentHash != r.state.LatestExecutionPayloadHeader.BlockHash {
+		return fmt.Errorf("%w: slot %d: execution payload's parent isn't the latest payload", ErrInvalidBlock, r.state.Slot)
+	}
+	if phase0.Root(payload.PrevRandao) != r.randaoMix(r.currentEpoch()) {
+		return fmt.Errorf("%w: slot %d: execution payload's prev randao isn't the state's", ErrInvalidBlock, r.state.Slot)
+	}
+	if payload.Timestamp != r.state.GenesisTime+uint64(r.state.Slot)*r.chain.SecondsPerSlot {
+		return fmt.Errorf("%w: slot %d: execution payload's timestamp %d isn't the slot's", ErrInvalidBlock, r.state.Slot, payload.Timestamp)
+	}
+	if len(body.BlobKZGCommitments) > MAX_BLOBS_PER_BLOCK {
+		return fmt.Errorf("%w: slot %d: %d blobs", ErrInvalidBlock, r.state.Slot, len(body.BlobKZGCommitments))
+	}
+
+	transactionsRoot, err := transactionsRoot(payload.Transactions)
+	if err != nil {
+		return err
+	}
+	withdrawalsRoot, err := withdrawalsRoot(payload.Withdrawals)
+	if err != nil {
+		return err
+	}
+	r.state.LatestExecutionPayloadHeader = &deneb.ExecutionPayloadHeader{
+		ParentHash:       payload.ParentHash,
+		FeeRecipient:     payload.FeeRecipient,
+		StateRoot:        payload.StateRoot,
+		ReceiptsRoot:     payload.ReceiptsRoot,
+		LogsBloom:        payload.LogsBloom,
+		PrevRandao:       payload.PrevRandao,
+		BlockNumber:      payload.BlockNumber,
+		GasLimit:         payload.GasLimit,
+		GasUsed:          payload.GasUsed,
+		Timestamp:        payload.Timestamp,
+		ExtraData:        payload.ExtraData,
+		BaseFeePerGas:    payload.BaseFeePerGas,
+		BlockHash:        payload.BlockHash,
+		TransactionsRoot: transactionsRoot,
+		WithdrawalsRoot:  withdrawalsRoot,
+		BlobGasUsed:      payload.BlobGasUsed,
+		ExcessBlobGas:    payload.ExcessBlobGas,
+	}
+	return nil
+}
+
+// the hash tree root of List[Transaction, MAX_TRANSACTIONS_PER_PAYLOAD], as ExecutionPayload hashes it.
+func transactionsRoot(transactions []bellatrix.Transaction) (phase0.Root, error) {
+	hh := ssz.NewHasher()
+	indx := hh.Index()
+	for _, transaction := range transactions {
+		elemIndx := hh.Index()
+		hh.AppendBytes32(transaction)
+		hh.MerkleizeWithMixin(elemIndx, uint64(len(transaction)), (1073741824+31)/32)
+	}
+	hh.MerkleizeWithMixin(indx, uint64(len(transactions)), 1048576)
+	return hh.HashRoot()
+}
+
+// the hash tree root of List[Withdrawal, MAX_WITHDRAWALS_PER_PAYLOAD].
+func withdrawalsRoot(withdrawals []*capella.Withdrawal) (phase0.Root, error) {
+	hh := ssz.NewHasher()
+	indx := hh.Index()
+	for _, withdrawal := range withdrawals {
+		if err := withdrawal.HashTreeRootWith(hh); err != nil {
+			return phase0.Root{}, err
+		}
+	}
+	hh.MerkleizeWithMixin(indx, uint64(len(withdrawals)), MAX_WITHDRAWALS_PER_PAYLOAD)
+	return hh.HashRoot()
+}
+
+func (r *replayer) processRandao(body *deneb.BeaconBlockBody) {
+	epoch := r.currentEpoch()
+	mix := r.randaoMix(epoch)
+	revealHash := hash(body.RANDAOReveal[:])
+	for i := range mix {
+		mix[i] ^= revealHash[i]
+	}
+	r.state.RANDAOMixes[epoch%EPOCHS_PER_HISTORICAL_VECTOR] = mix
+}
+
+func (r *replayer) processETH1Data(body *deneb.BeaconBlockBody) {
+	r.state.ETH1DataVotes = append(r.state.ETH1DataVotes, body.ETH1Data)
+	votes := 0
+	for _, vote := range r.state.ETH1DataVotes {
+		if eth1DataEqual(vote, body.ETH1Data) {
+			votes++
+		}
+	}
+	if votes*2 > EPOCHS_PER_ETH1_VOTING_PERIOD*SLOTS_PER_EPOCH {
+		r.state.ETH1Data = body.ETH1Data
+	}
+}
+
+func eth1DataEqual(a, b *phase0.ETH1Data) bool {
+	return a.DepositRoot == b.DepositRoot && a.DepositCount == b.DepositCount && bytes.Equal(a.BlockHash, b.BlockHash)
+}
+
+func (r *replayer) processOperations(ctx context.Context, block *deneb.BeaconBlock) error {
+	body := block.Body
+	if want := min(MAX_DEPOSITS, r.state.ETH1Data.DepositCount-r.state.ETH1DepositIndex); uint64(len(body.Deposits)) != want {
+		return fmt.Errorf("%w: slot %d: %d deposits, expected %d", ErrInvalidBlock, block.Slot, len(body.Deposits), want)
+	}
+
+	for _, slashing := range body.ProposerSlashings {
+		r.slashValidator(slashing.SignedHeader1.Message.ProposerIndex)
+	}
+	for _, slashing := range body.AttesterSlashings {
+		r.processAttesterSlashing(slashing)
+	}
+	for _, attestation := range body.Attestations {
+		if err := r.processAttestation(attestation); err != nil {
+			return err
+		}
+	}
+	for _, deposit := range body.Deposits {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.processDeposit(deposit); err != nil {
+			return err
+		}
+	}
+	for _, exit := range body.VoluntaryExits {
+		r.initiateValidatorExit(exit.Message.ValidatorIndex)
+	}
+	for _, change := range body.BLSToExecutionChanges {
+		credentials := make([]byte, 32)
+		credentials[0] = ETH1_ADDRESS_WITHDRAWAL_PREFIX
+		copy(credentials[12:], change.Message.ToExecutionAddress[:])
+		r.state.Validators[change.Message.ValidatorIndex].WithdrawalCredentials = credentials
+	}
+	return nil
+}
+
+func (r *replayer) processAttesterSlashing(slashing *phase0.AttesterSlashing) {
+	attesting := map[uint64]bool{}
+	for _, index := range slashing.Attestation1.AttestingIndices {
+		attesting[index] = true
+	}
+	// the attestations' indices are sorted, so this slashes in the spec's order
+	for _, index := range slashing.Attestation2.AttestingIndices {
+		if attesting[index] && isSlashable(r.state.Validators[index], r.currentEpoch()) {
+			r.slashValidator(phase0.ValidatorIndex(index))
+		}
+	}
+}
+
+func (r *replayer) processAttestation(attestation *phase0.Attestation) error {
+	data := attestation.Data
+	currentEpoch := r.currentEpoch()
+	if data.Target.Epoch != currentEpoch && data.Target.Epoch != r.previousEpoch() {
+		return fmt.Errorf("%w: slot %d: attestation targets epoch %d", ErrInvalidBlock, r.state.Slot, data.Target.Epoch)
+	}
+	if data.Target.Epoch != epochAt(data.Slot) || data.Slot+MIN_ATTESTATION_INCLUSION_DELAY > r.state.Slot {
+		return fmt.Errorf("%w: slot %d: attestation for slot %d can't be included", ErrInvalidBlock, r.state.Slot, data.Slot)
+	}
+	if uint64(data.Index) >= r.committeeCountPerSlot(data.Target.Epoch) {
+		return fmt.Errorf("%w: slot %d: attestation for committee %d", ErrInvalidBlock, r.state.Slot, data.Index)
+	}
+	committee := r.beaconCommittee(data.Slot, data.Index)
+	if attestation.AggregationBits.Len() != uint64(len(committee)) {
+		return fmt.Errorf("%w: slot %d: attestation has %d bits for a committee of %d", ErrInvalidBlock, r.state.Slot, attestation.AggregationBits.Len(), len(committee))
+	}
+
+	flags, err := r.attestationParticipationFlags(data, r.state.Slot-data.Slot)
+	if err != nil {
+		return err
+	}
+
+	participation := r.state.PreviousEpochParticipation
+	if data.Target.Epoch == currentEpoch {
+		participation = r.state.CurrentEpochParticipation
+	}
+	proposerRewardNumerator := phase0.Gwei(0)
+	for i, index := range committee {
+		if !attestation.AggregationBits.BitAt(uint64(i)) {
+			continue
+		}
+		for flag, weight := range PARTICIPATION_FLAG_WEIGHTS {
+			if flags[flag] && !hasFlag(uint8(participation[index]), flag) {
+				participation[index] |= altair.ParticipationFlags(1 << flag)
+				proposerRewardNumerator += r.baseReward(index) * phase0.Gwei(weight)
+			}
+		}
+	}
+
+	proposerRewardDenominator := phase0.Gwei((WEIGHT_DENOMINATOR - PROPOSER_WEIGHT) * WEIGHT_DENOMINATOR / PROPOSER_WEIGHT)
+	r.increaseBalance(r.proposerIndex, proposerRewardNumerator/proposerRewardDenominator)
+	return nil
+}
+
+// attestationParticipationFlags is get_attestation_participation_flag_indices, as a set.
+func (r *replayer) attestationParticipationFlags(data *phase0.AttestationData, inclusionDelay phase0.Slot) ([3]bool, error) {
+	justified := r.state.PreviousJustifiedCheckpoint
+	if data.Target.Epoch == r.currentEpoch() {
+		justified = r.state.CurrentJustifiedCheckpoint
+	}
+	if data.Source.Epoch != justified.Epoch || data.Source.Root != justified.Root {
+		return [3]bool{}, fmt.Errorf("%w: slot %d: attestation's source isn't the justified checkpoint", ErrInvalidBlock, r.state.Slot)
+	}
+	targetRoot, err := r.blockRoot(data.Target.Epoch)
+	if err != nil {
+		return [3]bool{}, err
+	}
+	headRoot, err := r.blockRootAtSlot(data.Slot)
+	if err != nil {
+		return [3]bool{}, err
+	}
+	matchingTarget := data.Target.Root == targetRoot
+	matchingHead := matchingTarget && data.BeaconBlockRoot == headRoot
+
+	var flags [3]bool
+	flags[TIMELY_SOURCE_FLAG_INDEX] = uint64(inclusionDelay) <= integerSquareRoot(SLOTS_PER_EPOCH)
+	flags[TIMELY_TARGET_FLAG_INDEX] = matchingTarget
+	flags[TIMELY_HEAD_FLAG_INDEX] = matchingHead && inclusionDelay == MIN_ATTESTATION_INCLUSION_DELAY
+	return flags, nil
+}
+
+func (r *replayer) processDeposit(deposit *phase0.Deposit) error {
+	leaf, err := deposit.Data.HashTreeRoot()
+	if err != nil {
+		return err
+	}
+	if !isValidMerkleBranch(leaf, deposit.Proof, DEPOSIT_CONTRACT_TREE_DEPTH+1, r.state.ETH1DepositIndex, r.state.ETH1Data.DepositRoot) {
+		return fmt.Errorf("%w: slot %d: deposit %d isn't in the deposit tree", ErrInvalidBlock, r.state.Slot, r.state.ETH1DepositIndex)
+	}
+	r.state.ETH1DepositIndex++
+
+	data := deposit.Data
+	if index, ok := r.validatorIndex(data.PublicKey); ok {
+		r.increaseBalance(index, data.Amount)
+		return nil
+	}
+
+	// a new validator's deposit is only valid with a proof of possession of its key
+	valid, err := r.verifyDepositSignature(data)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return nil
+	}
+
+	index := phase0.ValidatorIndex(len(r.state.Validators))
+	r.state.Validators = append(r.state.Validators, &phase0.Validator{
+		PublicKey:                  data.PublicKey,
+		WithdrawalCredentials:      data.WithdrawalCredentials,
+		ActivationEligibilityEpoch: FAR_FUTURE_EPOCH,
+		ActivationEpoch:            FAR_FUTURE_EPOCH,
+		ExitEpoch:                  FAR_FUTURE_EPOCH,
+		WithdrawableEpoch:          FAR_FUTURE_EPOCH,
+		EffectiveBalance:           min(data.Amount-data.Amount%EFFECTIVE_BALANCE_INCREMENT, MAX_EFFECTIVE_BALANCE),
+	})
+	r.state.Balances = append(r.state.Balances, data.Amount)
+	r.state.PreviousEpochParticipation = append(r.state.PreviousEpochParticipation, 0)
+	r.state.CurrentEpochParticipation = append(r.state.CurrentEpochParticipation, 0)
+	r.state.InactivityScores = append(r.state.InactivityScores, 0)
+	r.pubkeyIndices[data.PublicKey] = index
+	return nil
+}
+
+// isValidMerkleBranch is is_valid_merkle_branch.
+func isValidMerkleBranch(leaf phase0.Root, branch [][]byte, depth int, index uint64, root phase0.Root) bool {
+	if len(branch) != depth {
+		return false
+	}
+	value := leaf
+	for i := 0; i < depth; i++ {
+		if index>>i&1 == 1 {
+			value = hash(branch[i], value[:])
+		} else {
+			value = hash(value[:], branch[i])
+		}
+	}
+	return value == root
+}
+
+func (r *replayer) verifyDepositSignature(data *phase0.DepositData) (bool, error) {
+	domain, err := computeDomain(DOMAIN_DEPOSIT, r.genesisForkVersion, phase0.Root{})
+	if err != nil {
+		return false, err
+	}
+	messageRoot, err := (&phase0.DepositMessage{
+		PublicKey:             data.PublicKey,
+		WithdrawalCredentials: data.WithdrawalCredentials,
+		Amount:                data.Amount,
+	}).HashTreeRoot()
+	if err != nil {
+		return false, err
+	}
+	signingRoot, err := (&phase0.SigningData{ObjectRoot: messageRoot, Domain: domain}).HashTreeRoot()
+	if err != nil {
+		return false, err
+	}
+	return verifySignature(data.PublicKey, signingRoot[:], data.Signature), nil
+}
+
+func (r *replayer) processSyncAggregate(block *deneb.BeaconBlock) error {
+	committeeIndices, err := r.syncCommitteeValidatorIndices()
+	if err != nil {
+		return err
+	}
+
+	totalActiveIncrements := r.totalActiveBalance() / EFFECTIVE_BALANCE_INCREMENT
+	totalBaseRewards := r.baseRewardPerIncrement() * totalActiveIncrements
+	maxParticipantRewards := totalBaseRewards * SYNC_REWARD_WEIGHT / WEIGHT_DENOMINATOR / SLOTS_PER_EPOCH
+	participantReward := maxParticipantRewards / SYNC_COMMITTEE_SIZE
+	proposerReward := participantReward * PROPOSER_WEIGHT / (WEIGHT_DENOMINATOR - PROPOSER_WEIGHT)
+
+	bits := block.Body.SyncAggregate.SyncCommitteeBits
+	for i, index := range committeeIndices {
+		if bits.BitAt(uint64(i)) {
+			r.increaseBalance(index, participantReward)
+			r.increaseBalance(r.proposerIndex, proposerReward)
+		} else {
+			r.decreaseBalance(index, participantReward)
+		}
+	}
+	return nil
+}
+
+// the validator indices of the current sync committee's members, in committee order.
+func (r *replayer) syncCommitteeValidatorIndices() ([]phase0.ValidatorIndex, error) {
+	committee := r.state.CurrentSyncCommittee
+	if r.syncCommitteeIndices != nil && r.syncCommitteeAggregate == committee.AggregatePubkey {
+		return r.syncCommitteeIndices, nil
+	}
+	indices := make([]phase0.ValidatorIndex, len(committee.Pubkeys))
+	for i, pubkey := range committee.Pubkeys {
+		index, ok := r.validatorIndex(pubkey)
+		if !ok {
+			return nil, fmt.Errorf("sync committee member %#x isn't a validator", pubkey)
+		}
+		indices[i] = index
+	}
+	r.syncCommitteeIndices = indices
+	r.syncCommitteeAggregate = committee.AggregatePubkey
+	return indices, nil
+}
@@ -0,0 +1,54 @@
+package transition
+
+import (
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+)
+
+// The ciphersuite of Ethereum's BLS signatures: the proof of possession scheme, with pubkeys in G1.
+var signatureDST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+// verifySignature is the BLS Verify of the consensus specs, which treats any malformed input as an invalid
+// signature.
+func verifySignature(pubkey phase0.BLSPubKey, message []byte, signature phase0.BLSSignature) bool {
+	var pk bls12381.G1Affine
+	if _, err := pk.SetBytes(pubkey[:]); err != nil || pk.IsInfinity() {
+		return false
+	}
+	var sig bls12381.G2Affine
+	if _, err := sig.SetBytes(signature[:]); err != nil {
+		return false
+	}
+	h, err := bls12381.HashToG2(message, signatureDST)
+	if err != nil {
+		return false
+	}
+
+	// e(pk, H(m)) == e(g1, sig)
+	_, _, g1, _ := bls12381.Generators()
+	var negG1 bls12381.G1Affine
+	negG1.Neg(&g1)
+	ok, err := bls12381.PairingCheck([]bls12381.G1Affine{pk, negG1}, []bls12381.G2Affine{h, sig})
+	return err == nil && ok
+}
+
+// aggregatePubkeys is eth_aggregate_pubkeys.
+func aggregatePubkeys(pubkeys []phase0.BLSPubKey) (phase0.BLSPubKey, error) {
+	var sum bls12381.G1Jac
+	for i, pubkey := range pubkeys {
+		var pk bls12381.G1Affine
+		if _, err := pk.SetBytes(pubkey[:]); err != nil {
+			return phase0.BLSPubKey{}, fmt.Errorf("invalid pubkey %#x: %w", pubkey, err)
+		}
+		if i == 0 {
+			sum.FromAffine(&pk)
+		} else {
+			sum.AddMixed(&pk)
+		}
+	}
+	var aggregate bls12381.G1Affine
+	aggregate.FromJacobian(&sum)
+	return phase0.BLSPubKey(aggregate.Bytes()), nil
+}
@@ -0,0 +1,75 @@
+package transition
+
+import (
+	"math"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// The mainnet preset and the config values every supported chain shares. The names are the spec's.
+const (
+	SLOTS_PER_EPOCH                  = 32
+	SLOTS_PER_HISTORICAL_ROOT        = 8192
+	EPOCHS_PER_HISTORICAL_VECTOR     = 65536
+	EPOCHS_PER_SLASHINGS_VECTOR      = 8192
+	EPOCHS_PER_ETH1_VOTING_PERIOD    = 64
+	EPOCHS_PER_SYNC_COMMITTEE_PERIOD = 256
+	SYNC_COMMITTEE_SIZE              = 512
+	MIN_SEED_LOOKAHEAD               = 1
+	MAX_SEED_LOOKAHEAD               = 4
+	MIN_ATTESTATION_INCLUSION_DELAY  = 1
+	MIN_EPOCHS_TO_INACTIVITY_PENALTY = 4
+
+	MAX_COMMITTEES_PER_SLOT = 64
+	TARGET_COMMITTEE_SIZE   = 128
+	SHUFFLE_ROUND_COUNT     = 90
+
+	MAX_EFFECTIVE_BALANCE          = phase0.Gwei(32_000_000_000)
+	EFFECTIVE_BALANCE_INCREMENT    = phase0.Gwei(1_000_000_000)
+	HYSTERESIS_QUOTIENT            = 4
+	HYSTERESIS_DOWNWARD_MULTIPLIER = 1
+	HYSTERESIS_UPWARD_MULTIPLIER   = 5
+
+	BASE_REWARD_FACTOR                         = 64
+	WHISTLEBLOWER_REWARD_QUOTIENT              = 512
+	MIN_SLASHING_PENALTY_QUOTIENT_BELLATRIX    = 32
+	PROPORTIONAL_SLASHING_MULTIPLIER_BELLATRIX = 3
+	INACTIVITY_PENALTY_QUOTIENT_BELLATRIX      = 1 << 24
+
+	MAX_DEPOSITS                         = 16
+	DEPOSIT_CONTRACT_TREE_DEPTH          = 32
+	MAX_WITHDRAWALS_PER_PAYLOAD          = 16
+	MAX_VALIDATORS_PER_WITHDRAWALS_SWEEP = 16384
+	MAX_BLOBS_PER_BLOCK                  = 6
+
+	MIN_PER_EPOCH_CHURN_LIMIT            = 4
+	CHURN_LIMIT_QUOTIENT                 = 65536
+	MAX_PER_EPOCH_ACTIVATION_CHURN_LIMIT = 8
+	MIN_VALIDATOR_WITHDRAWABILITY_DELAY  = 256
+	INACTIVITY_SCORE_BIAS                = 4
+	INACTIVITY_SCORE_RECOVERY_RATE       = 16
+
+	FAR_FUTURE_EPOCH = phase0.Epoch(math.MaxUint64)
+	GENESIS_EPOCH    = phase0.Epoch(0)
+
+	ETH1_ADDRESS_WITHDRAWAL_PREFIX = 0x01
+)
+
+// Participation flags, and the rewards weights.
+const (
+	TIMELY_SOURCE_FLAG_INDEX = 0
+	TIMELY_TARGET_FLAG_INDEX = 1
+	TIMELY_HEAD_FLAG_INDEX   = 2
+
+	SYNC_REWARD_WEIGHT = 2
+	PROPOSER_WEIGHT    = 8
+	WEIGHT_DENOMINATOR = 64
+)
+
+var PARTICIPATION_FLAG_WEIGHTS = [3]uint64{14, 26, 14}
+
+var (
+	DOMAIN_BEACON_ATTESTER = phase0.DomainType{0x01, 0x00, 0x00, 0x00}
+	DOMAIN_DEPOSIT         = phase0.DomainType{0x03, 0x00, 0x00, 0x00}
+	DOMAIN_SYNC_COMMITTEE  = phase0.DomainType{0x07, 0x00, 0x00, 0x00}
+)
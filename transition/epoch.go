@@ -0,0 +1,317 @@
+package transition
+
+import (
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	ssz "github.com/ferranbt/fastssz"
+)
+
+// processEpoch is process_epoch.
+func (r *replayer) processEpoch() error {
+	if err := r.processJustificationAndFinalization(); err != nil {
+		return err
+	}
+	r.processInactivityUpdates()
+	r.processRewardsAndPenalties()
+	r.processRegistryUpdates()
+	r.processSlashings()
+	r.processETH1DataReset()
+	r.processEffectiveBalanceUpdates()
+	r.processSlashingsReset()
+	r.processRandaoMixesReset()
+	if err := r.processHistoricalSummariesUpdate(); err != nil {
+		return err
+	}
+	r.processParticipationFlagUpdates()
+	return r.processSyncCommitteeUpdates()
+}
+
+func (r *replayer) processJustificationAndFinalization() error {
+	if r.currentEpoch() <= GENESIS_EPOCH+1 {
+		return nil
+	}
+	previousTargetBalance := r.totalBalanceOf(r.unslashedParticipating(TIMELY_TARGET_FLAG_INDEX, r.previousEpoch()))
+	currentTargetBalance := r.totalBalanceOf(r.unslashedParticipating(TIMELY_TARGET_FLAG_INDEX, r.currentEpoch()))
+	return r.weighJustificationAndFinalization(r.totalActiveBalance(), previousTargetBalance, currentTargetBalance)
+}
+
+func (r *replayer) weighJustificationAndFinalization(totalActiveBalance, previousTargetBalance, currentTargetBalance phase0.Gwei) error {
+	previousEpoch := r.previousEpoch()
+	currentEpoch := r.currentEpoch()
+	oldPreviousJustified := r.state.PreviousJustifiedCheckpoint
+	oldCurrentJustified := r.state.CurrentJustifiedCheckpoint
+
+	r.state.PreviousJustifiedCheckpoint = r.state.CurrentJustifiedCheckpoint
+	bits := r.state.JustificationBits[0] << 1 & 0x0f
+	if previousTargetBalance*3 >= totalActiveBalance*2 {
+		root, err := r.blockRoot(previousEpoch)
+		if err != nil {
+			return err
+		}
+		r.state.CurrentJustifiedCheckpoint = &phase0.Checkpoint{Epoch: previousEpoch, Root: root}
+		bits |= 1 << 1
+	}
+	if currentTargetBalance*3 >= totalActiveBalance*2 {
+		root, err := r.blockRoot(currentEpoch)
+		if err != nil {
+			return err
+		}
+		r.state.CurrentJustifiedCheckpoint = &phase0.Checkpoint{Epoch: currentEpoch, Root: root}
+		bits |= 1 << 0
+	}
+	r.state.JustificationBits = []byte{bits}
+
+	// whether bits [from, to) are all set
+	all := func(from, to int) bool {
+		for i := from; i < to; i++ {
+			if bits&(1<<i) == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if all(1, 4) && oldPreviousJustified.Epoch+3 == currentEpoch {
+		r.state.FinalizedCheckpoint = oldPreviousJustified
+	}
+	if all(1, 3) && oldPreviousJustified.Epoch+2 == currentEpoch {
+		r.state.FinalizedCheckpoint = oldPreviousJustified
+	}
+	if all(0, 3) && oldCurrentJustified.Epoch+2 == currentEpoch {
+		r.state.FinalizedCheckpoint = oldCurrentJustified
+	}
+	if all(0, 2) && oldCurrentJustified.Epoch+1 == currentEpoch {
+		r.state.FinalizedCheckpoint = oldCurrentJustified
+	}
+	return nil
+}
+
+func (r *replayer) processInactivityUpdates() {
+	if r.currentEpoch() == GENESIS_EPOCH {
+		return
+	}
+	targeting := r.unslashedParticipating(TIMELY_TARGET_FLAG_INDEX, r.previousEpoch())
+	leaking := r.isInInactivityLeak()
+	for _, index := range r.eligibleValidatorIndices() {
+		if targeting[index] {
+			r.state.InactivityScores[index] -= min(1, r.state.InactivityScores[index])
+		} else {
+			r.state.InactivityScores[index] += INACTIVITY_SCORE_BIAS
+		}
+		if !leaking {
+			r.state.InactivityScores[index] -= min(INACTIVITY_SCORE_RECOVERY_RATE, r.state.InactivityScores[index])
+		}
+	}
+}
+
+func (r *replayer) processRewardsAndPenalties() {
+	if r.currentEpoch() == GENESIS_EPOCH {
+		return
+	}
+	eligible := r.eligibleValidatorIndices()
+
+	deltas := [][2][]phase0.Gwei{}
+	for flag := range PARTICIPATION_FLAG_WEIGHTS {
+		deltas = append(deltas, r.flagIndexDeltas(flag, eligible))
+	}
+	deltas = append(deltas, r.inactivityPenaltyDeltas(eligible))
+
+	for _, delta := range deltas {
+		rewards, penalties := delta[0], delta[1]
+		for i := range r.state.Validators {
+			r.increaseBalance(phase0.ValidatorIndex(i), rewards[i])
+			r.decreaseBalance(phase0.ValidatorIndex(i), penalties[i])
+		}
+	}
+}
+
+// flagIndexDeltas is get_flag_index_deltas: the rewards and penalties for one participation flag.
+func (r *replayer) flagIndexDeltas(flag int, eligible []phase0.ValidatorIndex) [2][]phase0.Gwei {
+	rewards := make([]phase0.Gwei, len(r.state.Validators))
+	penalties := make([]phase0.Gwei, len(r.state.Validators))
+
+	participating := r.unslashedParticipating(flag, r.previousEpoch())
+	weight := phase0.Gwei(PARTICIPATION_FLAG_WEIGHTS[flag])
+	participatingIncrements := r.totalBalanceOf(participating) / EFFECTIVE_BALANCE_INCREMENT
+	activeIncrements := r.totalActiveBalance() / EFFECTIVE_BALANCE_INCREMENT
+	leaking := r.isInInactivityLeak()
+	for _, index := range eligible {
+		baseReward := r.baseReward(index)
+		if participating[index] {
+			if !leaking {
+				rewards[index] += baseReward * weight * participatingIncrements / (activeIncrements * WEIGHT_DENOMINATOR)
+			}
+		} else if flag != TIMELY_HEAD_FLAG_INDEX {
+			penalties[index] += baseReward * weight / WEIGHT_DENOMINATOR
+		}
+	}
+	return [2][]phase0.Gwei{rewards, penalties}
+}
+
+// inactivityPenaltyDeltas is get_inactivity_penalty_deltas.
+func (r *replayer) inactivityPenaltyDeltas(eligible []phase0.ValidatorIndex) [2][]phase0.Gwei {
+	rewards := make([]phase0.Gwei, len(r.state.Validators))
+	penalties := make([]phase0.Gwei, len(r.state.Validators))
+
+	targeting := r.unslashedParticipating(TIMELY_TARGET_FLAG_INDEX, r.previousEpoch())
+	for _, index := range eligible {
+		if !targeting[index] {
+			numerator := uint64(r.state.Validators[index].EffectiveBalance) * r.state.InactivityScores[index]
+			penalties[index] += phase0.Gwei(numerator / (INACTIVITY_SCORE_BIAS * INACTIVITY_PENALTY_QUOTIENT_BELLATRIX))
+		}
+	}
+	return [2][]phase0.Gwei{rewards, penalties}
+}
+
+func (r *replayer) processRegistryUpdates() {
+	currentEpoch := r.currentEpoch()
+	for i, validator := range r.state.Validators {
+		if validator.ActivationEligibilityEpoch == FAR_FUTURE_EPOCH && validator.EffectiveBalance == MAX_EFFECTIVE_BALANCE {
+			validator.ActivationEligibilityEpoch = currentEpoch + 1
+		}
+		if isActive(validator, currentEpoch) && validator.EffectiveBalance <= r.ejectionBalance {
+			r.initiateValidatorExit(phase0.ValidatorIndex(i))
+		}
+	}
+
+	queue := []phase0.ValidatorIndex{}
+	for i, validator := range r.state.Validators {
+		if validator.ActivationEligibilityEpoch <= r.state.FinalizedCheckpoint.Epoch && validator.ActivationEpoch == FAR_FUTURE_EPOCH {
+			queue = append(queue, phase0.ValidatorIndex(i))
+		}
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		a, b := r.state.Validators[queue[i]], r.state.Validators[queue[j]]
+		if a.ActivationEligibilityEpoch != b.ActivationEligibilityEpoch {
+			return a.ActivationEligibilityEpoch < b.ActivationEligibilityEpoch
+		}
+		return queue[i] < queue[j]
+	})
+	churn := min(MAX_PER_EPOCH_ACTIVATION_CHURN_LIMIT, r.validatorChurnLimit())
+	for _, index := range queue[:min(uint64(len(queue)), churn)] {
+		r.state.Validators[index].ActivationEpoch = computeActivationExitEpoch(currentEpoch)
+	}
+}
+
+func (r *replayer) processSlashings() {
+	epoch := r.currentEpoch()
+	totalBalance := r.totalActiveBalance()
+	totalSlashings := phase0.Gwei(0)
+	for _, slashing := range r.state.Slashings {
+		totalSlashings += slashing
+	}
+	adjustedTotalSlashingBalance := min(totalSlashings*PROPORTIONAL_SLASHING_MULTIPLIER_BELLATRIX, totalBalance)
+	for i, validator := range r.state.Validators {
+		if validator.Slashed && epoch+EPOCHS_PER_SLASHINGS_VECTOR/2 == validator.WithdrawableEpoch {
+			penaltyNumerator := validator.EffectiveBalance / EFFECTIVE_BALANCE_INCREMENT * adjustedTotalSlashingBalance
+			penalty := penaltyNumerator / totalBalance * EFFECTIVE_BALANCE_INCREMENT
+			r.decreaseBalance(phase0.ValidatorIndex(i), penalty)
+		}
+	}
+}
+
+func (r *replayer) processETH1DataReset() {
+	if (r.currentEpoch()+1)%EPOCHS_PER_ETH1_VOTING_PERIOD == 0 {
+		r.state.ETH1DataVotes = []*phase0.ETH1Data{}
+	}
+}
+
+func (r *replayer) processEffectiveBalanceUpdates() {
+	const hysteresisIncrement = EFFECTIVE_BALANCE_INCREMENT / HYSTERESIS_QUOTIENT
+	const downwardThreshold = hysteresisIncrement * HYSTERESIS_DOWNWARD_MULTIPLIER
+	const upwardThreshold = hysteresisIncrement * HYSTERESIS_UPWARD_MULTIPLIER
+	for i, validator := range r.state.Validators {
+		balance := r.state.Balances[i]
+		if balance+downwardThreshold < validator.EffectiveBalance || validator.EffectiveBalance+upwardThreshold < balance {
+			validator.EffectiveBalance = min(balance-balance%EFFECTIVE_BALANCE_INCREMENT, MAX_EFFECTIVE_BALANCE)
+		}
+	}
+	r.totalActiveBalanceCache = nil
+}
+
+func (r *replayer) processSlashingsReset() {
+	r.state.Slashings[(r.currentEpoch()+1)%EPOCHS_PER_SLASHINGS_VECTOR] = 0
+}
+
+func (r *replayer) processRandaoMixesReset() {
+	next := r.currentEpoch() + 1
+	r.state.RANDAOMixes[next%EPOCHS_PER_HISTORICAL_VECTOR] = r.randaoMix(r.currentEpoch())
+}
+
+func (r *replayer) processHistoricalSummariesUpdate() error {
+	next := r.currentEpoch() + 1
+	if next%(SLOTS_PER_HISTORICAL_ROOT/SLOTS_PER_EPOCH) != 0 {
+		return nil
+	}
+	blockSummaryRoot, err := rootVectorRoot(r.state.BlockRoots)
+	if err != nil {
+		return err
+	}
+	stateSummaryRoot, err := rootVectorRoot(r.state.StateRoots)
+	if err != nil {
+		return err
+	}
+	r.state.HistoricalSummaries = append(r.state.HistoricalSummaries, &capella.HistoricalSummary{
+		BlockSummaryRoot: blockSummaryRoot,
+		StateSummaryRoot: stateSummaryRoot,
+	})
+	return nil
+}
+
+// the hash tree root of a Vector[Root, N].
+func rootVectorRoot(roots []phase0.Root) (phase0.Root, error) {
+	hh := ssz.NewHasher()
+	indx := hh.Index()
+	for _, root := range roots {
+		hh.Append(root[:])
+	}
+	hh.Merkleize(indx)
+	return hh.HashRoot()
+}
+
+func (r *replayer) processParticipationFlagUpdates() {
+	r.state.PreviousEpochParticipation = r.state.CurrentEpochParticipation
+	r.state.CurrentEpochParticipation = make([]altair.ParticipationFlags, len(r.state.Validators))
+}
+
+func (r *replayer) processSyncCommitteeUpdates() error {
+	next := r.currentEpoch() + 1
+	if next%EPOCHS_PER_SYNC_COMMITTEE_PERIOD != 0 {
+		return nil
+	}
+	nextSyncCommittee, err := r.nextSyncCommittee()
+	if err != nil {
+		return err
+	}
+	r.state.CurrentSyncCommittee = r.state.NextSyncCommittee
+	r.state.NextSyncCommittee = nextSyncCommittee
+	return nil
+}
+
+// nextSyncCommittee is get_next_sync_committee.
+func (r *replayer) nextSyncCommittee() (*altair.SyncCommittee, error) {
+	epoch := r.currentEpoch() + 1
+	active := r.activeValidatorIndices(epoch)
+	count := uint64(len(active))
+	seed := r.seed(epoch, DOMAIN_SYNC_COMMITTEE)
+
+	pubkeys := make([]phase0.BLSPubKey, 0, SYNC_COMMITTEE_SIZE)
+	var randomBytes [32]byte
+	for i := uint64(0); len(pubkeys) < SYNC_COMMITTEE_SIZE; i++ {
+		candidate := r.state.Validators[active[computeShuffledIndex(i%count, count, seed)]]
+		if i%32 == 0 {
+			randomBytes = hash(seed[:], uint64Bytes(i/32))
+		}
+		if candidate.EffectiveBalance*255 >= MAX_EFFECTIVE_BALANCE*phase0.Gwei(randomBytes[i%32]) {
+			pubkeys = append(pubkeys, candidate.PublicKey)
+		}
+	}
+
+	aggregate, err := aggregatePubkeys(pubkeys)
+	if err != nil {
+		return nil, err
+	}
+	return &altair.SyncCommittee{Pubkeys: pubkeys, AggregatePubkey: aggregate}, nil
+}
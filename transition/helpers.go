@@ -0,0 +1,330 @@
+package transition
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+func epochAt(slot phase0.Slot) phase0.Epoch {
+	return phase0.Epoch(slot / SLOTS_PER_EPOCH)
+}
+
+func epochStartSlot(epoch phase0.Epoch) phase0.Slot {
+	return phase0.Slot(epoch * SLOTS_PER_EPOCH)
+}
+
+func (r *replayer) currentEpoch() phase0.Epoch {
+	return epochAt(r.state.Slot)
+}
+
+func (r *replayer) previousEpoch() phase0.Epoch {
+	current := r.currentEpoch()
+	if current == GENESIS_EPOCH {
+		return GENESIS_EPOCH
+	}
+	return current - 1
+}
+
+func hash(data ...[]byte) [32]byte {
+	h := sha256.New()
+	for _, d := range data {
+		h.Write(d)
+	}
+	var out [32]byte
+	h.Sum(out[:0])
+	return out
+}
+
+func uint64Bytes(n uint64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, n)
+}
+
+func integerSquareRoot(n uint64) uint64 {
+	if n == ^uint64(0) {
+		return 4294967295
+	}
+	x := n
+	y := (x + 1) / 2
+	for y < x {
+		x = y
+		y = (x + n/x) / 2
+	}
+	return x
+}
+
+func isActive(validator *phase0.Validator, epoch phase0.Epoch) bool {
+	return validator.ActivationEpoch <= epoch && epoch < validator.ExitEpoch
+}
+
+func isSlashable(validator *phase0.Validator, epoch phase0.Epoch) bool {
+	return !validator.Slashed && validator.ActivationEpoch <= epoch && epoch < validator.WithdrawableEpoch
+}
+
+func (r *replayer) activeValidatorIndices(epoch phase0.Epoch) []phase0.ValidatorIndex {
+	if indices, ok := r.activeIndices[epoch]; ok {
+		return indices
+	}
+	indices := []phase0.ValidatorIndex{}
+	for i, validator := range r.state.Validators {
+		if isActive(validator, epoch) {
+			indices = append(indices, phase0.ValidatorIndex(i))
+		}
+	}
+	r.activeIndices[epoch] = indices
+	return indices
+}
+
+func (r *replayer) randaoMix(epoch phase0.Epoch) phase0.Root {
+	return r.state.RANDAOMixes[epoch%EPOCHS_PER_HISTORICAL_VECTOR]
+}
+
+func (r *replayer) seed(epoch phase0.Epoch, domainType phase0.DomainType) [32]byte {
+	mix := r.randaoMix(epoch + EPOCHS_PER_HISTORICAL_VECTOR - MIN_SEED_LOOKAHEAD - 1)
+	return hash(domainType[:], uint64Bytes(uint64(epoch)), mix[:])
+}
+
+func (r *replayer) blockRootAtSlot(slot phase0.Slot) (phase0.Root, error) {
+	if !(slot < r.state.Slot && r.state.Slot <= slot+SLOTS_PER_HISTORICAL_ROOT) {
+		return phase0.Root{}, fmt.Errorf("%w: no block root for slot %d at slot %d", ErrInvalidBlock, slot, r.state.Slot)
+	}
+	return r.state.BlockRoots[slot%SLOTS_PER_HISTORICAL_ROOT], nil
+}
+
+func (r *replayer) blockRoot(epoch phase0.Epoch) (phase0.Root, error) {
+	return r.blockRootAtSlot(epochStartSlot(epoch))
+}
+
+func (r *replayer) committeeCountPerSlot(epoch phase0.Epoch) uint64 {
+	count := uint64(len(r.activeValidatorIndices(epoch))) / SLOTS_PER_EPOCH / TARGET_COMMITTEE_SIZE
+	return max(1, min(MAX_COMMITTEES_PER_SLOT, count))
+}
+
+// shuffling is the epoch's active validators in committee order: compute_committee's
+// `indices[compute_shuffled_index(i, ...)]` for every i at once.
+func (r *replayer) shuffling(epoch phase0.Epoch) []phase0.ValidatorIndex {
+	if shuffled, ok := r.shufflings[epoch]; ok {
+		return shuffled
+	}
+	shuffled := append([]phase0.ValidatorIndex{}, r.activeValidatorIndices(epoch)...)
+	seed := r.seed(epoch, DOMAIN_BEACON_ATTESTER)
+	n := uint64(len(shuffled))
+	if n > 1 {
+		// compute_shuffled_index applies rounds 0 to SHUFFLE_ROUND_COUNT-1 to an index; each round swaps pairs of
+		// positions, so applying them to the list in reverse order moves every index where it belongs.
+		sources := make([][32]byte, (n+255)/256)
+		for round := SHUFFLE_ROUND_COUNT - 1; round >= 0; round-- {
+			pivot := shufflePivot(seed, uint8(round), n)
+			for chunk := range sources {
+				sources[chunk] = hash(seed[:], []byte{uint8(round)}, binary.LittleEndian.AppendUint32(nil, uint32(chunk)))
+			}
+			for i := uint64(0); i < n; i++ {
+				flip := (pivot + n - i) % n
+				if i >= flip {
+					continue
+				}
+				// the pair's bit is at the larger of its two positions
+				if shuffleBit(sources[flip/256], flip) {
+					shuffled[i], shuffled[flip] = shuffled[flip], shuffled[i]
+				}
+			}
+		}
+	}
+	r.shufflings[epoch] = shuffled
+	return shuffled
+}
+
+func shufflePivot(seed [32]byte, round uint8, n uint64) uint64 {
+	pivotHash := hash(seed[:], []byte{round})
+	return binary.LittleEndian.Uint64(pivotHash[:8]) % n
+}
+
+func shuffleBit(source [32]byte, position uint64) bool {
+	return (source[(position%256)/8]>>(position%8))%2 == 1
+}
+
+// computeShuffledIndex is compute_shuffled_index.
+func computeShuffledIndex(index, n uint64, seed [32]byte) uint64 {
+	for round := 0; round < SHUFFLE_ROUND_COUNT; round++ {
+		pivot := shufflePivot(seed, uint8(round), n)
+		flip := (pivot + n - index) % n
+		position := max(index, flip)
+		source := hash(seed[:], []byte{uint8(round)}, binary.LittleEndian.AppendUint32(nil, uint32(position/256)))
+		if shuffleBit(source, position) {
+			index = flip
+		}
+	}
+	return index
+}
+
+func (r *replayer) beaconCommittee(slot phase0.Slot, committeeIndex phase0.CommitteeIndex) []phase0.ValidatorIndex {
+	epoch := epochAt(slot)
+	committeesPerSlot := r.committeeCountPerSlot(epoch)
+	shuffled := r.shuffling(epoch)
+	n := uint64(len(shuffled))
+	count := committeesPerSlot * SLOTS_PER_EPOCH
+	index := uint64(slot%SLOTS_PER_EPOCH)*committeesPerSlot + uint64(committeeIndex)
+	return shuffled[n*index/count : n*(index+1)/count]
+}
+
+func (r *replayer) totalBalance(indices []phase0.ValidatorIndex) phase0.Gwei {
+	total := phase0.Gwei(0)
+	for _, index := range indices {
+		total += r.state.Validators[index].EffectiveBalance
+	}
+	return max(EFFECTIVE_BALANCE_INCREMENT, total)
+}
+
+func (r *replayer) totalActiveBalance() phase0.Gwei {
+	if r.totalActiveBalanceCache == nil {
+		total := r.totalBalance(r.activeValidatorIndices(r.currentEpoch()))
+		r.totalActiveBalanceCache = &total
+	}
+	return *r.totalActiveBalanceCache
+}
+
+func (r *replayer) baseRewardPerIncrement() phase0.Gwei {
+	return EFFECTIVE_BALANCE_INCREMENT * BASE_REWARD_FACTOR / phase0.Gwei(integerSquareRoot(uint64(r.totalActiveBalance())))
+}
+
+func (r *replayer) baseReward(index phase0.ValidatorIndex) phase0.Gwei {
+	increments := r.state.Validators[index].EffectiveBalance / EFFECTIVE_BALANCE_INCREMENT
+	return increments * r.baseRewardPerIncrement()
+}
+
+func (r *replayer) increaseBalance(index phase0.ValidatorIndex, delta phase0.Gwei) {
+	r.state.Balances[index] += delta
+}
+
+func (r *replayer) decreaseBalance(index phase0.ValidatorIndex, delta phase0.Gwei) {
+	if delta > r.state.Balances[index] {
+		r.state.Balances[index] = 0
+	} else {
+		r.state.Balances[index] -= delta
+	}
+}
+
+func computeActivationExitEpoch(epoch phase0.Epoch) phase0.Epoch {
+	return epoch + 1 + MAX_SEED_LOOKAHEAD
+}
+
+func (r *replayer) validatorChurnLimit() uint64 {
+	return max(MIN_PER_EPOCH_CHURN_LIMIT, uint64(len(r.activeValidatorIndices(r.currentEpoch())))/CHURN_LIMIT_QUOTIENT)
+}
+
+func (r *replayer) initiateValidatorExit(index phase0.ValidatorIndex) {
+	validator := r.state.Validators[index]
+	if validator.ExitEpoch != FAR_FUTURE_EPOCH {
+		return
+	}
+
+	exitQueueEpoch := computeActivationExitEpoch(r.currentEpoch())
+	for _, v := range r.state.Validators {
+		if v.ExitEpoch != FAR_FUTURE_EPOCH && v.ExitEpoch > exitQueueEpoch {
+			exitQueueEpoch = v.ExitEpoch
+		}
+	}
+	exitQueueChurn := uint64(0)
+	for _, v := range r.state.Validators {
+		if v.ExitEpoch == exitQueueEpoch {
+			exitQueueChurn++
+		}
+	}
+	if exitQueueChurn >= r.validatorChurnLimit() {
+		exitQueueEpoch++
+	}
+
+	validator.ExitEpoch = exitQueueEpoch
+	validator.WithdrawableEpoch = exitQueueEpoch + MIN_VALIDATOR_WITHDRAWABILITY_DELAY
+}
+
+// slashValidator is slash_validator, rewarding the block's proposer as the whistleblower.
+func (r *replayer) slashValidator(index phase0.ValidatorIndex) {
+	epoch := r.currentEpoch()
+	r.initiateValidatorExit(index)
+	validator := r.state.Validators[index]
+	validator.Slashed = true
+	validator.WithdrawableEpoch = max(validator.WithdrawableEpoch, epoch+EPOCHS_PER_SLASHINGS_VECTOR)
+	r.state.Slashings[epoch%EPOCHS_PER_SLASHINGS_VECTOR] += validator.EffectiveBalance
+	r.decreaseBalance(index, validator.EffectiveBalance/MIN_SLASHING_PENALTY_QUOTIENT_BELLATRIX)
+
+	whistleblowerReward := validator.EffectiveBalance / WHISTLEBLOWER_REWARD_QUOTIENT
+	proposerReward := whistleblowerReward * PROPOSER_WEIGHT / WEIGHT_DENOMINATOR
+	r.increaseBalance(r.proposerIndex, proposerReward)
+	r.increaseBalance(r.proposerIndex, whistleblowerReward-proposerReward)
+}
+
+// validatorIndex looks up a validator by pubkey.
+func (r *replayer) validatorIndex(pubkey phase0.BLSPubKey) (phase0.ValidatorIndex, bool) {
+	if r.pubkeyIndices == nil {
+		r.pubkeyIndices = make(map[phase0.BLSPubKey]phase0.ValidatorIndex, len(r.state.Validators))
+		for i, validator := range r.state.Validators {
+			if _, ok := r.pubkeyIndices[validator.PublicKey]; !ok {
+				r.pubkeyIndices[validator.PublicKey] = phase0.ValidatorIndex(i)
+			}
+		}
+	}
+	index, ok := r.pubkeyIndices[pubkey]
+	return index, ok
+}
+
+// the validators that are active, or slashed and not yet withdrawable, in the previous epoch.
+func (r *replayer) eligibleValidatorIndices() []phase0.ValidatorIndex {
+	previous := r.previousEpoch()
+	indices := []phase0.ValidatorIndex{}
+	for i, validator := range r.state.Validators {
+		if isActive(validator, previous) || (validator.Slashed && previous+1 < validator.WithdrawableEpoch) {
+			indices = append(indices, phase0.ValidatorIndex(i))
+		}
+	}
+	return indices
+}
+
+// unslashedParticipating is get_unslashed_participating_indices, as a set.
+func (r *replayer) unslashedParticipating(flag int, epoch phase0.Epoch) map[phase0.ValidatorIndex]bool {
+	participation := r.state.PreviousEpochParticipation
+	if epoch == r.currentEpoch() {
+		participation = r.state.CurrentEpochParticipation
+	}
+	indices := map[phase0.ValidatorIndex]bool{}
+	for _, index := range r.activeValidatorIndices(epoch) {
+		if hasFlag(uint8(participation[index]), flag) && !r.state.Validators[index].Slashed {
+			indices[index] = true
+		}
+	}
+	return indices
+}
+
+func (r *replayer) totalBalanceOf(indices map[phase0.ValidatorIndex]bool) phase0.Gwei {
+	total := phase0.Gwei(0)
+	for index := range indices {
+		total += r.state.Validators[index].EffectiveBalance
+	}
+	return max(EFFECTIVE_BALANCE_INCREMENT, total)
+}
+
+func hasFlag(flags uint8, flag int) bool {
+	return flags&(1<<flag) != 0
+}
+
+func (r *replayer) finalityDelay() phase0.Epoch {
+	return r.previousEpoch() - r.state.FinalizedCheckpoint.Epoch
+}
+
+func (r *replayer) isInInactivityLeak() bool {
+	return r.finalityDelay() > MIN_EPOCHS_TO_INACTIVITY_PENALTY
+}
+
+// computeDomain is compute_domain.
+func computeDomain(domainType phase0.DomainType, forkVersion phase0.Version, genesisValidatorsRoot phase0.Root) (phase0.Domain, error) {
+	forkDataRoot, err := (&phase0.ForkData{CurrentVersion: forkVersion, GenesisValidatorsRoot: genesisValidatorsRoot}).HashTreeRoot()
+	if err != nil {
+		return phase0.Domain{}, err
+	}
+	var domain phase0.Domain
+	copy(domain[:4], domainType[:])
+	copy(domain[4:], forkDataRoot[:28])
+	return domain, nil
+}
@@ -0,0 +1,215 @@
+// Package transition is the Deneb consensus state transition
+// (https://github.com/ethereum/consensus-specs/tree/dev/specs/deneb), for rebuilding the beacon states between
+// the ones an era archive stores (see era.StateTransition).
+//
+// The blocks it replays come from an archive of the canonical chain, so it doesn't verify their proposers or
+// signatures, nor ask an execution client about their payloads. The one signature it does check is a new
+// validator's deposit, since an invalid one is skipped rather than rejecting the block. era.Archive checks every
+// replay against the state root of the last block it applied, so a state that went wrong is never served.
+package transition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/era"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// ErrUnsupportedFork is returned for states and blocks of forks other than Deneb, and for replays that would
+// cross into the next fork.
+var ErrUnsupportedFork = errors.New("state transition only supports deneb")
+
+// ErrInvalidBlock is returned for a block that can't be applied to the state, e.g one from another chain.
+var ErrInvalidBlock = errors.New("invalid block")
+
+// DEFAULT_EJECTION_BALANCE is mainnet's, for chains that don't set one.
+const DEFAULT_EJECTION_BALANCE = phase0.Gwei(16_000_000_000)
+
+// Transition replays Deneb blocks for one chain.
+type Transition struct {
+	// nil means the chain is looked up from each replayed state's genesis time.
+	chain *chains.Chain
+}
+
+// New returns the state transition for `chain`, or (if it's nil) for whichever registered chain each replayed
+// state belongs to.
+func New(chain *chains.Chain) *Transition {
+	return &Transition{chain: chain}
+}
+
+var _ era.StateTransition = (*Transition)(nil)
+
+func (t *Transition) Replay(state *spec.VersionedBeaconState) (era.Replayer, error) {
+	if state.Version != spec.DataVersionDeneb {
+		return nil, fmt.Errorf("%w: state is %s", ErrUnsupportedFork, state.Version)
+	}
+
+	chain := t.chain
+	if chain == nil {
+		var ok bool
+		if chain, ok = chains.ByGenesisTime(state.Deneb.GenesisTime); !ok {
+			return nil, fmt.Errorf("%w with genesis time %d", chains.ErrUnsupported, state.Deneb.GenesisTime)
+		}
+	}
+	if chain.SlotsPerEpoch != SLOTS_PER_EPOCH {
+		return nil, fmt.Errorf("chain %d: the state transition only supports the mainnet preset (%d slots per epoch)", chain.ChainID, SLOTS_PER_EPOCH)
+	}
+	if err := checkFork(chain, epochAt(state.Deneb.Slot)); err != nil {
+		return nil, err
+	}
+
+	ejectionBalance := phase0.Gwei(chain.EjectionBalance)
+	if ejectionBalance == 0 {
+		ejectionBalance = DEFAULT_EJECTION_BALANCE
+	}
+	var genesisForkVersion phase0.Version
+	copy(genesisForkVersion[:], chain.GenesisForkVersion)
+
+	return &replayer{
+		chain:              chain,
+		ejectionBalance:    ejectionBalance,
+		genesisForkVersion: genesisForkVersion,
+		state:              state.Deneb,
+		activeIndices:      map[phase0.Epoch][]phase0.ValidatorIndex{},
+		shufflings:         map[phase0.Epoch][]phase0.ValidatorIndex{},
+	}, nil
+}
+
+func checkFork(chain *chains.Chain, epoch phase0.Epoch) error {
+	if fork := chain.ForkAt(uint64(epoch)); fork.Name != "deneb" {
+		return fmt.Errorf("%w: chain %d is on %s at epoch %d", ErrUnsupportedFork, chain.ChainID, fork.Name, epoch)
+	}
+	return nil
+}
+
+// replayer is one replay: the state, and what's derived from it.
+type replayer struct {
+	chain              *chains.Chain
+	ejectionBalance    phase0.Gwei
+	genesisForkVersion phase0.Version
+
+	state *deneb.BeaconState
+
+	// the proposer of the block being applied.
+	proposerIndex phase0.ValidatorIndex
+	// the state root the last applied block claims, used instead of hashing the state on the following slot.
+	// The archive checks the last block's claim against the replayed state.
+	blockStateRoot *phase0.Root
+
+	// Validators only become active or exit after a delay (MAX_SEED_LOOKAHEAD), so the active validators (and
+	// their shuffling) for the current epoch and those next to it don't change once computed.
+	activeIndices map[phase0.Epoch][]phase0.ValidatorIndex
+	shufflings    map[phase0.Epoch][]phase0.ValidatorIndex
+	// Effective balances change only in epoch processing, which resets this.
+	totalActiveBalanceCache *phase0.Gwei
+	// Every validator's index by pubkey, built the first time a deposit or sync committee needs it.
+	pubkeyIndices map[phase0.BLSPubKey]phase0.ValidatorIndex
+	// The current sync committee's validator indices, and the aggregate pubkey of the committee they're for.
+	syncCommitteeIndices   []phase0.ValidatorIndex
+	syncCommitteeAggregate phase0.BLSPubKey
+}
+
+func (r *replayer) State() *spec.VersionedBeaconState {
+	return &spec.VersionedBeaconState{Version: spec.DataVersionDeneb, Deneb: r.state}
+}
+
+// ProcessSlots is process_slots.
+func (r *replayer) ProcessSlots(ctx context.Context, slot phase0.Slot) error {
+	if r.state.Slot > slot {
+		return fmt.Errorf("state is at slot %d, past %d", r.state.Slot, slot)
+	}
+	for r.state.Slot < slot {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.processSlot(); err != nil {
+			return err
+		}
+		if (uint64(r.state.Slot)+1)%SLOTS_PER_EPOCH == 0 {
+			if err := checkFork(r.chain, epochAt(r.state.Slot)+1); err != nil {
+				return err
+			}
+			if err := r.processEpoch(); err != nil {
+				return fmt.Errorf("failed to process epoch %d: %w", epochAt(r.state.Slot), err)
+			}
+		}
+		r.state.Slot++
+		r.pruneCaches()
+	}
+	return nil
+}
+
+func (r *replayer) processSlot() error {
+	var previousStateRoot phase0.Root
+	if r.blockStateRoot != nil && r.state.LatestBlockHeader.Slot == r.state.Slot {
+		previousStateRoot = *r.blockStateRoot
+	} else {
+		root, err := r.state.HashTreeRoot()
+		if err != nil {
+			return err
+		}
+		previousStateRoot = root
+	}
+	r.blockStateRoot = nil
+
+	r.state.StateRoots[uint64(r.state.Slot)%SLOTS_PER_HISTORICAL_ROOT] = previousStateRoot
+	if r.state.LatestBlockHeader.StateRoot == (phase0.Root{}) {
+		r.state.LatestBlockHeader.StateRoot = previousStateRoot
+	}
+	previousBlockRoot, err := r.state.LatestBlockHeader.HashTreeRoot()
+	if err != nil {
+		return err
+	}
+	r.state.BlockRoots[uint64(r.state.Slot)%SLOTS_PER_HISTORICAL_ROOT] = previousBlockRoot
+	return nil
+}
+
+// ProcessBlock is process_block, without the checks that need signatures or an execution client.
+func (r *replayer) ProcessBlock(ctx context.Context, versionedBlock *spec.VersionedSignedBeaconBlock) error {
+	if versionedBlock.Version != spec.DataVersionDeneb {
+		return fmt.Errorf("%w: block is %s", ErrUnsupportedFork, versionedBlock.Version)
+	}
+	block := versionedBlock.Deneb.Message
+
+	if err := r.processBlockHeader(block); err != nil {
+		return err
+	}
+	if err := r.processWithdrawals(block.Body.ExecutionPayload); err != nil {
+		return err
+	}
+	if err := r.processExecutionPayload(block.Body); err != nil {
+		return err
+	}
+	r.processRandao(block.Body)
+	r.processETH1Data(block.Body)
+	if err := r.processOperations(ctx, block); err != nil {
+		return err
+	}
+	if err := r.processSyncAggregate(block); err != nil {
+		return err
+	}
+
+	stateRoot := block.StateRoot
+	r.blockStateRoot = &stateRoot
+	return nil
+}
+
+// drops the cached active validators and shufflings of epochs that can no longer be looked up.
+func (r *replayer) pruneCaches() {
+	previous := r.previousEpoch()
+	for epoch := range r.activeIndices {
+		if epoch < previous {
+			delete(r.activeIndices, epoch)
+		}
+	}
+	for epoch := range r.shufflings {
+		if epoch < previous {
+			delete(r.shufflings, epoch)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package transition
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/Layr-Labs/eigenpod-proofs-generation/beacontest"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
+)
+
+// a chain that's been on deneb since genesis, so beacontest's low slots can be replayed.
+var testChain = &chains.Chain{
+	Name:               "test",
+	ChainID:            1337,
+	GenesisTime:        beacontest.DefaultGenesisTime,
+	GenesisForkVersion: hexutil.MustDecode("0x10000000"),
+	SecondsPerSlot:     12,
+	SlotsPerEpoch:      32,
+	Forks:              []chains.Fork{{Name: "deneb", Epoch: 0, Version: hexutil.MustDecode("0x14000000")}},
+}
+
+var testPod = gethcommon.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+// a replayer for a state after the block at `slot`, with 64 validators (the first 8 with 33 ETH and 0x01
+// credentials) who make up the sync committee.
+func newTestReplayer(t *testing.T, slot phase0.Slot) *replayer {
+	t.Helper()
+
+	fixture, err := beacontest.NewStateBuilder(slot).
+		AddValidators(8, beacontest.Validator{Pod: testPod, Balance: 33_000_000_000}).
+		AddValidators(56, beacontest.Validator{}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := fixture.State.Deneb
+	for i := range state.CurrentSyncCommittee.Pubkeys {
+		state.CurrentSyncCommittee.Pubkeys[i] = state.Validators[i%len(state.Validators)].PublicKey
+	}
+	for i := range state.RANDAOMixes {
+		state.RANDAOMixes[i] = hash(uint64Bytes(uint64(i)))
+	}
+	state.PreviousEpochParticipation = make([]altair.ParticipationFlags, len(state.Validators))
+	state.CurrentEpochParticipation = make([]altair.ParticipationFlags, len(state.Validators))
+	state.InactivityScores = make([]uint64, len(state.Validators))
+
+	r, err := New(testChain).Replay(fixture.State)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.(*replayer)
+}
+
+// advances `r` to `slot` and returns a block there that's valid for the replayed state, with an attestation
+// from every committee of the previous slot.
+func nextBlock(t *testing.T, r *replayer, slot phase0.Slot) *spec.VersionedSignedBeaconBlock {
+	t.Helper()
+
+	if err := r.ProcessSlots(context.Background(), slot); err != nil {
+		t.Fatal(err)
+	}
+	parentRoot, err := r.state.LatestBlockHeader.HashTreeRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := beacontest.NewBlock(slot)
+	block.Message.ProposerIndex = phase0.ValidatorIndex(uint64(slot) % uint64(len(r.state.Validators)))
+	block.Message.ParentRoot = parentRoot
+	body := block.Message.Body
+	body.ETH1Data = r.state.ETH1Data
+	payload := body.ExecutionPayload
+	payload.ParentHash = r.state.LatestExecutionPayloadHeader.BlockHash
+	payload.PrevRandao = r.randaoMix(r.currentEpoch())
+	payload.Timestamp = r.state.GenesisTime + uint64(slot)*12
+	payload.BlockHash = phase0.Hash32(hash(uint64Bytes(uint64(slot))))
+	payload.Withdrawals = r.expectedWithdrawals()
+	body.SyncAggregate.SyncCommitteeBits.SetBitAt(0, true)
+
+	attested := slot - 1
+	targetEpoch := epochAt(attested)
+	targetRoot, err := r.blockRoot(targetEpoch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headRoot, err := r.blockRootAtSlot(attested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := r.state.CurrentJustifiedCheckpoint
+	if targetEpoch != r.currentEpoch() {
+		source = r.state.PreviousJustifiedCheckpoint
+	}
+	for index := uint64(0); index < r.committeeCountPerSlot(targetEpoch); index++ {
+		committee := r.beaconCommittee(attested, phase0.CommitteeIndex(index))
+		bits := bitfield.NewBitlist(uint64(len(committee)))
+		for i := range committee {
+			bits.SetBitAt(uint64(i), true)
+		}
+		body.Attestations = append(body.Attestations, &phase0.Attestation{
+			AggregationBits: bits,
+			Data: &phase0.AttestationData{
+				Slot:            attested,
+				Index:           phase0.CommitteeIndex(index),
+				BeaconBlockRoot: headRoot,
+				Source:          source,
+				Target:          &phase0.Checkpoint{Epoch: targetEpoch, Root: targetRoot},
+			},
+		})
+	}
+
+	return &spec.VersionedSignedBeaconBlock{Version: spec.DataVersionDeneb, Deneb: block}
+}
+
+func TestShufflingMatchesComputeShuffledIndex(t *testing.T) {
+	r := newTestReplayer(t, 100)
+	for i := 0; i < 300; i++ {
+		r.state.Validators = append(r.state.Validators, &phase0.Validator{ExitEpoch: FAR_FUTURE_EPOCH})
+	}
+
+	epoch := r.currentEpoch()
+	active := r.activeValidatorIndices(epoch)
+	seed := r.seed(epoch, DOMAIN_BEACON_ATTESTER)
+	shuffled := r.shuffling(epoch)
+	assert.Equal(t, len(active), len(shuffled))
+	for i := range shuffled {
+		assert.Equal(t, active[computeShuffledIndex(uint64(i), uint64(len(active)), seed)], shuffled[i], i)
+	}
+}
+
+func TestReplayAppliesBlocks(t *testing.T) {
+	r := newTestReplayer(t, 95)
+	proposerBalances := map[phase0.ValidatorIndex]phase0.Gwei{}
+
+	for slot := phase0.Slot(97); slot <= 100; slot++ {
+		block := nextBlock(t, r, slot)
+		proposer := block.Deneb.Message.ProposerIndex
+		proposerBalances[proposer] = r.state.Balances[proposer]
+		if err := r.ProcessBlock(context.Background(), block); err != nil {
+			t.Fatal(err)
+		}
+
+		root, err := block.Deneb.Message.Body.HashTreeRoot()
+		assert.Nil(t, err)
+		assert.Equal(t, phase0.Root(root), r.state.LatestBlockHeader.BodyRoot)
+		assert.Equal(t, block.Deneb.Message.Body.ExecutionPayload.BlockHash, r.state.LatestExecutionPayloadHeader.BlockHash)
+	}
+
+	// the epoch boundary was processed on the way to slot 96
+	assert.Equal(t, phase0.Slot(100), r.state.Slot)
+	assert.Equal(t, len(r.state.Validators), len(r.state.CurrentEpochParticipation))
+
+	// the committees of slots 96-99 attested on time, and no one else did
+	attested := map[phase0.ValidatorIndex]bool{}
+	for slot := phase0.Slot(96); slot < 100; slot++ {
+		for _, index := range r.beaconCommittee(slot, 0) {
+			attested[index] = true
+		}
+	}
+	assert.Equal(t, 8, len(attested))
+	for index := range r.state.Validators {
+		flags := altair.ParticipationFlags(0)
+		if attested[phase0.ValidatorIndex(index)] {
+			flags = 0b111
+		}
+		assert.Equal(t, flags, r.state.CurrentEpochParticipation[index], index)
+	}
+	// the 0x01 validators' extra ETH was swept
+	for index := 0; index < 8; index++ {
+		assert.True(t, r.state.Balances[index] < 33_000_000_000, index)
+	}
+	assert.Equal(t, uint64(8), uint64(r.state.NextWithdrawalIndex))
+	// proposers are rewarded for the attestations and sync aggregates they include
+	for proposer, before := range proposerBalances {
+		if proposer >= 8 {
+			assert.True(t, r.state.Balances[proposer] > before, proposer)
+		}
+	}
+}
+
+func TestReplayRejectsInvalidBlocks(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(block *deneb.BeaconBlock)
+	}{
+		{"wrong parent", func(block *deneb.BeaconBlock) { block.ParentRoot[0] ^= 1 }},
+		{"missing withdrawals", func(block *deneb.BeaconBlock) { block.Body.ExecutionPayload.Withdrawals = nil }},
+		{"wrong timestamp", func(block *deneb.BeaconBlock) { block.Body.ExecutionPayload.Timestamp++ }},
+		{"wrong payload parent", func(block *deneb.BeaconBlock) { block.Body.ExecutionPayload.ParentHash[0] ^= 1 }},
+		{"attestation with the wrong source", func(block *deneb.BeaconBlock) {
+			block.Body.Attestations[0].Data.Source = &phase0.Checkpoint{Epoch: 1}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := newTestReplayer(t, 100)
+			block := nextBlock(t, r, 101)
+			test.modify(block.Deneb.Message)
+			err := r.ProcessBlock(context.Background(), block)
+			assert.True(t, errors.Is(err, ErrInvalidBlock), err)
+		})
+	}
+}
+
+func TestReplayRejectsOtherForks(t *testing.T) {
+	_, err := New(chains.Holesky).Replay(newTestReplayer(t, 100).State())
+	assert.True(t, errors.Is(err, ErrUnsupportedFork), err)
+
+	r := newTestReplayer(t, 100)
+	r.chain = &chains.Chain{ChainID: 1337, SlotsPerEpoch: 32, Forks: []chains.Fork{
+		{Name: "deneb", Epoch: 0},
+		{Name: "electra", Epoch: 4},
+	}}
+	err = r.ProcessSlots(context.Background(), 130)
+	assert.True(t, errors.Is(err, ErrUnsupportedFork), err)
+}
+
+func TestEpochProcessingJustifies(t *testing.T) {
+	r := newTestReplayer(t, 95)
+	for slot := phase0.Slot(97); slot < 160; slot++ {
+		if err := r.ProcessBlock(context.Background(), nextBlock(t, r, slot)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.ProcessSlots(context.Background(), 160); err != nil {
+		t.Fatal(err)
+	}
+
+	// epoch 3 (slots 96-127) and epoch 4 had full participation
+	assert.Equal(t, phase0.Epoch(4), r.state.CurrentJustifiedCheckpoint.Epoch)
+	root, err := r.blockRoot(4)
+	assert.Nil(t, err)
+	assert.Equal(t, root, r.state.CurrentJustifiedCheckpoint.Root)
+	assert.Equal(t, phase0.Epoch(3), r.state.PreviousJustifiedCheckpoint.Epoch)
+	assert.Equal(t, phase0.Epoch(3), r.state.FinalizedCheckpoint.Epoch)
+}
+
+// a BLS key pair: the secret key, and the compressed pubkey.
+func newKey(secret int64) (*big.Int, phase0.BLSPubKey) {
+	sk := big.NewInt(secret)
+	var pk bls12381.G1Affine
+	pk.ScalarMultiplicationBase(sk)
+	return sk, phase0.BLSPubKey(pk.Bytes())
+}
+
+func sign(t *testing.T, sk *big.Int, message []byte) phase0.BLSSignature {
+	t.Helper()
+	h, err := bls12381.HashToG2(message, signatureDST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sig bls12381.G2Affine
+	sig.ScalarMultiplication(&h, sk)
+	return phase0.BLSSignature(sig.Bytes())
+}
+
+func TestVerifySignature(t *testing.T) {
+	sk, pk := newKey(123456789)
+	_, otherPK := newKey(987654321)
+	message := []byte("message")
+	signature := sign(t, sk, message)
+
+	assert.True(t, verifySignature(pk, message, signature))
+	assert.False(t, verifySignature(pk, []byte("other message"), signature))
+	assert.False(t, verifySignature(otherPK, message, signature))
+	assert.False(t, verifySignature(beacontest.Pubkey(0), message, signature))
+	assert.False(t, verifySignature(pk, message, phase0.BLSSignature{}))
+}
+
+func TestAggregatePubkeys(t *testing.T) {
+	_, pk1 := newKey(1000)
+	_, pk2 := newKey(2000)
+	_, pk3 := newKey(4000)
+	_, sum := newKey(7000)
+
+	aggregate, err := aggregatePubkeys([]phase0.BLSPubKey{pk1, pk2, pk3})
+	assert.Nil(t, err)
+	assert.Equal(t, sum, aggregate)
+
+	_, err = aggregatePubkeys([]phase0.BLSPubKey{pk1, beacontest.Pubkey(0)})
+	assert.NotNil(t, err)
+}
+
+// sets up the state's deposit tree to hold just `data`, and returns its deposit.
+func singleDeposit(t *testing.T, r *replayer, data *phase0.DepositData) *phase0.Deposit {
+	t.Helper()
+
+	leaf, err := data.HashTreeRoot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof := [][]byte{}
+	root := leaf
+	zero := phase0.Root{}
+	for i := 0; i < DEPOSIT_CONTRACT_TREE_DEPTH; i++ {
+		proof = append(proof, append([]byte{}, zero[:]...))
+		root = hash(root[:], zero[:])
+		zero = hash(zero[:], zero[:])
+	}
+	length := make([]byte, 32)
+	binary.LittleEndian.PutUint64(length, 1)
+	proof = append(proof, length)
+	root = hash(root[:], length)
+
+	r.state.ETH1Data = &phase0.ETH1Data{DepositRoot: root, DepositCount: 1, BlockHash: make([]byte, 32)}
+	r.state.ETH1DepositIndex = 0
+	return &phase0.Deposit{Proof: proof, Data: data}
+}
+
+func TestDeposits(t *testing.T) {
+	sk, pk := newKey(42)
+	data := &phase0.DepositData{
+		PublicKey:             pk,
+		WithdrawalCredentials: beacontest.WithdrawalCredentials(testPod),
+		Amount:                32_000_000_000,
+	}
+	domain, err := computeDomain(DOMAIN_DEPOSIT, phase0.Version{0x10, 0x00, 0x00, 0x00}, phase0.Root{})
+	assert.Nil(t, err)
+	messageRoot, err := (&phase0.DepositMessage{PublicKey: pk, WithdrawalCredentials: data.WithdrawalCredentials, Amount: data.Amount}).HashTreeRoot()
+	assert.Nil(t, err)
+	signingRoot, err := (&phase0.SigningData{ObjectRoot: messageRoot, Domain: domain}).HashTreeRoot()
+	assert.Nil(t, err)
+	signed := *data
+	signed.Signature = sign(t, sk, signingRoot[:])
+
+	t.Run("a new validator", func(t *testing.T) {
+		r := newTestReplayer(t, 100)
+		validators := len(r.state.Validators)
+		assert.Nil(t, r.processDeposit(singleDeposit(t, r, &signed)))
+		assert.Equal(t, validators+1, len(r.state.Validators))
+		assert.Equal(t, pk, r.state.Validators[validators].PublicKey)
+		assert.Equal(t, MAX_EFFECTIVE_BALANCE, r.state.Validators[validators].EffectiveBalance)
+		assert.Equal(t, uint64(1), r.state.ETH1DepositIndex)
+	})
+
+	t.Run("a new validator without proof of possession", func(t *testing.T) {
+		r := newTestReplayer(t, 100)
+		validators := len(r.state.Validators)
+		assert.Nil(t, r.processDeposit(singleDeposit(t, r, data)))
+		assert.Equal(t, validators, len(r.state.Validators))
+		assert.Equal(t, uint64(1), r.state.ETH1DepositIndex)
+	})
+
+	t.Run("a top-up", func(t *testing.T) {
+		r := newTestReplayer(t, 100)
+		topUp := &phase0.DepositData{PublicKey: r.state.Validators[10].PublicKey, WithdrawalCredentials: make([]byte, 32), Amount: 1_000_000_000}
+		before := r.state.Balances[10]
+		assert.Nil(t, r.processDeposit(singleDeposit(t, r, topUp)))
+		assert.Equal(t, before+1_000_000_000, r.state.Balances[10])
+	})
+
+	t.Run("not in the deposit tree", func(t *testing.T) {
+		r := newTestReplayer(t, 100)
+		deposit := singleDeposit(t, r, &signed)
+		deposit.Proof[0][0] = 1
+		assert.True(t, errors.Is(r.processDeposit(deposit), ErrInvalidBlock))
+	})
+}
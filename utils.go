@@ -7,6 +7,7 @@ import (
 	"math/bits"
 
 	beacon "github.com/Layr-Labs/eigenpod-proofs-generation/beacon"
+	"github.com/Layr-Labs/eigenpod-proofs-generation/chains"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -90,12 +91,23 @@ func NextPowerOfTwo(v uint64) uint {
 	return uint(v)
 }
 
+// GetSlotTimestamp is the timestamp of `blockHeader`'s slot, on the chain `beaconState` is from (looked up by its
+// genesis time). It's an error if the chain isn't registered.
 func GetSlotTimestamp(beaconState *spec.VersionedBeaconState, blockHeader *phase0.BeaconBlockHeader) (uint64, error) {
 	genesisTime, err := beacon.GetGenesisTime(beaconState)
 	if err != nil {
 		return 0, err
 	}
-	return genesisTime + uint64(blockHeader.Slot)*12, nil
+	chain, ok := chains.ByGenesisTime(genesisTime)
+	if !ok {
+		return 0, fmt.Errorf("%w: no chain has genesis time %d (register it with a chains file)", chains.ErrUnsupported, genesisTime)
+	}
+	return GetSlotTimestampOnChain(chain, blockHeader), nil
+}
+
+// GetSlotTimestampOnChain is the timestamp of `blockHeader`'s slot on `chain`.
+func GetSlotTimestampOnChain(chain *chains.Chain, blockHeader *phase0.BeaconBlockHeader) uint64 {
+	return chain.SlotTimestamp(uint64(blockHeader.Slot))
 }
 
 func ConvertValidatorToValidatorFields(v *phase0.Validator) []Bytes32 {